@@ -0,0 +1,273 @@
+package featbit
+
+import (
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/types/data"
+	"github.com/featbit/featbit-go-sdk/internal/types/insight"
+	"github.com/featbit/featbit-go-sdk/internal/util/log"
+	"runtime"
+	"sync"
+)
+
+const defaultBatchBufferSize = 1024
+
+// BatchInsightMode specifies how the insight events are generated in a batch evaluation
+type BatchInsightMode int
+
+const (
+	// BatchInsightAggregated sends one insight event per user, which contains all the flags evaluated for this user
+	BatchInsightAggregated BatchInsightMode = iota
+	// BatchInsightSuppressed doesn't send any insight event back to feature flag center
+	BatchInsightSuppressed
+)
+
+// BatchOptions exposes the options of FBClient.BatchVariations
+//
+//	options := BatchOptions{Workers: 8, InsightMode: BatchInsightSuppressed}
+type BatchOptions struct {
+	// Workers the number of go routines evaluating the users in parallel
+	//
+	// Setting this to a zero or negative value will use the number of CPUs
+	Workers int
+	// BufferSize the number of results that can be computed ahead of the consumer
+	//
+	// Setting this to a zero or negative value will use the default size 1024
+	BufferSize int
+	// InsightMode how the insight events are handled, BatchInsightAggregated by default
+	InsightMode BatchInsightMode
+}
+
+// UserIterator is a source of FBUser consumed by FBClient.BatchVariations.
+//
+// Next returns the next user and true, or false if there is no more user.
+// Next is called from a single go routine.
+type UserIterator interface {
+	Next() (FBUser, bool)
+}
+
+type sliceUserIterator struct {
+	users []FBUser
+	index int
+}
+
+func (s *sliceUserIterator) Next() (FBUser, bool) {
+	if s.index >= len(s.users) {
+		return FBUser{}, false
+	}
+	user := s.users[s.index]
+	s.index++
+	return user, true
+}
+
+// UsersFromSlice returns an UserIterator over a slice of FBUser
+func UsersFromSlice(users []FBUser) UserIterator {
+	return &sliceUserIterator{users: users}
+}
+
+type chanUserIterator struct {
+	ch <-chan FBUser
+}
+
+func (c chanUserIterator) Next() (FBUser, bool) {
+	user, ok := <-c.ch
+	return user, ok
+}
+
+// UsersFromChannel returns an UserIterator that receives users from a channel until the channel is closed
+func UsersFromChannel(ch <-chan FBUser) UserIterator {
+	return chanUserIterator{ch: ch}
+}
+
+// BatchResult is the evaluation result of all the requested flags for a given user.
+//
+// Details and Errors are in the same order as the flag keys passed to FBClient.BatchVariations.
+// The Variation of each interfaces.EvalDetail is the string representation of the flag value, as returned by FBClient.Variation,
+// or the empty string if the evaluation failed.
+type BatchResult struct {
+	User    FBUser
+	Details []EvalDetail
+	Errors  []error
+}
+
+// BatchResults is a streaming iterator of BatchResult.
+// The results are not guaranteed to be in the same order as the users.
+//
+//	results, _ := client.BatchVariations(flagKeys, featbit.UsersFromSlice(users), featbit.BatchOptions{})
+//	defer results.Close()
+//	for results.Next() {
+//	    res := results.Result()
+//	    // do something
+//	}
+type BatchResults struct {
+	resultCh  <-chan BatchResult
+	current   BatchResult
+	closeCh   chan struct{}
+	closeOnce sync.Once
+}
+
+// Next advances to the next result, it blocks until a result is available.
+// Returns false if all the users have been evaluated or the iterator is closed.
+func (b *BatchResults) Next() bool {
+	// the buffered results are discarded once closed
+	select {
+	case <-b.closeCh:
+		return false
+	default:
+	}
+	select {
+	case res, ok := <-b.resultCh:
+		if !ok {
+			return false
+		}
+		b.current = res
+		return true
+	case <-b.closeCh:
+		return false
+	}
+}
+
+// Result returns the current result
+func (b *BatchResults) Result() BatchResult {
+	return b.current
+}
+
+// Close stops the batch evaluation, the remaining users will not be evaluated.
+// It's safe to call Close more than once.
+func (b *BatchResults) Close() {
+	b.closeOnce.Do(func() {
+		close(b.closeCh)
+	})
+}
+
+type batchEvaluation struct {
+	client   *FBClient
+	flagKeys []string
+	// flags are resolved once before the evaluation, nil if not found
+	flags   []*data.FeatureFlag
+	options BatchOptions
+	stale   bool
+}
+
+func (be *batchEvaluation) evaluateUser(user *FBUser) BatchResult {
+	res := BatchResult{
+		User:    *user,
+		Details: make([]EvalDetail, len(be.flags)),
+		Errors:  make([]error, len(be.flags)),
+	}
+	if !user.IsValid() {
+		for i, key := range be.flagKeys {
+			res.Details[i] = EvalDetail{Reason: ReasonUserNotSpecified, KeyName: key, Name: FlagNameUnknown}
+			res.Errors[i] = userInvalid
+		}
+		return res
+	}
+	var event Event
+	if be.options.InsightMode == BatchInsightAggregated {
+		event = insight.NewFlagEvent(insight.ConvertFBUserToEventUser(user))
+	}
+	// the segments are matched once for all the flags
+	segments := be.client.evaluator.segmentResultsOf(user)
+	for i, flag := range be.flags {
+		if er, ok := be.client.evaluateOverride(be.flagKeys[i], user, nil); ok {
+			res.Details[i] = EvalDetail{Variation: er.fv, Reason: er.reason, KeyName: er.keyName, Name: er.name}
+			continue
+		}
+		if flag == nil {
+			res.Details[i] = EvalDetail{Reason: ReasonFlagNotFound, KeyName: be.flagKeys[i], Name: FlagNameUnknown}
+			res.Errors[i] = flagNotFound
+			continue
+		}
+		er := be.client.evaluator.evaluateWith(flag, user, event, segments)
+		if er == nil || !er.success {
+			res.Details[i] = EvalDetail{Reason: ReasonError, KeyName: flag.Key, Name: flag.Name}
+			res.Errors[i] = evalFailed
+			continue
+		}
+		res.Details[i] = EvalDetail{Variation: er.fv, Reason: er.reason, KeyName: er.keyName, Name: er.name, Stale: be.stale || er.stale}
+	}
+	if event != nil && event.IsSendEvent() {
+		be.client.sendEvent(event)
+	}
+	return res
+}
+
+func (be *batchEvaluation) run(users UserIterator, resultCh chan<- BatchResult, closeCh <-chan struct{}) {
+	workers := be.options.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	userCh := make(chan FBUser, workers)
+	// producer
+	go func() {
+		defer close(userCh)
+		for {
+			user, ok := users.Next()
+			if !ok {
+				return
+			}
+			select {
+			case userCh <- user:
+			case <-closeCh:
+				return
+			}
+		}
+	}()
+	// workers
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for user := range userCh {
+				res := be.evaluateUser(&user)
+				select {
+				case resultCh <- res:
+				case <-closeCh:
+					// drain the producer
+					for range userCh {
+					}
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(resultCh)
+}
+
+// BatchVariations evaluates a set of feature flags for many users in parallel, this method is designed for the batch jobs
+// that evaluate a handful of flags for a large number of users.
+//
+// The flags are resolved only once at the beginning of the batch, the changes received afterwards are not taken into account.
+// Depending on BatchOptions.InsightMode, the insight events are either aggregated in one event per user
+// or not sent at all.
+//
+// The returned BatchResults must be consumed or closed, otherwise the evaluation go routines will be blocked.
+// An error is returned if the client is not initialized.
+func (client *FBClient) BatchVariations(flagKeys []string, users UserIterator, options BatchOptions) (*BatchResults, error) {
+	if !client.isReadyToEvaluate() {
+		log.LogWarn("FB GO SDK: batch evaluation is called before GO SDK client is initialized")
+		return nil, clientNotInitialized
+	}
+	be := &batchEvaluation{
+		client:   client,
+		flagKeys: flagKeys,
+		flags:    make([]*data.FeatureFlag, len(flagKeys)),
+		options:  options,
+		stale:    client.isStale(),
+	}
+	for i, key := range flagKeys {
+		be.flags[i] = client.getFlag(key)
+		if be.flags[i] == nil {
+			log.LogWarn("FB Go SDK: unknown feature flag %v in batch evaluation", key)
+		}
+	}
+	bufferSize := options.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultBatchBufferSize
+	}
+	resultCh := make(chan BatchResult, bufferSize)
+	results := &BatchResults{resultCh: resultCh, closeCh: make(chan struct{})}
+	go be.run(users, resultCh, results.closeCh)
+	return results, nil
+}
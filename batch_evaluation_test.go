@@ -0,0 +1,167 @@
+package featbit
+
+import (
+	"encoding/json"
+	"github.com/featbit/featbit-go-sdk/fixtures"
+	"github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/datastorage"
+	"github.com/featbit/featbit-go-sdk/internal/datasynchronization"
+	insight2 "github.com/featbit/featbit-go-sdk/internal/insight"
+	"github.com/featbit/featbit-go-sdk/internal/types/insight"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestBatchVariations(t *testing.T) {
+	config := FBConfig{Offline: true, StartWait: 1 * time.Millisecond}
+	client, _ := MakeCustomFBClient(fakeEnvSecret, "ws://fake-url", "http://fake-url", config)
+	jsonBytes, _ := fixtures.LoadFBClientTestData()
+	ok, err := client.InitializeFromExternalJson(string(jsonBytes))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	flagKeys := []string{"ff-test-bool", "ff-test-string", "ff-not-existed"}
+
+	t.Run("evaluate users", func(t *testing.T) {
+		users := []interfaces.FBUser{testUser1, testUser3, testUser5, testUser7}
+		results, err := client.BatchVariations(flagKeys, UsersFromSlice(users), BatchOptions{Workers: 2})
+		require.NoError(t, err)
+		defer results.Close()
+		got := make(map[string]BatchResult)
+		for results.Next() {
+			res := results.Result()
+			got[res.User.GetKey()] = res
+		}
+		assert.Equal(t, len(users), len(got))
+		for _, user := range users {
+			res := got[user.GetKey()]
+			require.Equal(t, len(flagKeys), len(res.Details))
+			boolValue, _, _ := client.BoolVariation("ff-test-bool", user, false)
+			assert.Equal(t, strconv.FormatBool(boolValue), res.Details[0].Variation)
+			assert.NoError(t, res.Errors[0])
+			stringValue, detail, _ := client.Variation("ff-test-string", user, "error")
+			assert.Equal(t, stringValue, res.Details[1].Variation)
+			assert.Equal(t, detail.Reason, res.Details[1].Reason)
+			assert.NoError(t, res.Errors[1])
+			assert.Equal(t, flagNotFound, res.Errors[2])
+			assert.Equal(t, ReasonFlagNotFound, res.Details[2].Reason)
+		}
+	})
+	t.Run("evaluate users from channel", func(t *testing.T) {
+		ch := make(chan interfaces.FBUser)
+		go func() {
+			for i := 0; i < 100; i++ {
+				user, _ := interfaces.NewUserBuilder("user-" + strconv.Itoa(i)).Build()
+				ch <- user
+			}
+			close(ch)
+		}()
+		results, err := client.BatchVariations(flagKeys, UsersFromChannel(ch), BatchOptions{BufferSize: 10})
+		require.NoError(t, err)
+		defer results.Close()
+		count := 0
+		for results.Next() {
+			count++
+		}
+		assert.Equal(t, 100, count)
+	})
+	t.Run("invalid user", func(t *testing.T) {
+		results, err := client.BatchVariations(flagKeys, UsersFromSlice([]interfaces.FBUser{{}}), BatchOptions{})
+		require.NoError(t, err)
+		defer results.Close()
+		require.True(t, results.Next())
+		res := results.Result()
+		for i := range flagKeys {
+			assert.Equal(t, userInvalid, res.Errors[i])
+			assert.Equal(t, ReasonUserNotSpecified, res.Details[i].Reason)
+		}
+		assert.False(t, results.Next())
+	})
+	t.Run("close before the end", func(t *testing.T) {
+		users := make([]interfaces.FBUser, 1000)
+		for i := range users {
+			users[i], _ = interfaces.NewUserBuilder("user-" + strconv.Itoa(i)).Build()
+		}
+		results, err := client.BatchVariations(flagKeys, UsersFromSlice(users), BatchOptions{Workers: 1, BufferSize: 1})
+		require.NoError(t, err)
+		assert.True(t, results.Next())
+		results.Close()
+		assert.False(t, results.Next())
+	})
+	t.Run("buffered results are discarded once closed", func(t *testing.T) {
+		users := []interfaces.FBUser{testUser1, testUser3, testUser5, testUser7}
+		results, err := client.BatchVariations(flagKeys, UsersFromSlice(users), BatchOptions{BufferSize: 10})
+		require.NoError(t, err)
+		assert.Eventually(t, func() bool { return len(results.resultCh) == len(users) }, time.Second, time.Millisecond)
+		results.Close()
+		for i := 0; i < len(users); i++ {
+			assert.False(t, results.Next())
+		}
+	})
+	_ = client.Close()
+}
+
+func TestBatchVariationsInsight(t *testing.T) {
+	parseFlagEvent := func(bytes []byte) []interfaces.Event {
+		var events []*insight.FlagEvent
+		_ = json.Unmarshal(bytes, &events)
+		ret := make([]interfaces.Event, len(events))
+		for i, event := range events {
+			ret[i] = event
+		}
+		return ret
+	}
+	newClient := func(sender *insight2.MockSender) *FBClient {
+		config := FBConfig{
+			StartWait:               200 * time.Millisecond,
+			DataStorageFactory:      datastorage.NewMockDataStorageBuilder(),
+			DataSynchronizerFactory: datasynchronization.NewMockStreamingBuilder(true, true, 10*time.Millisecond),
+			InsightProcessorFactory: insight2.NewMockInsightProcessorFactory(sender, 100, 100*time.Millisecond),
+		}
+		client, err := MakeCustomFBClient(fakeEnvSecret, "ws://fake-url", "http://fake-url", config)
+		require.NoError(t, err)
+		return client
+	}
+	flagKeys := []string{"ff-test-bool", "ff-test-string"}
+
+	t.Run("aggregated events", func(t *testing.T) {
+		sender := insight2.NewMockSender()
+		sender.SetParseJson(parseFlagEvent)
+		client := newClient(sender)
+		results, err := client.BatchVariations(flagKeys, UsersFromSlice([]interfaces.FBUser{testUser1}), BatchOptions{})
+		require.NoError(t, err)
+		for results.Next() {
+		}
+		info, ok := sender.GetLatestSendingInfo(200 * time.Millisecond)
+		assert.True(t, ok)
+		assert.Equal(t, 1, info.Size())
+		assert.True(t, info.Contains("test-user-1"))
+		_ = client.Close()
+	})
+	t.Run("suppressed events", func(t *testing.T) {
+		sender := insight2.NewMockSender()
+		sender.SetParseJson(parseFlagEvent)
+		client := newClient(sender)
+		results, err := client.BatchVariations(flagKeys, UsersFromSlice([]interfaces.FBUser{testUser1}), BatchOptions{InsightMode: BatchInsightSuppressed})
+		require.NoError(t, err)
+		for results.Next() {
+		}
+		_, ok := sender.GetLatestSendingInfo(200 * time.Millisecond)
+		assert.False(t, ok)
+		_ = client.Close()
+	})
+	t.Run("client not initialized", func(t *testing.T) {
+		config := FBConfig{
+			StartWait:               0,
+			DataStorageFactory:      datastorage.NewMockDataStorageBuilder(),
+			DataSynchronizerFactory: datasynchronization.NewMockStreamingBuilder(false, false, 10*time.Millisecond),
+			InsightProcessorFactory: insight2.NewMockInsightProcessorFactory(nil, 100, 100*time.Millisecond),
+		}
+		client, _ := MakeCustomFBClient(fakeEnvSecret, "ws://fake-url", "http://fake-url", config)
+		_, err := client.BatchVariations(flagKeys, UsersFromSlice([]interfaces.FBUser{testUser1}), BatchOptions{})
+		assert.Equal(t, clientNotInitialized, err)
+		_ = client.Close()
+	})
+}
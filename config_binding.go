@@ -0,0 +1,245 @@
+package featbit
+
+import (
+	"encoding/json"
+	"fmt"
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/util/log"
+	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const configBindingTag = "featbit"
+
+var (
+	bindingTargetInvalid = fmt.Errorf("binding target should be a non-nil pointer to struct")
+	bindingTypeMismatch  = fmt.Errorf("binding output type doesn't match the bound struct")
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// ConfigBindingOptions exposes the options of FBClient.BindConfig
+type ConfigBindingOptions struct {
+	// Validate is called with a pointer to each newly built configuration before it is published.
+	// If it returns an error, the new configuration is discarded and the previous one is kept.
+	Validate func(config interface{}) error
+	// OnChange is called with a pointer to each newly published configuration.
+	// It's called from the refreshing go routine, the configuration should not be modified.
+	OnChange func(config interface{})
+}
+
+type validationError struct {
+	err error
+}
+
+type bindingField struct {
+	index   int
+	flagKey string
+}
+
+// ConfigBinding is a struct bound to the feature flags by FBClient.BindConfig.
+//
+// The configuration is rebuilt and atomically replaced each time the feature flags are changed, the readers
+// always get a complete configuration, either the previous one or the new one.
+type ConfigBinding struct {
+	client   *FBClient
+	user     FBUser
+	typ      reflect.Type
+	defaults reflect.Value
+	fields   []bindingField
+	options  ConfigBindingOptions
+	current  atomic.Value
+	lastErr  atomic.Value // stores validationError
+	lock     sync.Mutex
+	cancel   func()
+	done     chan struct{}
+}
+
+// BindConfig binds a struct to the feature flags for a given user, it's useful when the feature flags are used as a remote configuration.
+//
+// The target is a pointer to a struct whose fields are tagged with the flag keys, the values of the target are used as the defaults
+// when a flag is not found or its value can't be converted to the field type.
+// The string, bool and numeric fields are parsed from the flag value, time.Duration fields accept the duration strings such as "1m30s",
+// the other fields are decoded from the json value.
+//
+//	type Settings struct {
+//	    Timeout time.Duration `featbit:"timeout"`
+//	    Retries int           `featbit:"retries"`
+//	    Limits  Limits        `featbit:"limits"`
+//	}
+//	binding, err := client.BindConfig(user, &Settings{Retries: 3}, ConfigBindingOptions{})
+//	var settings Settings
+//	_ = binding.Get(&settings)
+//
+// The binding is refreshed each time the data synchronizer applies a change, until the binding or the client is closed.
+// The evaluations of the binding don't send insight events.
+//
+// If the first configuration is rejected by ConfigBindingOptions.Validate, it still returns a binding that
+// holds the default values, and the error of the validation.
+func (client *FBClient) BindConfig(user FBUser, target interface{}, options ConfigBindingOptions) (*ConfigBinding, error) {
+	if client.subscribeDataUpdate == nil {
+		return nil, emptyClient
+	}
+	if !user.IsValid() {
+		return nil, userInvalid
+	}
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil, bindingTargetInvalid
+	}
+	typ := v.Elem().Type()
+	defaults := reflect.New(typ).Elem()
+	defaults.Set(v.Elem())
+	binding := &ConfigBinding{
+		client:   client,
+		user:     user,
+		typ:      typ,
+		defaults: defaults,
+		options:  options,
+		done:     make(chan struct{}),
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if flagKey := field.Tag.Get(configBindingTag); flagKey != "" && flagKey != "-" && field.PkgPath == "" {
+			binding.fields = append(binding.fields, bindingField{index: i, flagKey: flagKey})
+		}
+	}
+	// the default values are published if the first configuration is invalid
+	binding.current.Store(defaults.Addr().Interface())
+	updated, cancel := client.subscribeDataUpdate()
+	binding.cancel = cancel
+	err := binding.Refresh()
+	go func() {
+		defer close(binding.done)
+		for range updated {
+			binding.Refresh()
+		}
+	}()
+	return binding, err
+}
+
+// Refresh rebuilds the configuration from the latest feature flags,
+// returns the error of the validation if the new configuration is discarded.
+//
+// It's not necessary to call it, unless you want to take the changes of the overrides into account.
+func (b *ConfigBinding) Refresh() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	config := reflect.New(b.typ)
+	config.Elem().Set(b.defaults)
+	for _, f := range b.fields {
+		value, ok := b.evaluate(f.flagKey)
+		if !ok {
+			continue
+		}
+		if err := setFieldValue(config.Elem().Field(f.index), value); err != nil {
+			log.LogWarn("FB GO SDK: flag %v can't be bound to config field %v, using default value: %v", f.flagKey, b.typ.Field(f.index).Name, err)
+		}
+	}
+	newConfig := config.Interface()
+	if b.options.Validate != nil {
+		if err := b.options.Validate(newConfig); err != nil {
+			log.LogWarn("FB GO SDK: invalid config, keeping the previous one: %v", err)
+			b.lastErr.Store(validationError{err})
+			return err
+		}
+	}
+	b.current.Store(newConfig)
+	b.lastErr.Store(validationError{})
+	if b.options.OnChange != nil {
+		b.options.OnChange(newConfig)
+	}
+	return nil
+}
+
+func (b *ConfigBinding) evaluate(flagKey string) (string, bool) {
+	if er, ok := b.client.evaluateOverride(flagKey, &b.user, nil); ok {
+		return er.fv, true
+	}
+	flag := b.client.getFlag(flagKey)
+	if flag == nil {
+		return "", false
+	}
+	er := b.client.evaluator.evaluate(flag, &b.user, nil)
+	if !er.success {
+		return "", false
+	}
+	return er.fv, true
+}
+
+func setFieldValue(field reflect.Value, value string) error {
+	if field.Type() == durationType {
+		if d, err := time.ParseDuration(value); err == nil {
+			field.SetInt(int64(d))
+			return nil
+		}
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		// decode into a fresh value so that a partial decoding doesn't leak into the config
+		v := reflect.New(field.Type())
+		if err := json.Unmarshal([]byte(value), v.Interface()); err != nil {
+			return err
+		}
+		field.Set(v.Elem())
+	}
+	return nil
+}
+
+// Load returns a pointer to the current configuration, the type is the pointer to the bound struct.
+// The configuration is shared and should not be modified.
+func (b *ConfigBinding) Load() interface{} {
+	return b.current.Load()
+}
+
+// Get copies the current configuration into out, which should be a pointer to the bound struct
+func (b *ConfigBinding) Get(out interface{}) error {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Type() != b.typ {
+		return bindingTypeMismatch
+	}
+	v.Elem().Set(reflect.ValueOf(b.current.Load()).Elem())
+	return nil
+}
+
+// LastError returns the error of the last validation, nil if the last configuration was valid
+func (b *ConfigBinding) LastError() error {
+	ve, _ := b.lastErr.Load().(validationError)
+	return ve.err
+}
+
+// Close stops refreshing the configuration, the last configuration is still readable
+func (b *ConfigBinding) Close() error {
+	b.cancel()
+	<-b.done
+	return nil
+}
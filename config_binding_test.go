@@ -0,0 +1,109 @@
+package featbit
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/featbit/featbit-go-sdk/fixtures"
+	"github.com/featbit/featbit-go-sdk/internal/types/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type testSettings struct {
+	Number   int           `featbit:"ff-test-number"`
+	Channel  string        `featbit:"ff-test-string"`
+	Enabled  bool          `featbit:"ff-test-off"`
+	Prize    Dummy         `featbit:"ff-test-json"`
+	Timeout  time.Duration `featbit:"ff-not-existed"`
+	Ignored  string
+	internal string `featbit:"ff-test-string"`
+}
+
+const numberFlagJson = `{"id":"ff-test-number","key":"ff-test-number","name":"ff-test-number","isEnabled":true,
+"variationType":"number","disabledVariationId":"v1","updatedAt":"%s",
+"variations":[{"id":"v1","value":"%s"}],
+"fallthrough":{"includedInExpt":true,"variations":[{"id":"v1","rollout":[0,1],"exptRollout":1}]}}`
+
+var numberFlagUpdates int
+
+func updateNumberFlag(t *testing.T, client *FBClient, value string) {
+	// the timestamps are in milliseconds, make sure that each update is newer than the previous one
+	numberFlagUpdates++
+	updatedAt := time.Now().Add(time.Duration(numberFlagUpdates) * time.Second).UTC().Format(time.RFC3339Nano)
+	var flag data.FeatureFlag
+	require.NoError(t, json.Unmarshal([]byte(fmt.Sprintf(numberFlagJson, updatedAt, value)), &flag))
+	require.True(t, client.dataUpdater.Upsert(data.Features, flag.Key, &flag, client.dataUpdater.GetVersion()+1))
+}
+
+func TestBindConfig(t *testing.T) {
+	config := FBConfig{Offline: true, StartWait: 1 * time.Millisecond}
+	client, _ := MakeCustomFBClient(fakeEnvSecret, "ws://fake-url", "http://fake-url", config)
+	defer client.Close()
+	jsonBytes, _ := fixtures.LoadFBClientTestData()
+	_, _ = client.InitializeFromExternalJson(string(jsonBytes))
+
+	t.Run("invalid target", func(t *testing.T) {
+		_, err := client.BindConfig(testUser1, testSettings{}, ConfigBindingOptions{})
+		assert.Equal(t, bindingTargetInvalid, err)
+		_, err = client.BindConfig(testUser1, (*testSettings)(nil), ConfigBindingOptions{})
+		assert.Equal(t, bindingTargetInvalid, err)
+	})
+	t.Run("bind and refresh", func(t *testing.T) {
+		changes := make(chan int, 10)
+		binding, err := client.BindConfig(testUser1, &testSettings{Timeout: time.Second, Ignored: "default"}, ConfigBindingOptions{
+			Validate: func(config interface{}) error {
+				if config.(*testSettings).Number < 0 {
+					return fmt.Errorf("negative number")
+				}
+				return nil
+			},
+			OnChange: func(config interface{}) {
+				changes <- config.(*testSettings).Number
+			},
+		})
+		require.NoError(t, err)
+		defer binding.Close()
+		var settings testSettings
+		require.NoError(t, binding.Get(&settings))
+		assert.Equal(t, 1, settings.Number)
+		assert.Equal(t, "others", settings.Channel)
+		assert.False(t, settings.Enabled)
+		assert.Equal(t, Dummy{Code: 200, Reason: "you win 100 euros"}, settings.Prize)
+		assert.Equal(t, time.Second, settings.Timeout)
+		assert.Equal(t, "default", settings.Ignored)
+		assert.Equal(t, "", settings.internal)
+		assert.Equal(t, 1, <-changes)
+
+		updateNumberFlag(t, client, "42")
+		select {
+		case n := <-changes:
+			assert.Equal(t, 42, n)
+		case <-time.After(time.Second):
+			t.Fatal("config is not refreshed")
+		}
+		assert.Equal(t, 42, binding.Load().(*testSettings).Number)
+		assert.NoError(t, binding.LastError())
+
+		// the invalid config is discarded
+		updateNumberFlag(t, client, "-1")
+		assert.Eventually(t, func() bool { return binding.LastError() != nil }, time.Second, 10*time.Millisecond)
+		assert.Equal(t, 42, binding.Load().(*testSettings).Number)
+
+		// the value can't be converted, the default value is used
+		updateNumberFlag(t, client, "not a number")
+		assert.Equal(t, 0, <-changes)
+	})
+	t.Run("overrides", func(t *testing.T) {
+		binding, err := client.BindConfig(testUser1, &testSettings{}, ConfigBindingOptions{})
+		require.NoError(t, err)
+		defer binding.Close()
+		require.NoError(t, client.SetOverride("ff-test-string", "email"))
+		defer client.RemoveOverride("ff-test-string")
+		require.NoError(t, binding.Refresh())
+		assert.Equal(t, "email", binding.Load().(*testSettings).Channel)
+		var wrongType Dummy
+		assert.Equal(t, bindingTypeMismatch, binding.Get(&wrongType))
+	})
+}
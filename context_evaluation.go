@@ -0,0 +1,93 @@
+package featbit
+
+import (
+	"context"
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+)
+
+type requestOverridesKey struct{}
+
+// WithRequestOverrides returns a copy of ctx that carries the request scoped overrides, which map the flag keys to the
+// variation values or ids.
+//
+// The evaluations made with FBClient.WithContext(ctx) honour these overrides, they have priority over the overrides of the client.
+func WithRequestOverrides(ctx context.Context, overrides map[string]string) context.Context {
+	if len(overrides) == 0 {
+		return ctx
+	}
+	copied := make(map[string]string, len(overrides))
+	for k, v := range overrides {
+		copied[k] = v
+	}
+	return context.WithValue(ctx, requestOverridesKey{}, copied)
+}
+
+// RequestOverridesFromContext returns the request scoped overrides carried by ctx, nil if none
+func RequestOverridesFromContext(ctx context.Context) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+	overrides, _ := ctx.Value(requestOverridesKey{}).(map[string]string)
+	return overrides
+}
+
+type contextEvaluation struct {
+	client    *FBClient
+	overrides map[string]string
+}
+
+// WithContext returns an interfaces.FBEvaluation whose evaluations honour the request scoped information carried by ctx,
+// such as the overrides set by WithRequestOverrides.
+//
+//	client.WithContext(r.Context()).BoolVariation("flag key", user, false)
+func (client *FBClient) WithContext(ctx context.Context) FBEvaluation {
+	return contextEvaluation{client: client, overrides: RequestOverridesFromContext(ctx)}
+}
+
+func (c contextEvaluation) Variation(featureFlagKey string, user FBUser, defaultValue string) (string, EvalDetail, error) {
+	ed, err := c.client.evaluateDetail(featureFlagKey, &user, FlagStringType, defaultValue, c.overrides)
+	if err != nil {
+		return defaultValue, ed, err
+	}
+	ret, _ := ed.Variation.(string)
+	return ret, ed, nil
+}
+
+func (c contextEvaluation) BoolVariation(featureFlagKey string, user FBUser, defaultValue bool) (bool, EvalDetail, error) {
+	ed, err := c.client.evaluateDetail(featureFlagKey, &user, FlagBoolType, defaultValue, c.overrides)
+	if err != nil {
+		return defaultValue, ed, err
+	}
+	ret, _ := ed.Variation.(bool)
+	return ret, ed, nil
+}
+
+func (c contextEvaluation) IntVariation(featureFlagKey string, user FBUser, defaultValue int) (int, EvalDetail, error) {
+	ed, err := c.client.evaluateDetail(featureFlagKey, &user, FlagNumericType, defaultValue, c.overrides)
+	if err != nil {
+		return defaultValue, ed, err
+	}
+	ret, _ := ed.Variation.(int)
+	return ret, ed, nil
+}
+
+func (c contextEvaluation) DoubleVariation(featureFlagKey string, user FBUser, defaultValue float64) (float64, EvalDetail, error) {
+	ed, err := c.client.evaluateDetail(featureFlagKey, &user, FlagNumericType, defaultValue, c.overrides)
+	if err != nil {
+		return defaultValue, ed, err
+	}
+	ret, _ := ed.Variation.(float64)
+	return ret, ed, nil
+}
+
+func (c contextEvaluation) JsonVariation(featureFlagKey string, user FBUser, defaultValue interface{}) (interface{}, EvalDetail, error) {
+	ed, err := c.client.evaluateDetail(featureFlagKey, &user, FlagJsonType, defaultValue, c.overrides)
+	if err != nil {
+		return defaultValue, ed, err
+	}
+	return ed.Variation, ed, nil
+}
+
+func (c contextEvaluation) AllLatestFlagsVariations(user FBUser) (AllFlagState, error) {
+	return c.client.allLatestFlagsVariations(user, c.overrides)
+}
@@ -3,6 +3,7 @@ package featbit
 import (
 	"encoding/json"
 	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/types/data"
 	"github.com/featbit/featbit-go-sdk/internal/types/insight"
 	"github.com/featbit/featbit-go-sdk/internal/util/log"
 	"reflect"
@@ -98,12 +99,39 @@ type evalResult struct {
 	reason           string
 	keyName          string
 	name             string
+	// evaluated from the stale data
+	stale bool
 }
 
 func errorResult(reason string, keyName string, name string) *evalResult {
 	return &evalResult{reason: reason, keyName: keyName, name: name}
 }
 
+// overrideResult creates the result of an overridden flag, flag is nil if it's unknown in the storage
+func overrideResult(keyName string, value string, flag *data.FeatureFlag) *evalResult {
+	er := &evalResult{
+		fv:       value,
+		success:  true,
+		flagType: FlagStringType,
+		reason:   ReasonOverride,
+		keyName:  keyName,
+		name:     FlagNameUnknown,
+	}
+	if flag != nil {
+		er.name = flag.Name
+		er.flagType = flag.VariationType
+		// the value could be either the id or the value of a variation
+		for _, variation := range flag.Variations {
+			if variation.Id == value || variation.Value == value {
+				er.id = variation.Id
+				er.fv = variation.Value
+				break
+			}
+		}
+	}
+	return er
+}
+
 func (er *evalResult) toEventFlag() insight.EventFlag {
 	return insight.NewEventFlag(er.keyName, er.sendToExperiment, er.id, er.fv, er.reason)
 }
@@ -133,13 +161,13 @@ func (er *evalResult) castVariationByFlagType(requiredType string, defaultValue
 	switch requiredType {
 	case FlagBoolType:
 		b, _ := strconv.ParseBool(er.fv)
-		return EvalDetail{Variation: b, Reason: er.reason, KeyName: er.keyName, Name: er.name}, nil
+		return EvalDetail{Variation: b, Reason: er.reason, KeyName: er.keyName, Name: er.name, Stale: er.stale}, nil
 	case FlagNumericType:
 		f, _ := strconv.ParseFloat(er.fv, 64)
 		if reflect.TypeOf(defaultValue).Kind() == reflect.Int {
-			return EvalDetail{Variation: int(f), Reason: er.reason, KeyName: er.keyName, Name: er.name}, nil
+			return EvalDetail{Variation: int(f), Reason: er.reason, KeyName: er.keyName, Name: er.name, Stale: er.stale}, nil
 		}
-		return EvalDetail{Variation: f, Reason: er.reason, KeyName: er.keyName, Name: er.name}, nil
+		return EvalDetail{Variation: f, Reason: er.reason, KeyName: er.keyName, Name: er.name, Stale: er.stale}, nil
 
 	case FlagJsonType:
 		t := reflect.TypeOf(defaultValue)
@@ -149,9 +177,9 @@ func (er *evalResult) castVariationByFlagType(requiredType string, defaultValue
 			return EvalDetail{Variation: defaultValue, Reason: er.reason, KeyName: er.keyName, Name: er.name}, err
 		}
 		inf = reflect.ValueOf(inf).Elem().Interface()
-		return EvalDetail{Variation: inf, Reason: er.reason, KeyName: er.keyName, Name: er.name}, nil
+		return EvalDetail{Variation: inf, Reason: er.reason, KeyName: er.keyName, Name: er.name, Stale: er.stale}, nil
 	default:
-		return EvalDetail{Variation: er.fv, Reason: er.reason, KeyName: er.keyName, Name: er.name}, nil
+		return EvalDetail{Variation: er.fv, Reason: er.reason, KeyName: er.keyName, Name: er.name, Stale: er.stale}, nil
 	}
 
 }
@@ -3,6 +3,7 @@ package featbit
 import (
 	. "github.com/featbit/featbit-go-sdk/interfaces"
 	"github.com/featbit/featbit-go-sdk/internal/types/data"
+	"github.com/featbit/featbit-go-sdk/internal/util"
 	"github.com/featbit/featbit-go-sdk/internal/util/log"
 )
 
@@ -17,6 +18,7 @@ const (
 	ReasonWrongType        = "wrong type"
 	ReasonUserNotSpecified = "user not specified"
 	ReasonError            = "error in evaluation"
+	ReasonOverride         = "override"
 	FlagNameUnknown        = "flag Name unknown"
 	ThanClause             = "Than"
 	GeClause               = "BiggerEqualThan"
@@ -43,16 +45,24 @@ const (
 	FlagStringType         = "string"
 )
 
+// ReasonBigSegmentsUnavailable the flag depends on a big segment, but the memberships of the user can't be read
+// from the store, the big segments are matched by their own rules instead
+const ReasonBigSegmentsUnavailable = "big segments unavailable"
+
 type evaluator struct {
 	getFlag    func(key string) *data.FeatureFlag
 	getSegment func(key string) *data.Segment
-	funcSlice  []func(*data.FeatureFlag, *FBUser) (*evalResult, bool)
+	funcSlice  []func(*data.FeatureFlag, *FBUser, *segmentResults) (*evalResult, bool)
+	// the memberships of the big segments, nil if the big segments are not configured
+	bigSegments BigSegments
+	// the results of the segments by user, nil if the results are only kept during an evaluation
+	segmentCache *util.LRUCache
 }
 
 func newEvaluator(getFlag func(key string) *data.FeatureFlag,
 	getSegment func(key string) *data.Segment) *evaluator {
 	e := &evaluator{getFlag: getFlag, getSegment: getSegment}
-	fs := []func(*data.FeatureFlag, *FBUser) (*evalResult, bool){
+	fs := []func(*data.FeatureFlag, *FBUser, *segmentResults) (*evalResult, bool){
 		e.matchFeatureFlagDisabledUserVariation,
 		e.matchTargetedUserVariation,
 		e.matchConditionedUserVariation,
@@ -62,9 +72,49 @@ func newEvaluator(getFlag func(key string) *data.FeatureFlag,
 	return e
 }
 
-func (e *evaluator) evaluate(flag *data.FeatureFlag, user *FBUser, event Event) (er *evalResult) {
+// withBigSegments sets the memberships of the big segments used by the evaluator
+func (e *evaluator) withBigSegments(bigSegments BigSegments) *evaluator {
+	e.bigSegments = bigSegments
+	return e
+}
+
+// withSegmentCache sets the cache of the results of the segments by user, shared by the evaluators of a client
+func (e *evaluator) withSegmentCache(segmentCache *util.LRUCache) *evaluator {
+	e.segmentCache = segmentCache
+	return e
+}
+
+// segmentResultsOf returns the results of the segments for a user, the cached ones if the evaluator has a segment cache
+func (e *evaluator) segmentResultsOf(user *FBUser) *segmentResults {
+	if e.segmentCache == nil {
+		return &segmentResults{userSegmentResults: &userSegmentResults{}}
+	}
+	key := userFingerprint(user)
+	if results, ok := e.segmentCache.Get(key); ok {
+		return &segmentResults{userSegmentResults: results.(*userSegmentResults)}
+	}
+	results := &userSegmentResults{}
+	e.segmentCache.Add(key, results)
+	return &segmentResults{userSegmentResults: results}
+}
+
+func (e *evaluator) evaluate(flag *data.FeatureFlag, user *FBUser, event Event) *evalResult {
+	return e.evaluateWith(flag, user, event, e.segmentResultsOf(user))
+}
+
+// evaluateWith evaluates a flag with the results of the segments already known for the user,
+// the same results should be used to evaluate several flags for a user
+func (e *evaluator) evaluateWith(flag *data.FeatureFlag, user *FBUser, event Event, segments *segmentResults) (er *evalResult) {
+	segments = segments.forEvaluation()
 	defer func() {
 		if er.success {
+			// the reason is ReasonBigSegmentsUnavailable if the memberships of the user can't be read,
+			// the result is stale if the memberships are out of date
+			if segments.bigSegmentsUnavailable {
+				er.reason = ReasonBigSegmentsUnavailable
+			} else if segments.bigSegmentsStale {
+				er.stale = true
+			}
 			log.LogInfo("FB Go SDK: User %v, Feature Flag %v, Flag Value %v", user.GetKey(), flag.Key, er.fv)
 			if event != nil {
 				eventFlag := er.toEventFlag()
@@ -74,7 +124,7 @@ func (e *evaluator) evaluate(flag *data.FeatureFlag, user *FBUser, event Event)
 	}()
 	var ok bool
 	for _, f := range e.funcSlice {
-		er, ok = f(flag, user)
+		er, ok = f(flag, user, segments)
 		if ok {
 			return
 		}
@@ -82,7 +132,7 @@ func (e *evaluator) evaluate(flag *data.FeatureFlag, user *FBUser, event Event)
 	return
 }
 
-func (e *evaluator) matchFeatureFlagDisabledUserVariation(flag *data.FeatureFlag, _ *FBUser) (*evalResult, bool) {
+func (e *evaluator) matchFeatureFlagDisabledUserVariation(flag *data.FeatureFlag, _ *FBUser, _ *segmentResults) (*evalResult, bool) {
 	if !flag.Enabled {
 		return &evalResult{
 			id:               flag.DisabledVariationId,
@@ -98,7 +148,7 @@ func (e *evaluator) matchFeatureFlagDisabledUserVariation(flag *data.FeatureFlag
 	return nil, false
 }
 
-func (e *evaluator) matchTargetedUserVariation(flag *data.FeatureFlag, user *FBUser) (*evalResult, bool) {
+func (e *evaluator) matchTargetedUserVariation(flag *data.FeatureFlag, user *FBUser, _ *segmentResults) (*evalResult, bool) {
 	for _, targetUser := range flag.TargetUsers {
 		for _, keyId := range targetUser.KeyIds {
 			if keyId == user.GetKey() {
@@ -118,10 +168,10 @@ func (e *evaluator) matchTargetedUserVariation(flag *data.FeatureFlag, user *FBU
 	return nil, false
 }
 
-func (e *evaluator) matchConditionedUserVariation(flag *data.FeatureFlag, user *FBUser) (*evalResult, bool) {
+func (e *evaluator) matchConditionedUserVariation(flag *data.FeatureFlag, user *FBUser, segments *segmentResults) (*evalResult, bool) {
 	var rule *data.TargetRule
 	for _, targetRule := range flag.Rules {
-		if e.ifUserMatchRule(user, targetRule.Conditions) {
+		if e.ifUserMatchRule(user, targetRule.Conditions, segments) {
 			rule = &targetRule
 			break
 		}
@@ -132,7 +182,7 @@ func (e *evaluator) matchConditionedUserVariation(flag *data.FeatureFlag, user *
 	return nil, false
 }
 
-func (e *evaluator) matchFallThroughUserVariation(flag *data.FeatureFlag, user *FBUser) (*evalResult, bool) {
+func (e *evaluator) matchFallThroughUserVariation(flag *data.FeatureFlag, user *FBUser, _ *segmentResults) (*evalResult, bool) {
 	ft := flag.Fallthrough
 	return getRolloutVariationValue(flag, ft.Variations, user, ReasonFallthrough, ft.IncludedInExpt, ft.DispatchKey)
 }
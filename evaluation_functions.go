@@ -10,9 +10,9 @@ import (
 	"strings"
 )
 
-func (e *evaluator) ifUserMatchRule(user *FBUser, conditions []data.Condition) bool {
+func (e *evaluator) ifUserMatchRule(user *FBUser, conditions []data.Condition, segments *segmentResults) bool {
 	for _, condition := range conditions {
-		if e.ifUserMatchCondition(user, &condition) {
+		if e.ifUserMatchCondition(user, &condition, segments) {
 			continue
 		}
 		return false
@@ -20,7 +20,7 @@ func (e *evaluator) ifUserMatchRule(user *FBUser, conditions []data.Condition) b
 	return true
 }
 
-func (e *evaluator) ifUserMatchCondition(user *FBUser, condition *data.Condition) bool {
+func (e *evaluator) ifUserMatchCondition(user *FBUser, condition *data.Condition, segments *segmentResults) bool {
 	op := condition.Op
 	// segment hasn't any operation
 	if op == "" {
@@ -55,34 +55,74 @@ func (e *evaluator) ifUserMatchCondition(user *FBUser, condition *data.Condition
 	case NotMatchRegexClause:
 		return !matchRegExCondition(user, condition)
 	case IsInSegmentClause:
-		return e.isInSegmentCondition(user, condition)
+		return e.isInSegmentCondition(user, condition, segments)
 	case NotInSegmentClause:
-		return !e.isInSegmentCondition(user, condition)
+		return !e.isInSegmentCondition(user, condition, segments)
 	}
 	return false
 }
 
-func (e *evaluator) isInSegmentCondition(user *FBUser, condition *data.Condition) bool {
-	pv := user.GetKey()
+func (e *evaluator) isInSegmentCondition(user *FBUser, condition *data.Condition, segments *segmentResults) bool {
 	cv := condition.Value
-	var segments []string
-	if err := json.Unmarshal([]byte(cv), &segments); err != nil {
+	var ids []string
+	if err := json.Unmarshal([]byte(cv), &ids); err != nil {
 		return false
 	}
-	for _, sid := range segments {
+	for _, sid := range ids {
 		segment := e.getSegment(sid)
 		if segment == nil {
 			continue
 		}
-		switch segment.MatchUser(pv) {
-		case data.SegmentExcludeUser:
-		case data.SegmentIncludeUser:
+		if e.matchSegment(user, segment, segments) {
 			return true
-		default:
-			for _, rule := range segment.Rules {
-				if e.ifUserMatchRule(user, rule.Conditions) {
-					return true
-				}
+		}
+	}
+	return false
+}
+
+// matchSegment returns true if the user is in the segment, the result is kept in the results of the segments,
+// except for the big segments whose memberships have their own cache, and the segments depending on them
+func (e *evaluator) matchSegment(user *FBUser, segment *data.Segment, segments *segmentResults) bool {
+	if segment.BigSegment {
+		segments.bigSegmentsUsed = true
+		// the memberships in the store take precedence over the rules of a big segment
+		if e.bigSegments == nil {
+			segments.bigSegmentsUnavailable = true
+		} else if membership, status := e.bigSegments.GetMembership(user.GetKey()); membership == nil {
+			segments.bigSegmentsUnavailable = true
+		} else {
+			if !status.Available || status.Stale {
+				segments.bigSegmentsStale = true
+			}
+			if included, ok := membership[segment.Id]; ok {
+				return included
+			}
+		}
+		return e.matchSegmentRules(user, segment, segments)
+	}
+	if matched, ok := segments.get(segment); ok {
+		return matched
+	}
+	used := segments.bigSegmentsUsed
+	segments.bigSegmentsUsed = false
+	matched := e.matchSegmentRules(user, segment, segments)
+	if !segments.bigSegmentsUsed {
+		segments.set(segment, matched)
+	}
+	segments.bigSegmentsUsed = segments.bigSegmentsUsed || used
+	return matched
+}
+
+func (e *evaluator) matchSegmentRules(user *FBUser, segment *data.Segment, segments *segmentResults) bool {
+	switch segment.MatchUser(user.GetKey()) {
+	case data.SegmentExcludeUser:
+		return false
+	case data.SegmentIncludeUser:
+		return true
+	default:
+		for _, rule := range segment.Rules {
+			if e.ifUserMatchRule(user, rule.Conditions, segments) {
+				return true
 			}
 		}
 	}
@@ -1,12 +1,16 @@
 package featbit
 
 import (
+	"encoding/json"
+	"fmt"
 	"github.com/featbit/featbit-go-sdk/interfaces"
 	"github.com/featbit/featbit-go-sdk/internal/datastorage"
 	"github.com/featbit/featbit-go-sdk/internal/types/data"
 	"github.com/featbit/featbit-go-sdk/internal/types/insight"
+	"github.com/featbit/featbit-go-sdk/internal/util"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 var user1, _ = interfaces.NewUserBuilder("test-user-1").Build()
@@ -123,3 +127,213 @@ func TestEvaluation(t *testing.T) {
 		assert.Equal(t, ReasonFallthrough, er.reason)
 	})
 }
+
+type mockBigSegments struct {
+	memberships map[string]interfaces.BigSegmentMembership
+	status      interfaces.BigSegmentStoreStatus
+}
+
+func (m *mockBigSegments) Close() error {
+	return nil
+}
+
+func (m *mockBigSegments) GetStatus() interfaces.BigSegmentStoreStatus {
+	return m.status
+}
+
+func (m *mockBigSegments) AddStatusListener() (<-chan interfaces.BigSegmentStoreStatus, func()) {
+	return make(chan interfaces.BigSegmentStoreStatus), func() {}
+}
+
+func (m *mockBigSegments) GetMembership(userKey string) (interfaces.BigSegmentMembership, interfaces.BigSegmentStoreStatus) {
+	if !m.status.Available {
+		return nil, m.status
+	}
+	return m.memberships[userKey], m.status
+}
+
+func TestEvaluationWithBigSegments(t *testing.T) {
+	var bigFlag data.FeatureFlag
+	assert.NoError(t, json.Unmarshal([]byte(`{"id":"ff-big","key":"ff-big","name":"ff-big","variationType":"boolean","isEnabled":true,
+"variations":[{"id":"v1","value":"true"},{"id":"v2","value":"false"}],
+"rules":[{"conditions":[{"property":"User is in segment","op":"","value":"[\"seg-big\"]"}],"variations":[{"id":"v1","rollout":[0,1]}]}],
+"fallthrough":{"variations":[{"id":"v2","rollout":[0,1]}]},"updatedAt":"2023-01-19T07:49:19Z"}`), &bigFlag))
+	var bigSegment data.Segment
+	assert.NoError(t, json.Unmarshal([]byte(`{"id":"seg-big","isBigSegment":true,"included":["u-rule"],"excluded":[],"rules":[],"updatedAt":"2023-01-19T07:49:19Z"}`), &bigSegment))
+	getFlag := func(key string) *data.FeatureFlag { return nil }
+	getSegment := func(key string) *data.Segment {
+		if key == bigSegment.Id {
+			return &bigSegment
+		}
+		return nil
+	}
+	included, _ := interfaces.NewUserBuilder("u-included").Build()
+	excluded, _ := interfaces.NewUserBuilder("u-excluded").Build()
+	ruleUser, _ := interfaces.NewUserBuilder("u-rule").Build()
+	bigSegments := &mockBigSegments{
+		memberships: map[string]interfaces.BigSegmentMembership{
+			"u-included": {"seg-big": true},
+			"u-excluded": {"seg-big": false},
+			"u-rule":     {"other-seg": true},
+		},
+		status: interfaces.BigSegmentStoreStatus{Available: true},
+	}
+	e := newEvaluator(getFlag, getSegment).withBigSegments(bigSegments)
+
+	t.Run("membership in store", func(t *testing.T) {
+		er := e.evaluate(&bigFlag, &included, nil)
+		assert.Equal(t, "true", er.fv)
+		assert.Equal(t, ReasonRuleMatch, er.reason)
+		assert.False(t, er.stale)
+		er = e.evaluate(&bigFlag, &excluded, nil)
+		assert.Equal(t, "false", er.fv)
+		assert.Equal(t, ReasonFallthrough, er.reason)
+		// the segment is matched by its own lists if the user is not in the membership
+		er = e.evaluate(&bigFlag, &ruleUser, nil)
+		assert.Equal(t, "true", er.fv)
+	})
+	t.Run("stale store", func(t *testing.T) {
+		bigSegments.status = interfaces.BigSegmentStoreStatus{Available: true, Stale: true}
+		defer func() { bigSegments.status = interfaces.BigSegmentStoreStatus{Available: true} }()
+		er := e.evaluate(&bigFlag, &included, nil)
+		assert.Equal(t, "true", er.fv)
+		assert.Equal(t, ReasonRuleMatch, er.reason)
+		assert.True(t, er.stale)
+	})
+	t.Run("unavailable store", func(t *testing.T) {
+		bigSegments.status = interfaces.BigSegmentStoreStatus{Available: false}
+		defer func() { bigSegments.status = interfaces.BigSegmentStoreStatus{Available: true} }()
+		er := e.evaluate(&bigFlag, &included, nil)
+		assert.Equal(t, "false", er.fv)
+		assert.Equal(t, ReasonBigSegmentsUnavailable, er.reason)
+		er = e.evaluate(&bigFlag, &ruleUser, nil)
+		assert.Equal(t, "true", er.fv)
+		assert.Equal(t, ReasonBigSegmentsUnavailable, er.reason)
+	})
+	t.Run("big segments not configured", func(t *testing.T) {
+		er := newEvaluator(getFlag, getSegment).evaluate(&bigFlag, &included, nil)
+		assert.Equal(t, "false", er.fv)
+		assert.Equal(t, ReasonBigSegmentsUnavailable, er.reason)
+	})
+	t.Run("rule without big segment", func(t *testing.T) {
+		var flag data.FeatureFlag
+		assert.NoError(t, json.Unmarshal([]byte(`{"id":"ff-mixed","key":"ff-mixed","name":"ff-mixed","variationType":"boolean","isEnabled":true,
+"variations":[{"id":"v1","value":"true"},{"id":"v2","value":"false"}],
+"rules":[{"conditions":[{"property":"country","op":"Equal","value":"CHN"}],"variations":[{"id":"v1","rollout":[0,1]}]},
+{"conditions":[{"property":"User is in segment","op":"","value":"[\"seg-big\"]"}],"variations":[{"id":"v1","rollout":[0,1]}]}],
+"fallthrough":{"variations":[{"id":"v2","rollout":[0,1]}]},"updatedAt":"2023-01-19T07:49:19Z"}`), &flag))
+		bigSegments.status = interfaces.BigSegmentStoreStatus{Available: false}
+		defer func() { bigSegments.status = interfaces.BigSegmentStoreStatus{Available: true} }()
+		user, _ := interfaces.NewUserBuilder("u-included").Custom("country", "CHN").Build()
+		er := e.evaluate(&flag, &user, nil)
+		assert.Equal(t, "true", er.fv)
+		assert.Equal(t, ReasonRuleMatch, er.reason)
+		// the big segment is matched once the first rule fails
+		user, _ = interfaces.NewUserBuilder("u-included").Custom("country", "USA").Build()
+		er = e.evaluate(&flag, &user, nil)
+		assert.Equal(t, "false", er.fv)
+		assert.Equal(t, ReasonBigSegmentsUnavailable, er.reason)
+	})
+}
+
+func newSegmentFlag(t testing.TB, key string, segmentId string) *data.FeatureFlag {
+	var flag data.FeatureFlag
+	jsonStr := fmt.Sprintf(`{"id":"%[1]s","key":"%[1]s","name":"%[1]s","variationType":"boolean","isEnabled":true,
+"variations":[{"id":"v1","value":"true"},{"id":"v2","value":"false"}],
+"rules":[{"conditions":[{"property":"User is in segment","op":"","value":"[\"%[2]s\"]"}],"variations":[{"id":"v1","rollout":[0,1]}]}],
+"fallthrough":{"variations":[{"id":"v2","rollout":[0,1]}]},"updatedAt":"2023-01-19T07:49:19Z"}`, key, segmentId)
+	if err := json.Unmarshal([]byte(jsonStr), &flag); err != nil {
+		t.Fatal(err)
+	}
+	return &flag
+}
+
+func newRuleSegment(t testing.TB, id string, country string, updatedAt string) *data.Segment {
+	var segment data.Segment
+	jsonStr := fmt.Sprintf(`{"id":"%s","included":[],"excluded":[],"updatedAt":"%s",
+"rules":[{"conditions":[{"property":"country","op":"Equal","value":"%s"}]}]}`, id, updatedAt, country)
+	if err := json.Unmarshal([]byte(jsonStr), &segment); err != nil {
+		t.Fatal(err)
+	}
+	return &segment
+}
+
+func TestSegmentResults(t *testing.T) {
+	segment := newRuleSegment(t, "seg1", "CHN", "2023-01-19T07:49:19Z")
+	getFlag := func(key string) *data.FeatureFlag { return nil }
+	getSegment := func(key string) *data.Segment {
+		if key == segment.Id {
+			return segment
+		}
+		return nil
+	}
+	segmentFlag := newSegmentFlag(t, "ff-segment", "seg1")
+	user, _ := interfaces.NewUserBuilder("u1").Custom("country", "CHN").Build()
+
+	t.Run("results are kept during an evaluation", func(t *testing.T) {
+		e := newEvaluator(getFlag, getSegment)
+		segments := e.segmentResultsOf(&user)
+		er := e.evaluateWith(segmentFlag, &user, nil, segments)
+		assert.Equal(t, ReasonRuleMatch, er.reason)
+		matched, ok := segments.get(segment)
+		assert.True(t, ok)
+		assert.True(t, matched)
+		// a new evaluation matches the segments again
+		assert.NotSame(t, segments, e.segmentResultsOf(&user))
+	})
+	t.Run("results are cached by user until the segment is updated", func(t *testing.T) {
+		defer func() { segment = newRuleSegment(t, "seg1", "CHN", "2023-01-19T07:49:19Z") }()
+		e := newEvaluator(getFlag, getSegment).withSegmentCache(util.NewLRUCache(10, time.Minute))
+		assert.Equal(t, ReasonRuleMatch, e.evaluate(segmentFlag, &user, nil).reason)
+		// same timestamp, the cached result is used
+		segment = newRuleSegment(t, "seg1", "USA", "2023-01-19T07:49:19Z")
+		assert.Equal(t, ReasonRuleMatch, e.evaluate(segmentFlag, &user, nil).reason)
+		// another user with the same key doesn't share the results
+		other, _ := interfaces.NewUserBuilder("u1").Custom("country", "FRA").Build()
+		assert.Equal(t, ReasonFallthrough, e.evaluate(segmentFlag, &other, nil).reason)
+		// the segment is updated
+		segment = newRuleSegment(t, "seg1", "USA", "2023-01-20T07:49:19Z")
+		assert.Equal(t, ReasonFallthrough, e.evaluate(segmentFlag, &user, nil).reason)
+	})
+}
+
+func BenchmarkSegmentResults(b *testing.B) {
+	segments := make(map[string]*data.Segment, 10)
+	flags := make([]*data.FeatureFlag, 0, 100)
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("seg%d", i)
+		segments[id] = newRuleSegment(b, id, "CHN", "2023-01-19T07:49:19Z")
+		for j := 0; j < 10; j++ {
+			flags = append(flags, newSegmentFlag(b, fmt.Sprintf("ff-%d-%d", i, j), id))
+		}
+	}
+	getFlag := func(key string) *data.FeatureFlag { return nil }
+	getSegment := func(key string) *data.Segment { return segments[key] }
+	user, _ := interfaces.NewUserBuilder("u1").Custom("country", "CHN").Build()
+	b.Run("per flag", func(b *testing.B) {
+		e := newEvaluator(getFlag, getSegment)
+		for i := 0; i < b.N; i++ {
+			for _, flag := range flags {
+				e.evaluate(flag, &user, nil)
+			}
+		}
+	})
+	b.Run("per user", func(b *testing.B) {
+		e := newEvaluator(getFlag, getSegment)
+		for i := 0; i < b.N; i++ {
+			results := e.segmentResultsOf(&user)
+			for _, flag := range flags {
+				e.evaluateWith(flag, &user, nil, results)
+			}
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		e := newEvaluator(getFlag, getSegment).withSegmentCache(util.NewLRUCache(100, time.Minute))
+		for i := 0; i < b.N; i++ {
+			results := e.segmentResultsOf(&user)
+			for _, flag := range flags {
+				e.evaluateWith(flag, &user, nil, results)
+			}
+		}
+	})
+}
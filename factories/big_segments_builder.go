@@ -0,0 +1,110 @@
+package factories
+
+import (
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/bigsegments"
+	"time"
+)
+
+const (
+	DefaultBigSegmentsUserCacheSize      = 1000
+	DefaultBigSegmentsUserCacheTTL       = 5 * time.Second
+	DefaultBigSegmentsStaleAfter         = 2 * time.Minute
+	DefaultBigSegmentsStatusPollInterval = 5 * time.Second
+)
+
+// BigSegmentsBuilder factory to create an implementation of interfaces.BigSegments, which gives the membership of the users
+// in the big segments, the segments whose users are kept in an external interfaces.BigSegmentStore.
+//
+// The membership of a user is cached for a TTL, the number of users in the cache is limited.
+// The store is polled to check its availability and its freshness: the store is stale if it has not been updated
+// for longer than the threshold, see interfaces.BigSegmentStoreStatusProvider.
+//
+//	factory := factories.NewBigSegmentsBuilder(redis.NewBigSegmentStoreBuilder().URL("redis://redis:6379"))
+//	config := featbit.FBConfig{BigSegmentsFactory: factory}
+type BigSegmentsBuilder struct {
+	storeFactory       BigSegmentStoreFactory
+	userCacheSize      int
+	userCacheTTL       time.Duration
+	staleAfter         time.Duration
+	statusPollInterval time.Duration
+}
+
+// NewBigSegmentsBuilder creates an instance of BigSegmentsBuilder with the factory of the store,
+// such as FileBigSegmentStoreBuilder, or redis.BigSegmentStoreBuilder of the module github.com/featbit/featbit-go-sdk/factories/redis
+func NewBigSegmentsBuilder(storeFactory BigSegmentStoreFactory) *BigSegmentsBuilder {
+	return &BigSegmentsBuilder{
+		storeFactory:       storeFactory,
+		userCacheSize:      DefaultBigSegmentsUserCacheSize,
+		userCacheTTL:       DefaultBigSegmentsUserCacheTTL,
+		staleAfter:         DefaultBigSegmentsStaleAfter,
+		statusPollInterval: DefaultBigSegmentsStatusPollInterval,
+	}
+}
+
+// UserCacheSize sets the max number of users whose membership is cached
+func (b *BigSegmentsBuilder) UserCacheSize(userCacheSize int) *BigSegmentsBuilder {
+	if userCacheSize <= 0 {
+		b.userCacheSize = DefaultBigSegmentsUserCacheSize
+	} else {
+		b.userCacheSize = userCacheSize
+	}
+	return b
+}
+
+// UserCacheTTL sets how long the membership of a user is cached
+func (b *BigSegmentsBuilder) UserCacheTTL(userCacheTTL time.Duration) *BigSegmentsBuilder {
+	if userCacheTTL <= 0 {
+		b.userCacheTTL = DefaultBigSegmentsUserCacheTTL
+	} else {
+		b.userCacheTTL = userCacheTTL
+	}
+	return b
+}
+
+// StaleAfter sets how long the store could be not updated before it's considered as stale
+func (b *BigSegmentsBuilder) StaleAfter(staleAfter time.Duration) *BigSegmentsBuilder {
+	if staleAfter <= 0 {
+		b.staleAfter = DefaultBigSegmentsStaleAfter
+	} else {
+		b.staleAfter = staleAfter
+	}
+	return b
+}
+
+// StatusPollInterval sets the interval to check the availability and the freshness of the store
+func (b *BigSegmentsBuilder) StatusPollInterval(statusPollInterval time.Duration) *BigSegmentsBuilder {
+	if statusPollInterval <= 0 {
+		b.statusPollInterval = DefaultBigSegmentsStatusPollInterval
+	} else {
+		b.statusPollInterval = statusPollInterval
+	}
+	return b
+}
+
+// CreateBigSegments creates an instance of interfaces.BigSegments
+func (b *BigSegmentsBuilder) CreateBigSegments(context Context) (BigSegments, error) {
+	store, err := b.storeFactory.CreateBigSegmentStore(context)
+	if err != nil {
+		return nil, err
+	}
+	return bigsegments.NewBigSegmentsImpl(store, b.userCacheSize, b.userCacheTTL, b.staleAfter, b.statusPollInterval), nil
+}
+
+// FileBigSegmentStoreBuilder factory to create an implementation of interfaces.BigSegmentStore that reads the memberships
+// from a json file, for the tests and the local development. The file is reloaded once it's changed.
+//
+//	{"lastUpdated": "2023-01-19T07:49:19Z", "users": {"user-key": {"included": ["segment-id"], "excluded": []}}}
+type FileBigSegmentStoreBuilder struct {
+	path string
+}
+
+// NewFileBigSegmentStoreBuilder creates an instance of FileBigSegmentStoreBuilder with the path of the file
+func NewFileBigSegmentStoreBuilder(path string) *FileBigSegmentStoreBuilder {
+	return &FileBigSegmentStoreBuilder{path: path}
+}
+
+// CreateBigSegmentStore creates an instance of interfaces.BigSegmentStore
+func (f *FileBigSegmentStoreBuilder) CreateBigSegmentStore(_ Context) (BigSegmentStore, error) {
+	return bigsegments.NewFileBigSegmentStore(f.path), nil
+}
@@ -0,0 +1,48 @@
+package factories
+
+import (
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/datasynchronization"
+	"time"
+)
+
+const (
+	DefaultDaemonCheckInterval = 5 * time.Second
+	minDaemonCheckInterval     = 10 * time.Millisecond
+)
+
+// DaemonBuilder factory to create an implementation of interfaces.DataSynchronizer for the daemon mode: the SDK doesn't
+// connect to the feature flag center, the flags are evaluated from a persistent storage populated by another process,
+// such as a relay. The reads are cached by the storage, see SQLStorageBuilder.CacheTTL or redis.StorageBuilder.CacheTTL.
+//
+// The client is initialized once the storage has got the data, the availability of the storage is checked periodically
+// and reported by interfaces.DataUpdateStatusProvider.
+//
+// The daemon mode is normally enabled by featbit.FBConfig.DaemonMode, this builder is only needed to customize the check interval.
+//
+//	config := featbit.FBConfig{DaemonMode: true, DataStorageFactory: redis.NewStorageBuilder()}
+type DaemonBuilder struct {
+	checkInterval time.Duration
+}
+
+// NewDaemonBuilder creates an instance of DaemonBuilder
+func NewDaemonBuilder() *DaemonBuilder {
+	return &DaemonBuilder{checkInterval: DefaultDaemonCheckInterval}
+}
+
+// CheckInterval sets the interval to check the availability of the storage
+func (d *DaemonBuilder) CheckInterval(checkInterval time.Duration) *DaemonBuilder {
+	if checkInterval <= 0 {
+		d.checkInterval = DefaultDaemonCheckInterval
+	} else if checkInterval < minDaemonCheckInterval {
+		d.checkInterval = minDaemonCheckInterval
+	} else {
+		d.checkInterval = checkInterval
+	}
+	return d
+}
+
+// CreateDataSynchronizer creates an instance of interfaces.DataSynchronizer
+func (d *DaemonBuilder) CreateDataSynchronizer(_ Context, dataUpdater DataUpdater) (DataSynchronizer, error) {
+	return datasynchronization.NewDaemon(dataUpdater, d.checkInterval), nil
+}
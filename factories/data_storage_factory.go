@@ -14,3 +14,19 @@ func NewInMemoryStorageBuilder() InMemoryStorageBuilder {
 func (i InMemoryStorageBuilder) CreateDataStorage(Context) (DataStorage, error) {
 	return datastorage.NewInMemoryDataStorage(), nil
 }
+
+// CopyOnWriteStorageBuilder factory to create an in-memory implementation of interfaces.DataStorage optimized for the reads:
+// the flags are read without any lock, and all the flags are evaluated against a consistent view of the data.
+// The updates are slower than the default in-memory storage, which is a good trade-off for the applications
+// evaluating the flags at a high rate.
+//
+//	config := featbit.FBConfig{DataStorageFactory: factories.NewCopyOnWriteStorageBuilder()}
+type CopyOnWriteStorageBuilder struct{}
+
+func NewCopyOnWriteStorageBuilder() CopyOnWriteStorageBuilder {
+	return CopyOnWriteStorageBuilder{}
+}
+
+func (c CopyOnWriteStorageBuilder) CreateDataStorage(Context) (DataStorage, error) {
+	return datastorage.NewCopyOnWriteDataStorage(), nil
+}
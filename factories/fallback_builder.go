@@ -0,0 +1,86 @@
+package factories
+
+import (
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/datasynchronization"
+	"time"
+)
+
+const (
+	DefaultFallbackThreshold = time.Minute
+	DefaultProbeInterval     = 5 * time.Minute
+)
+
+// FallbackBuilder factory to create an implementation of interfaces.DataSynchronizer that feeds the data from the streaming,
+// and switches automatically to the polling if the streaming is not healthy(INTERRUPTED) for longer than a threshold.
+// While the polling is active, the streaming is periodically probed and takes over again once it's healthy.
+//
+// The active source is reported by interfaces.ActiveSourceProvider.GetActiveSource.
+//
+//	factory := factories.NewFallbackBuilder().FallbackThreshold(30 * time.Second)
+//	config := featbit.FBConfig{DataSynchronizerFactory: factory}
+type FallbackBuilder struct {
+	primary       DataSynchronizerFactory
+	secondary     DataSynchronizerFactory
+	threshold     time.Duration
+	probeInterval time.Duration
+}
+
+// NewFallbackBuilder creates an instance of FallbackBuilder, using the default StreamingBuilder and PollingBuilder
+func NewFallbackBuilder() *FallbackBuilder {
+	return &FallbackBuilder{
+		primary:       NewStreamingBuilder(),
+		secondary:     NewPollingBuilder(),
+		threshold:     DefaultFallbackThreshold,
+		probeInterval: DefaultProbeInterval,
+	}
+}
+
+// Streaming sets the factory of the primary source, normally a customized StreamingBuilder
+func (f *FallbackBuilder) Streaming(primary DataSynchronizerFactory) *FallbackBuilder {
+	if primary != nil {
+		f.primary = primary
+	}
+	return f
+}
+
+// Polling sets the factory of the secondary source, normally a customized PollingBuilder
+func (f *FallbackBuilder) Polling(secondary DataSynchronizerFactory) *FallbackBuilder {
+	if secondary != nil {
+		f.secondary = secondary
+	}
+	return f
+}
+
+// FallbackThreshold sets how long the streaming could be unhealthy before switching to the polling,
+// it's also the time given to the streaming to become healthy when it's probed
+func (f *FallbackBuilder) FallbackThreshold(threshold time.Duration) *FallbackBuilder {
+	if threshold <= 0 {
+		f.threshold = DefaultFallbackThreshold
+	} else {
+		f.threshold = threshold
+	}
+	return f
+}
+
+// ProbeInterval sets the interval to probe the streaming while the polling is active
+func (f *FallbackBuilder) ProbeInterval(probeInterval time.Duration) *FallbackBuilder {
+	if probeInterval <= 0 {
+		f.probeInterval = DefaultProbeInterval
+	} else {
+		f.probeInterval = probeInterval
+	}
+	return f
+}
+
+// CreateDataSynchronizer creates an instance of interfaces.DataSynchronizer
+func (f *FallbackBuilder) CreateDataSynchronizer(context Context, dataUpdater DataUpdater) (DataSynchronizer, error) {
+	primary, secondary := f.primary, f.secondary
+	createPrimary := func(dataUpdater DataUpdater) (DataSynchronizer, error) {
+		return primary.CreateDataSynchronizer(context, dataUpdater)
+	}
+	createSecondary := func(dataUpdater DataUpdater) (DataSynchronizer, error) {
+		return secondary.CreateDataSynchronizer(context, dataUpdater)
+	}
+	return datasynchronization.NewFallback(dataUpdater, createPrimary, createSecondary, f.threshold, f.probeInterval), nil
+}
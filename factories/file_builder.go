@@ -0,0 +1,61 @@
+package factories
+
+import (
+	"fmt"
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/datasynchronization"
+	"time"
+)
+
+const (
+	DefaultFileReloadInterval = time.Second
+	minFileReloadInterval     = 10 * time.Millisecond
+)
+
+// FileDataSynchronizerBuilder factory to create an implementation of interfaces.DataSynchronizer that loads
+// the flags and segments from local json files in place of the feature flag center, it's designed for the tests
+// in the environments without network access.
+//
+// The files are in the format accepted by FBClient.InitializeFromExternalJson, the flags and segments of all the files
+// are merged. The files are watched for changes and reloaded automatically; if a file can't be parsed, the status is
+// set to INTERRUPTED and the last good data are kept.
+//
+//	factory := factories.NewFileDataSynchronizerBuilder("flags.json", "segments.json")
+//	config := featbit.FBConfig{DataSynchronizerFactory: factory, InsightProcessorFactory: factories.ExternalEventTrack()}
+type FileDataSynchronizerBuilder struct {
+	paths          []string
+	reloadInterval time.Duration
+}
+
+// NewFileDataSynchronizerBuilder creates an instance of FileDataSynchronizerBuilder with the paths of the files
+func NewFileDataSynchronizerBuilder(paths ...string) *FileDataSynchronizerBuilder {
+	return &FileDataSynchronizerBuilder{paths: paths, reloadInterval: DefaultFileReloadInterval}
+}
+
+// FilePaths adds the paths of the files to load
+func (f *FileDataSynchronizerBuilder) FilePaths(paths ...string) *FileDataSynchronizerBuilder {
+	f.paths = append(f.paths, paths...)
+	return f
+}
+
+// ReloadInterval sets the interval to check if the files have changed
+func (f *FileDataSynchronizerBuilder) ReloadInterval(reloadInterval time.Duration) *FileDataSynchronizerBuilder {
+	if reloadInterval <= 0 {
+		f.reloadInterval = DefaultFileReloadInterval
+	} else if reloadInterval < minFileReloadInterval {
+		f.reloadInterval = minFileReloadInterval
+	} else {
+		f.reloadInterval = reloadInterval
+	}
+	return f
+}
+
+// CreateDataSynchronizer creates an instance of interfaces.DataSynchronizer
+func (f *FileDataSynchronizerBuilder) CreateDataSynchronizer(_ Context, dataUpdater DataUpdater) (DataSynchronizer, error) {
+	if len(f.paths) == 0 {
+		return nil, fmt.Errorf("no data file")
+	}
+	paths := make([]string, len(f.paths))
+	copy(paths, f.paths)
+	return datasynchronization.NewFileDataSynchronizer(dataUpdater, paths, f.reloadInterval), nil
+}
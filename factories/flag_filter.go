@@ -0,0 +1,52 @@
+package factories
+
+import (
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/datasynchronization"
+)
+
+// FlagFilter selects the feature flags synchronized into the data storage, for the services that only use a few flags
+// of the environment. A flag is kept if its key is in Keys, if it starts with one of KeyPrefixes, or if it has one of Tags.
+// The segments referenced by the kept flags are kept as well.
+//
+// An empty filter keeps all the flags. The flags that are not kept are unknown to the SDK, their evaluations return
+// the default values.
+//
+//	filter := factories.FlagFilter{KeyPrefixes: []string{"checkout-"}, Tags: []string{"payment"}}
+//	config := featbit.FBConfig{DataSynchronizerFactory: factories.NewStreamingBuilder().Filter(filter)}
+type FlagFilter struct {
+	// KeyPrefixes the prefixes of the flag keys
+	KeyPrefixes []string
+	// Keys the flag keys
+	Keys []string
+	// Tags the flag tags
+	Tags []string
+}
+
+func (f *FlagFilter) isEmpty() bool {
+	return len(f.KeyPrefixes) == 0 && len(f.Keys) == 0 && len(f.Tags) == 0
+}
+
+// apply returns the DataUpdater in which the filtered data are pushed
+func (f *FlagFilter) apply(dataUpdater DataUpdater) DataUpdater {
+	if f == nil || f.isEmpty() {
+		return dataUpdater
+	}
+	return datasynchronization.NewFilteredUpdater(dataUpdater, f.KeyPrefixes, f.Keys, f.Tags)
+}
+
+type filteredSynchronizerBuilder struct {
+	factory DataSynchronizerFactory
+	filter  FlagFilter
+}
+
+// WithFlagFilter applies a FlagFilter to any factory of interfaces.DataSynchronizer, such as FallbackBuilder or FileBuilder
+//
+//	factory := factories.WithFlagFilter(factories.NewFallbackBuilder(), factories.FlagFilter{Keys: []string{"flag-key"}})
+func WithFlagFilter(factory DataSynchronizerFactory, filter FlagFilter) DataSynchronizerFactory {
+	return &filteredSynchronizerBuilder{factory: factory, filter: filter}
+}
+
+func (f *filteredSynchronizerBuilder) CreateDataSynchronizer(context Context, dataUpdater DataUpdater) (DataSynchronizer, error) {
+	return f.factory.CreateDataSynchronizer(context, f.filter.apply(dataUpdater))
+}
@@ -0,0 +1,62 @@
+package factories
+
+import (
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/overrides"
+	"github.com/featbit/featbit-go-sdk/internal/util"
+	"time"
+)
+
+const DefaultOverridesReloadInterval = time.Second
+
+// FlagOverridesBuilder factory to create default implementation of interfaces.FlagOverrides
+//
+// The overrides can be set from a map or a json/yaml file that maps the flag keys to the values:
+//
+//	factories.NewFlagOverridesBuilder().Values(map[string]interface{}{"flag-key": true}).File("overrides.yaml")
+type FlagOverridesBuilder struct {
+	values         map[string]interface{}
+	filePath       string
+	reloadInterval time.Duration
+}
+
+// NewFlagOverridesBuilder creates an instance of FlagOverridesBuilder
+func NewFlagOverridesBuilder() *FlagOverridesBuilder {
+	return &FlagOverridesBuilder{reloadInterval: DefaultOverridesReloadInterval}
+}
+
+// Values sets the static overrides, the value could be a string, bool, number or any object serializable in json
+func (f *FlagOverridesBuilder) Values(values map[string]interface{}) *FlagOverridesBuilder {
+	f.values = values
+	return f
+}
+
+// File sets the path of a json or yaml(.yaml/.yml) file that maps the flag keys to the values,
+// the file overrides have priority over the static values.
+func (f *FlagOverridesBuilder) File(filePath string) *FlagOverridesBuilder {
+	f.filePath = filePath
+	return f
+}
+
+// ReloadInterval sets the interval to check if the overrides file changes
+func (f *FlagOverridesBuilder) ReloadInterval(reloadInterval time.Duration) *FlagOverridesBuilder {
+	if reloadInterval <= 0 {
+		f.reloadInterval = DefaultOverridesReloadInterval
+	} else {
+		f.reloadInterval = reloadInterval
+	}
+	return f
+}
+
+// CreateFlagOverrides creates an instance of interfaces.FlagOverrides
+func (f *FlagOverridesBuilder) CreateFlagOverrides(Context) (FlagOverrides, error) {
+	values := make(map[string]string, len(f.values))
+	for key, value := range f.values {
+		v, err := util.ValueToString(value)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = v
+	}
+	return overrides.NewFlagOverridesImpl(values, f.filePath, f.reloadInterval)
+}
@@ -0,0 +1,73 @@
+package factories
+
+import (
+	"fmt"
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/datasynchronization"
+	"net/http"
+	"time"
+)
+
+const (
+	DefaultPollingInterval = 30 * time.Second
+	minPollingInterval     = time.Second
+)
+
+// PollingBuilder factory to create an implementation of interfaces.DataSynchronizer that polls
+// the data from the feature flag center over HTTP, in place of the websocket streaming.
+//
+// The polling asks for the data changed since the version of the storage, and uses the conditional requests(ETag)
+// to avoid to download the same data twice. The polling url is built from the event url.
+//
+//	config := featbit.FBConfig{DataSynchronizerFactory: factories.NewPollingBuilder().PollingInterval(10 * time.Second)}
+type PollingBuilder struct {
+	pollingInterval time.Duration
+	firstRetryDelay time.Duration
+	filter          *FlagFilter
+}
+
+// NewPollingBuilder creates an instance of PollingBuilder
+func NewPollingBuilder() *PollingBuilder {
+	return &PollingBuilder{pollingInterval: DefaultPollingInterval, firstRetryDelay: defaultFirstRetryDelay}
+}
+
+// PollingInterval sets the interval between two polls, the minimum value is 1 second
+func (p *PollingBuilder) PollingInterval(pollingInterval time.Duration) *PollingBuilder {
+	if pollingInterval <= 0 {
+		p.pollingInterval = DefaultPollingInterval
+	} else if pollingInterval < minPollingInterval {
+		p.pollingInterval = minPollingInterval
+	} else {
+		p.pollingInterval = pollingInterval
+	}
+	return p
+}
+
+// FirstRetryDelay sets the time to wait for next poll if the last one failed
+func (p *PollingBuilder) FirstRetryDelay(firstRetryDelay time.Duration) *PollingBuilder {
+	if firstRetryDelay <= 0 {
+		p.firstRetryDelay = defaultFirstRetryDelay
+	} else {
+		p.firstRetryDelay = firstRetryDelay
+	}
+	return p
+}
+
+// Filter sets the FlagFilter selecting the flags kept in the data storage, all the flags are kept by default.
+// In a FallbackBuilder, the filter should be applied to the FallbackBuilder by WithFlagFilter
+func (p *PollingBuilder) Filter(filter FlagFilter) *PollingBuilder {
+	p.filter = &filter
+	return p
+}
+
+// CreateDataSynchronizer creates an instance of interfaces.DataSynchronizer
+func (p *PollingBuilder) CreateDataSynchronizer(context Context, dataUpdater DataUpdater) (DataSynchronizer, error) {
+	client, ok := context.GetNetwork().GetHTTPClient().(*http.Client)
+	if !ok {
+		return nil, fmt.Errorf("non supported HTTP Client")
+	}
+	if _, ok := context.(PollingConfig); !ok {
+		return nil, fmt.Errorf("the context doesn't give the polling url")
+	}
+	return datasynchronization.NewPolling(context, p.filter.apply(dataUpdater), client, p.pollingInterval, p.firstRetryDelay), nil
+}
@@ -0,0 +1,82 @@
+package bigsegments
+
+import (
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/gomodule/redigo/redis"
+	"time"
+)
+
+// RedisBigSegmentStore is a BigSegmentStore reading the memberships from redis, populated by another process.
+//
+// All the keys start with the prefix:
+//   - {prefix}:big_segment_include:{user key} is the set of the ids of the big segments including the user
+//   - {prefix}:big_segment_exclude:{user key} is the set of the ids of the big segments excluding the user
+//   - {prefix}:big_segments:$synchronizedOn is the last time the memberships were synchronized, in unix milliseconds
+type RedisBigSegmentStore struct {
+	pool    *redis.Pool
+	ownPool bool
+	prefix  string
+}
+
+// NewRedisBigSegmentStore creates a RedisBigSegmentStore, the pool is closed with the store if ownPool is true
+func NewRedisBigSegmentStore(pool *redis.Pool, ownPool bool, prefix string) *RedisBigSegmentStore {
+	return &RedisBigSegmentStore{pool: pool, ownPool: ownPool, prefix: prefix}
+}
+
+func (r *RedisBigSegmentStore) includeKey(userKey string) string {
+	return r.prefix + ":big_segment_include:" + userKey
+}
+
+func (r *RedisBigSegmentStore) excludeKey(userKey string) string {
+	return r.prefix + ":big_segment_exclude:" + userKey
+}
+
+func (r *RedisBigSegmentStore) synchronizedOnKey() string {
+	return r.prefix + ":big_segments:$synchronizedOn"
+}
+
+func (r *RedisBigSegmentStore) Close() error {
+	if r.ownPool {
+		return r.pool.Close()
+	}
+	return nil
+}
+
+func (r *RedisBigSegmentStore) GetMembership(userKey string) (BigSegmentMembership, error) {
+	conn := r.pool.Get()
+	defer conn.Close()
+	_ = conn.Send("SMEMBERS", r.includeKey(userKey))
+	_ = conn.Send("SMEMBERS", r.excludeKey(userKey))
+	if err := conn.Flush(); err != nil {
+		return nil, err
+	}
+	included, err := redis.Strings(conn.Receive())
+	if err != nil {
+		return nil, err
+	}
+	excluded, err := redis.Strings(conn.Receive())
+	if err != nil {
+		return nil, err
+	}
+	membership := make(BigSegmentMembership, len(included)+len(excluded))
+	for _, id := range included {
+		membership[id] = true
+	}
+	// the exclusion wins over the inclusion, as the user lists of a segment
+	for _, id := range excluded {
+		membership[id] = false
+	}
+	return membership, nil
+}
+
+func (r *RedisBigSegmentStore) GetMetadata() (BigSegmentStoreMetadata, error) {
+	conn := r.pool.Get()
+	defer conn.Close()
+	millis, err := redis.Int64(conn.Do("GET", r.synchronizedOnKey()))
+	if err == redis.ErrNil {
+		return BigSegmentStoreMetadata{}, nil
+	} else if err != nil {
+		return BigSegmentStoreMetadata{}, err
+	}
+	return BigSegmentStoreMetadata{LastUpdated: time.Unix(0, millis*int64(time.Millisecond))}, nil
+}
@@ -0,0 +1,44 @@
+package bigsegments
+
+import (
+	"github.com/alicebob/miniredis/v2"
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRedisBigSegmentStore(t *testing.T) {
+	server, err := miniredis.Run()
+	require.NoError(t, err)
+	defer server.Close()
+	addr := server.Addr()
+	pool := &redis.Pool{Dial: func() (redis.Conn, error) { return redis.Dial("tcp", addr) }}
+	store := NewRedisBigSegmentStore(pool, true, "test")
+	defer store.Close()
+
+	metadata, err := store.GetMetadata()
+	require.NoError(t, err)
+	assert.True(t, metadata.LastUpdated.IsZero())
+
+	_, _ = server.SAdd("test:big_segment_include:u1", "seg1", "seg2")
+	_, _ = server.SAdd("test:big_segment_exclude:u1", "seg2", "seg3")
+	require.NoError(t, server.Set("test:big_segments:$synchronizedOn", "1674114559000"))
+	membership, err := store.GetMembership("u1")
+	require.NoError(t, err)
+	assert.Equal(t, BigSegmentMembership{"seg1": true, "seg2": false, "seg3": false}, membership)
+	membership, err = store.GetMembership("u2")
+	require.NoError(t, err)
+	assert.Empty(t, membership)
+	metadata, err = store.GetMetadata()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1674114559000), metadata.LastUpdated.UnixNano()/int64(time.Millisecond))
+
+	server.Close()
+	_, err = store.GetMembership("u1")
+	assert.Error(t, err)
+	_, err = store.GetMetadata()
+	assert.Error(t, err)
+}
@@ -0,0 +1,301 @@
+package datastorage
+
+import (
+	"fmt"
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/types/data"
+	"github.com/featbit/featbit-go-sdk/internal/util/log"
+	"github.com/gomodule/redigo/redis"
+	"sync"
+	"time"
+)
+
+// the categories persisted in redis
+var redisCategories = []Category{data.Features, data.Segments}
+
+// KEYS: the version key, then the hash key of each category
+// ARGV: the version, then for each category, the number of items followed by the pairs of key and encoded item
+var redisInitScript = redis.NewScript(-1, `
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+if current >= tonumber(ARGV[1]) then
+	return 0
+end
+local i = 2
+for k = 2, #KEYS do
+	redis.call('DEL', KEYS[k])
+	local n = tonumber(ARGV[i])
+	i = i + 1
+	for j = 1, n do
+		redis.call('HSET', KEYS[k], ARGV[i], ARGV[i + 1])
+		i = i + 2
+	end
+end
+redis.call('SET', KEYS[1], ARGV[1])
+return 1
+`)
+
+// KEYS: the version key, the hash key of the category
+// ARGV: the version, the key of item, the timestamp of item, the encoded item
+var redisUpsertScript = redis.NewScript(2, `
+local old = redis.call('HGET', KEYS[2], ARGV[2])
+if old and cjson.decode(old).timestamp >= tonumber(ARGV[3]) then
+	return 0
+end
+redis.call('HSET', KEYS[2], ARGV[2], ARGV[4])
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+if current < tonumber(ARGV[1]) then
+	redis.call('SET', KEYS[1], ARGV[1])
+end
+return 1
+`)
+
+// KEYS: the version key, then the hash key of each category
+// ARGV: the version, then for each category, the number of items followed by the triples of key, timestamp and encoded item
+var redisUpsertBatchScript = redis.NewScript(-1, `
+local updated = 0
+local i = 2
+for k = 2, #KEYS do
+	local n = tonumber(ARGV[i])
+	i = i + 1
+	for j = 1, n do
+		local old = redis.call('HGET', KEYS[k], ARGV[i])
+		if not old or cjson.decode(old).timestamp < tonumber(ARGV[i + 1]) then
+			redis.call('HSET', KEYS[k], ARGV[i], ARGV[i + 2])
+			updated = 1
+		end
+		i = i + 3
+	end
+end
+if updated == 1 and tonumber(redis.call('GET', KEYS[1]) or '0') < tonumber(ARGV[1]) then
+	redis.call('SET', KEYS[1], ARGV[1])
+end
+return updated
+`)
+
+// RedisDataStorage is a DataStorage that persists the data in redis, so that the data are shared by the SDK instances
+// and survive the restarts.
+//
+// All the keys start with the prefix, the items of a category are saved in a hash,
+// the version checks are done atomically by the lua scripts: an item is only replaced by a newer one,
+// and the version of storage is the latest version applied.
+type RedisDataStorage struct {
+	pool        *redis.Pool
+	ownPool     bool
+	prefix      string
+	lock        sync.RWMutex
+	initialized bool
+	// the interval at which the availability of redis is probed
+	probeInterval time.Duration
+}
+
+// NewRedisDataStorage creates a RedisDataStorage, the pool is closed with the storage if ownPool is true.
+// The availability of redis is probed at the given interval, the default interval of the SDK is used if it's 0.
+func NewRedisDataStorage(pool *redis.Pool, ownPool bool, prefix string, probeInterval time.Duration) *RedisDataStorage {
+	return &RedisDataStorage{pool: pool, ownPool: ownPool, prefix: prefix, probeInterval: probeInterval}
+}
+
+// ProbeInterval returns the interval at which the availability of redis is probed
+func (r *RedisDataStorage) ProbeInterval() time.Duration {
+	return r.probeInterval
+}
+
+func (r *RedisDataStorage) versionKey() string {
+	return r.prefix + ":$version"
+}
+
+func (r *RedisDataStorage) itemsKey(category Category) string {
+	return r.prefix + ":" + category.GetTag()
+}
+
+func isRedisCategory(category Category) bool {
+	for _, cat := range redisCategories {
+		if cat == category {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RedisDataStorage) Close() error {
+	if r.ownPool {
+		return r.pool.Close()
+	}
+	return nil
+}
+
+func (r *RedisDataStorage) Init(allData map[Category]map[string]Item, version int64) error {
+	if len(allData) == 0 {
+		return nil
+	}
+	keys := []interface{}{r.versionKey()}
+	args := []interface{}{version}
+	for cat := range allData {
+		if !isRedisCategory(cat) {
+			return fmt.Errorf("unsupported category: %s", cat.GetName())
+		}
+	}
+	// the categories not in the data are cleared
+	for _, cat := range redisCategories {
+		items := allData[cat]
+		keys = append(keys, r.itemsKey(cat))
+		args = append(args, len(items))
+		for key, item := range items {
+			bytes, err := data.EncodeItem(item)
+			if err != nil {
+				return err
+			}
+			args = append(args, key, bytes)
+		}
+	}
+	conn := r.pool.Get()
+	defer conn.Close()
+	params := append([]interface{}{len(keys)}, keys...)
+	params = append(params, args...)
+	if _, err := redis.Int(redisInitScript.Do(conn, params...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *RedisDataStorage) Upsert(category Category, key string, item Item, version int64) (bool, error) {
+	if version <= 0 || item == nil || category == nil || key == "" {
+		return false, nil
+	}
+	if !isRedisCategory(category) {
+		return false, fmt.Errorf("unsupported category: %s", category.GetName())
+	}
+	bytes, err := data.EncodeItem(item)
+	if err != nil {
+		return false, err
+	}
+	conn := r.pool.Get()
+	defer conn.Close()
+	res, err := redis.Int(redisUpsertScript.Do(conn, r.versionKey(), r.itemsKey(category), version, key, item.GetTimestamp(), bytes))
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
+
+// UpsertBatch applies the items in a lua script, which is atomic in redis
+func (r *RedisDataStorage) UpsertBatch(items map[Category]map[string]Item, version int64) (bool, error) {
+	if version <= 0 {
+		return false, nil
+	}
+	keys := []interface{}{r.versionKey()}
+	args := []interface{}{version}
+	for cat, catItems := range items {
+		if !isRedisCategory(cat) {
+			return false, fmt.Errorf("unsupported category: %s", cat.GetName())
+		}
+		keys = append(keys, r.itemsKey(cat))
+		n := len(args)
+		args = append(args, 0)
+		for key, item := range catItems {
+			if item == nil || key == "" {
+				continue
+			}
+			bytes, err := data.EncodeItem(item)
+			if err != nil {
+				return false, err
+			}
+			args = append(args, key, item.GetTimestamp(), bytes)
+		}
+		args[n] = (len(args) - n - 1) / 3
+	}
+	if len(keys) == 1 {
+		return false, nil
+	}
+	conn := r.pool.Get()
+	defer conn.Close()
+	params := append([]interface{}{len(keys)}, keys...)
+	params = append(params, args...)
+	res, err := redis.Int(redisUpsertBatchScript.Do(conn, params...))
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
+
+func (r *RedisDataStorage) Get(category Category, key string) (Item, error) {
+	conn := r.pool.Get()
+	defer conn.Close()
+	bytes, err := redis.Bytes(conn.Do("HGET", r.itemsKey(category), key))
+	if err == redis.ErrNil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	item, err := data.DecodeItem(category, bytes)
+	if err != nil {
+		return nil, err
+	}
+	if item.IsArchived() {
+		return nil, nil
+	}
+	return item, nil
+}
+
+func (r *RedisDataStorage) GetAll(category Category) (map[string]Item, error) {
+	conn := r.pool.Get()
+	defer conn.Close()
+	values, err := redis.StringMap(conn.Do("HGETALL", r.itemsKey(category)))
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]Item, len(values))
+	for key, value := range values {
+		item, err := data.DecodeItem(category, []byte(value))
+		if err != nil {
+			return nil, err
+		}
+		if !item.IsArchived() {
+			res[key] = item
+		}
+	}
+	return res, nil
+}
+
+// IsInitialized returns true if the storage is initialized by any of the SDK instances sharing the same prefix
+func (r *RedisDataStorage) IsInitialized() bool {
+	r.lock.RLock()
+	initialized := r.initialized
+	r.lock.RUnlock()
+	if initialized {
+		return true
+	}
+	conn := r.pool.Get()
+	defer conn.Close()
+	exists, err := redis.Bool(conn.Do("EXISTS", r.versionKey()))
+	if err != nil {
+		log.LogError("FB GO SDK: redis error: %v", err)
+		return false
+	}
+	if exists {
+		// the data in redis are never removed by the SDK
+		r.lock.Lock()
+		r.initialized = true
+		r.lock.Unlock()
+	}
+	return exists
+}
+
+func (r *RedisDataStorage) GetVersion() int64 {
+	conn := r.pool.Get()
+	defer conn.Close()
+	version, err := redis.Int64(conn.Do("GET", r.versionKey()))
+	if err != nil {
+		if err != redis.ErrNil {
+			log.LogError("FB GO SDK: redis error: %v", err)
+		}
+		return 0
+	}
+	return version
+}
+
+func (r *RedisDataStorage) CheckAvailability() error {
+	conn := r.pool.Get()
+	defer conn.Close()
+	_, err := conn.Do("PING")
+	return err
+}
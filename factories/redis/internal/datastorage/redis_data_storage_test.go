@@ -0,0 +1,154 @@
+package datastorage
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/alicebob/miniredis/v2"
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/types/data"
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func newFlag(t *testing.T, key string, value string, updatedAt int64, archived bool) *data.FeatureFlag {
+	jsonStr := fmt.Sprintf(`{"id":"%[1]s","key":"%[1]s","name":"%[1]s","isArchived":%[4]t,"isEnabled":false,"disabledVariationId":"v1","variations":[{"id":"v1","value":"%[2]s"}],"updatedAt":"%[3]s"}`,
+		key, value, time.Unix(0, updatedAt*int64(time.Millisecond)).UTC().Format(time.RFC3339Nano), archived)
+	var flag data.FeatureFlag
+	require.NoError(t, json.Unmarshal([]byte(jsonStr), &flag))
+	return &flag
+}
+
+func newSegment(t *testing.T, id string, updatedAt int64) *data.Segment {
+	jsonStr := fmt.Sprintf(`{"id":"%s","included":["u1","u2"],"excluded":["u3"],"rules":[],"updatedAt":"%s"}`,
+		id, time.Unix(0, updatedAt*int64(time.Millisecond)).UTC().Format(time.RFC3339Nano))
+	var segment data.Segment
+	require.NoError(t, json.Unmarshal([]byte(jsonStr), &segment))
+	return &segment
+}
+
+func newTestRedisStorage(t *testing.T) (*miniredis.Miniredis, *RedisDataStorage) {
+	server, err := miniredis.Run()
+	require.NoError(t, err)
+	addr := server.Addr()
+	pool := &redis.Pool{Dial: func() (redis.Conn, error) { return redis.Dial("tcp", addr) }}
+	return server, NewRedisDataStorage(pool, true, "test", 0)
+}
+
+func TestRedisDataStorage(t *testing.T) {
+	t.Run("init and get", func(t *testing.T) {
+		server, storage := newTestRedisStorage(t)
+		defer server.Close()
+		defer storage.Close()
+		assert.False(t, storage.IsInitialized())
+		assert.Equal(t, int64(0), storage.GetVersion())
+
+		allData := map[Category]map[string]Item{
+			data.Features: {"ff1": newFlag(t, "ff1", "a", 10, false), "ff2": newFlag(t, "ff2", "b", 20, true).ToArchivedItem()},
+			data.Segments: {"seg1": newSegment(t, "seg1", 30)},
+		}
+		require.NoError(t, storage.Init(allData, 30))
+		assert.True(t, storage.IsInitialized())
+		assert.Equal(t, int64(30), storage.GetVersion())
+		assert.True(t, server.Exists("test:ff"))
+		version, _ := server.Get("test:$version")
+		assert.Equal(t, "30", version)
+
+		item, err := storage.Get(data.Features, "ff1")
+		require.NoError(t, err)
+		flag := item.(*data.FeatureFlag)
+		assert.Equal(t, "a", flag.GetFlagValue("v1"))
+		assert.Equal(t, int64(10), flag.GetTimestamp())
+		item, err = storage.Get(data.Features, "ff2")
+		require.NoError(t, err)
+		assert.Nil(t, item)
+		item, err = storage.Get(data.Features, "unknown")
+		require.NoError(t, err)
+		assert.Nil(t, item)
+		item, err = storage.Get(data.Segments, "seg1")
+		require.NoError(t, err)
+		segment := item.(*data.Segment)
+		assert.Equal(t, data.SegmentIncludeUser, segment.MatchUser("u2"))
+		assert.Equal(t, data.SegmentExcludeUser, segment.MatchUser("u3"))
+		assert.Equal(t, int64(30), segment.GetTimestamp())
+		all, err := storage.GetAll(data.Features)
+		require.NoError(t, err)
+		assert.Equal(t, 1, len(all))
+
+		// an older version is ignored
+		require.NoError(t, storage.Init(map[Category]map[string]Item{data.Features: {}}, 20))
+		all, _ = storage.GetAll(data.Features)
+		assert.Equal(t, 1, len(all))
+		// a newer version replaces all the data
+		require.NoError(t, storage.Init(map[Category]map[string]Item{data.Features: {"ff3": newFlag(t, "ff3", "c", 40, false)}}, 40))
+		all, _ = storage.GetAll(data.Features)
+		assert.Equal(t, 1, len(all))
+		assert.NotNil(t, all["ff3"])
+		all, _ = storage.GetAll(data.Segments)
+		assert.Equal(t, 0, len(all))
+
+		err = storage.Init(map[Category]map[string]Item{data.Datatests: {"test": data.NewTestItem(false)}}, 50)
+		assert.Error(t, err)
+	})
+
+	t.Run("upsert", func(t *testing.T) {
+		server, storage := newTestRedisStorage(t)
+		defer server.Close()
+		defer storage.Close()
+
+		ok, err := storage.Upsert(data.Features, "ff1", newFlag(t, "ff1", "a", 10, false), 10)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.True(t, storage.IsInitialized())
+		// a delayed item is applied, the version of storage is not changed
+		ok, _ = storage.Upsert(data.Features, "ff0", newFlag(t, "ff0", "z", 5, false), 5)
+		assert.True(t, ok)
+		assert.Equal(t, int64(10), storage.GetVersion())
+		// the item is not newer
+		ok, _ = storage.Upsert(data.Features, "ff1", newFlag(t, "ff1", "b", 10, false), 20)
+		assert.False(t, ok)
+		ok, _ = storage.Upsert(data.Features, "ff1", newFlag(t, "ff1", "b", 20, false), 20)
+		assert.True(t, ok)
+		assert.Equal(t, int64(20), storage.GetVersion())
+		item, _ := storage.Get(data.Features, "ff1")
+		assert.Equal(t, "b", item.(*data.FeatureFlag).GetFlagValue("v1"))
+
+		archived := newFlag(t, "ff1", "b", 30, true).ToArchivedItem()
+		ok, _ = storage.Upsert(data.Features, "ff1", archived, 30)
+		assert.True(t, ok)
+		item, _ = storage.Get(data.Features, "ff1")
+		assert.Nil(t, item)
+		// the placeholder is kept
+		ok, _ = storage.Upsert(data.Features, "ff1", newFlag(t, "ff1", "c", 25, false), 40)
+		assert.False(t, ok)
+	})
+
+	t.Run("shared by instances", func(t *testing.T) {
+		server, storage1 := newTestRedisStorage(t)
+		defer server.Close()
+		defer storage1.Close()
+		pool := &redis.Pool{Dial: func() (redis.Conn, error) { return redis.Dial("tcp", server.Addr()) }}
+		storage2 := NewRedisDataStorage(pool, true, "test", 0)
+		defer storage2.Close()
+		other := NewRedisDataStorage(pool, false, "other", 0)
+
+		_, _ = storage1.Upsert(data.Features, "ff1", newFlag(t, "ff1", "a", 10, false), 10)
+		assert.True(t, storage2.IsInitialized())
+		assert.Equal(t, int64(10), storage2.GetVersion())
+		item, _ := storage2.Get(data.Features, "ff1")
+		assert.NotNil(t, item)
+		assert.False(t, other.IsInitialized())
+	})
+
+	t.Run("redis error", func(t *testing.T) {
+		server, storage := newTestRedisStorage(t)
+		defer storage.Close()
+		server.Close()
+		_, err := storage.Get(data.Features, "ff1")
+		assert.Error(t, err)
+		assert.False(t, storage.IsInitialized())
+		assert.Equal(t, int64(0), storage.GetVersion())
+	})
+}
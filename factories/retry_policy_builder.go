@@ -0,0 +1,117 @@
+package factories
+
+import (
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/datasynchronization"
+	"time"
+)
+
+const defaultMaxAuthFailures = 3
+
+// BackoffRetryPolicyBuilder factory to create the default implementation of interfaces.RetryPolicy: the delay before
+// the next attempt is doubled after each failure, up to the max delay; a part of the delay, given by the jitter ratio,
+// is randomized so that the instances don't reconnect at the same time. The delay is reset to the first one
+// if the last connection lasted longer than the reset interval.
+//
+//	policy := factories.NewBackoffRetryPolicyBuilder().MaxRetryDelay(30 * time.Second).JitterRatio(0.2)
+//	factory := factories.NewStreamingBuilder().RetryPolicy(policy)
+type BackoffRetryPolicyBuilder struct {
+	firstRetryDelay time.Duration
+	maxRetryDelay   time.Duration
+	resetInterval   time.Duration
+	jitterRatio     float64
+}
+
+// NewBackoffRetryPolicyBuilder creates an instance of BackoffRetryPolicyBuilder
+func NewBackoffRetryPolicyBuilder() *BackoffRetryPolicyBuilder {
+	return &BackoffRetryPolicyBuilder{
+		firstRetryDelay: defaultFirstRetryDelay,
+		maxRetryDelay:   datasynchronization.DefaultMaxRetryDelay,
+		resetInterval:   datasynchronization.DefaultResetInterval,
+		jitterRatio:     datasynchronization.DefaultJitterRatio,
+	}
+}
+
+// FirstRetryDelay sets the delay after the first failure, 1 second by default
+func (b *BackoffRetryPolicyBuilder) FirstRetryDelay(firstRetryDelay time.Duration) *BackoffRetryPolicyBuilder {
+	if firstRetryDelay <= 0 {
+		b.firstRetryDelay = defaultFirstRetryDelay
+	} else {
+		b.firstRetryDelay = firstRetryDelay
+	}
+	return b
+}
+
+// MaxRetryDelay sets the max delay between two attempts, 60 seconds by default
+func (b *BackoffRetryPolicyBuilder) MaxRetryDelay(maxRetryDelay time.Duration) *BackoffRetryPolicyBuilder {
+	if maxRetryDelay <= 0 {
+		b.maxRetryDelay = datasynchronization.DefaultMaxRetryDelay
+	} else {
+		b.maxRetryDelay = maxRetryDelay
+	}
+	return b
+}
+
+// ResetInterval sets how long a connection should last to reset the delay to the first one, 60 seconds by default
+func (b *BackoffRetryPolicyBuilder) ResetInterval(resetInterval time.Duration) *BackoffRetryPolicyBuilder {
+	if resetInterval <= 0 {
+		b.resetInterval = datasynchronization.DefaultResetInterval
+	} else {
+		b.resetInterval = resetInterval
+	}
+	return b
+}
+
+// JitterRatio sets the part of the delay that is randomized, between 0 (no jitter) and 1, 0.5 by default
+func (b *BackoffRetryPolicyBuilder) JitterRatio(jitterRatio float64) *BackoffRetryPolicyBuilder {
+	if jitterRatio < 0 || jitterRatio > 1 {
+		b.jitterRatio = datasynchronization.DefaultJitterRatio
+	} else {
+		b.jitterRatio = jitterRatio
+	}
+	return b
+}
+
+// CreateRetryPolicy creates an instance of interfaces.RetryPolicy
+func (b *BackoffRetryPolicyBuilder) CreateRetryPolicy(_ Context) (RetryPolicy, error) {
+	return datasynchronization.NewBackoffAndJitterStrategy(b.firstRetryDelay, b.maxRetryDelay, b.resetInterval, b.jitterRatio), nil
+}
+
+// CircuitBreakerRetryPolicyBuilder factory to create an interfaces.RetryPolicy that stops retrying after a number of
+// consecutive auth failures, when the feature flag center keeps rejecting the env secret. The delays between the attempts
+// are given by the wrapped policy.
+//
+//	policy := factories.NewCircuitBreakerRetryPolicyBuilder(factories.NewBackoffRetryPolicyBuilder()).MaxAuthFailures(5)
+//	factory := factories.NewStreamingBuilder().RetryPolicy(policy)
+type CircuitBreakerRetryPolicyBuilder struct {
+	policy          RetryPolicyFactory
+	maxAuthFailures int
+}
+
+// NewCircuitBreakerRetryPolicyBuilder creates an instance of CircuitBreakerRetryPolicyBuilder wrapping the given policy,
+// the default BackoffRetryPolicyBuilder if it's nil
+func NewCircuitBreakerRetryPolicyBuilder(policy RetryPolicyFactory) *CircuitBreakerRetryPolicyBuilder {
+	if policy == nil {
+		policy = NewBackoffRetryPolicyBuilder()
+	}
+	return &CircuitBreakerRetryPolicyBuilder{policy: policy, maxAuthFailures: defaultMaxAuthFailures}
+}
+
+// MaxAuthFailures sets the number of consecutive auth failures before giving up, 3 by default
+func (c *CircuitBreakerRetryPolicyBuilder) MaxAuthFailures(maxAuthFailures int) *CircuitBreakerRetryPolicyBuilder {
+	if maxAuthFailures <= 0 {
+		c.maxAuthFailures = defaultMaxAuthFailures
+	} else {
+		c.maxAuthFailures = maxAuthFailures
+	}
+	return c
+}
+
+// CreateRetryPolicy creates an instance of interfaces.RetryPolicy
+func (c *CircuitBreakerRetryPolicyBuilder) CreateRetryPolicy(context Context) (RetryPolicy, error) {
+	policy, err := c.policy.CreateRetryPolicy(context)
+	if err != nil {
+		return nil, err
+	}
+	return datasynchronization.NewCircuitBreakerRetryPolicy(policy, c.maxAuthFailures), nil
+}
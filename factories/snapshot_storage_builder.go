@@ -0,0 +1,43 @@
+package factories
+
+import (
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/datastorage"
+)
+
+// SnapshotStorageBuilder factory to create an implementation of interfaces.DataStorage that saves the data to a local
+// snapshot file after each update, and loads the last snapshot at startup for the warm starts: the flags are evaluated
+// from the snapshot even if the feature flag center is unreachable.
+//
+// The data loaded from the snapshot are stale until the data synchronizer delivers fresh data, it's reported by
+// interfaces.EvalDetail.Stale and interfaces.StaleStatusProvider.IsStale. The snapshot is only loaded by the SDK
+// of the environment that saved it.
+//
+//	factory := factories.NewSnapshotStorageBuilder("/var/lib/myapp/featbit.json")
+//	config := featbit.FBConfig{DataStorageFactory: factory}
+type SnapshotStorageBuilder struct {
+	path    string
+	storage DataStorageFactory
+}
+
+// NewSnapshotStorageBuilder creates an instance of SnapshotStorageBuilder with the path of the snapshot file
+func NewSnapshotStorageBuilder(path string) *SnapshotStorageBuilder {
+	return &SnapshotStorageBuilder{path: path, storage: NewInMemoryStorageBuilder()}
+}
+
+// Storage sets the factory of the storage to decorate, InMemoryStorageBuilder by default
+func (s *SnapshotStorageBuilder) Storage(storage DataStorageFactory) *SnapshotStorageBuilder {
+	if storage != nil {
+		s.storage = storage
+	}
+	return s
+}
+
+// CreateDataStorage creates an instance of interfaces.DataStorage
+func (s *SnapshotStorageBuilder) CreateDataStorage(context Context) (DataStorage, error) {
+	storage, err := s.storage.CreateDataStorage(context)
+	if err != nil {
+		return nil, err
+	}
+	return datastorage.NewSnapshotDataStorage(storage, s.path, context.GetEnvSecret()), nil
+}
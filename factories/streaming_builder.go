@@ -15,14 +15,24 @@ const defaultFirstRetryDelay = time.Second
 type StreamingBuilder struct {
 	firstRetryDelay time.Duration
 	maxRetryTimes   int
+	retryPolicy     RetryPolicyFactory
+	pingInterval    time.Duration
+	pongTimeout     time.Duration
+	filter          *FlagFilter
 }
 
 // NewStreamingBuilder creates an instance of StreamingBuilder
 func NewStreamingBuilder() *StreamingBuilder {
-	return &StreamingBuilder{firstRetryDelay: defaultFirstRetryDelay, maxRetryTimes: math.MaxInt32}
+	return &StreamingBuilder{
+		firstRetryDelay: defaultFirstRetryDelay,
+		maxRetryTimes:   math.MaxInt32,
+		pingInterval:    datasynchronization.DefaultPingInterval,
+		pongTimeout:     datasynchronization.DefaultPongTimeout,
+	}
 }
 
-// FirstRetryDelay sets the time to wait for next retry if the last data synchronization failed
+// FirstRetryDelay sets the time to wait for next retry if the last data synchronization failed,
+// it's ignored if a RetryPolicy is set
 func (s *StreamingBuilder) FirstRetryDelay(firstRetryDelay time.Duration) *StreamingBuilder {
 	if firstRetryDelay <= 0 {
 		s.firstRetryDelay = defaultFirstRetryDelay
@@ -32,7 +42,8 @@ func (s *StreamingBuilder) FirstRetryDelay(firstRetryDelay time.Duration) *Strea
 	return s
 }
 
-// MaxRetryTimes sets the max retry times for the failed data synchronization
+// MaxRetryTimes sets the max retry times after consecutive failed connections, the streaming stops with the OFF state
+// once exceeded. The counter is reset by a successful connection, the default value is unlimited.
 func (s *StreamingBuilder) MaxRetryTimes(maxRetryTimes int) *StreamingBuilder {
 	if maxRetryTimes <= 0 {
 		s.maxRetryTimes = math.MaxInt32
@@ -42,6 +53,43 @@ func (s *StreamingBuilder) MaxRetryTimes(maxRetryTimes int) *StreamingBuilder {
 	return s
 }
 
+// RetryPolicy sets the factory of the interfaces.RetryPolicy deciding when to reconnect after a failure, such as
+// BackoffRetryPolicyBuilder or CircuitBreakerRetryPolicyBuilder. By default, the delay is doubled after each failure
+// from FirstRetryDelay up to 60 seconds.
+func (s *StreamingBuilder) RetryPolicy(retryPolicy RetryPolicyFactory) *StreamingBuilder {
+	s.retryPolicy = retryPolicy
+	return s
+}
+
+// PingInterval sets the interval of the pings sent to keep the websocket connection alive, 10 seconds by default
+func (s *StreamingBuilder) PingInterval(pingInterval time.Duration) *StreamingBuilder {
+	if pingInterval <= 0 {
+		s.pingInterval = datasynchronization.DefaultPingInterval
+	} else {
+		s.pingInterval = pingInterval
+	}
+	return s
+}
+
+// PongTimeout sets how long to wait for the answer of a ping, 10 seconds by default. If nothing is received from
+// the server in the ping interval plus this timeout, the connection is considered as lost, the data update status
+// becomes INTERRUPTED and the streaming reconnects.
+func (s *StreamingBuilder) PongTimeout(pongTimeout time.Duration) *StreamingBuilder {
+	if pongTimeout <= 0 {
+		s.pongTimeout = datasynchronization.DefaultPongTimeout
+	} else {
+		s.pongTimeout = pongTimeout
+	}
+	return s
+}
+
+// Filter sets the FlagFilter selecting the flags kept in the data storage, all the flags are kept by default.
+// In a FallbackBuilder, the filter should be applied to the FallbackBuilder by WithFlagFilter
+func (s *StreamingBuilder) Filter(filter FlagFilter) *StreamingBuilder {
+	s.filter = &filter
+	return s
+}
+
 // CreateDataSynchronizer creates an instance of interfaces.DataSynchronizer
 func (s *StreamingBuilder) CreateDataSynchronizer(context Context, dataUpdater DataUpdater) (DataSynchronizer, error) {
 	network := context.GetNetwork()
@@ -49,7 +97,16 @@ func (s *StreamingBuilder) CreateDataSynchronizer(context Context, dataUpdater D
 	if !ok {
 		return nil, fmt.Errorf("non supported Websocket Client")
 	}
-	return datasynchronization.NewStreaming(context, dataUpdater, s.firstRetryDelay, s.maxRetryTimes), nil
+	retryPolicyFactory := s.retryPolicy
+	if retryPolicyFactory == nil {
+		retryPolicyFactory = NewBackoffRetryPolicyBuilder().FirstRetryDelay(s.firstRetryDelay)
+	}
+	// each streaming has its own retry state
+	retryPolicy, err := retryPolicyFactory.CreateRetryPolicy(context)
+	if err != nil {
+		return nil, err
+	}
+	return datasynchronization.NewStreaming(context, s.filter.apply(dataUpdater), retryPolicy, s.maxRetryTimes, s.pingInterval, s.pongTimeout), nil
 }
 
 type nullDataSynchronizerBuilder struct{}
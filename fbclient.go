@@ -6,26 +6,35 @@ import (
 	"github.com/featbit/featbit-go-sdk/factories"
 	. "github.com/featbit/featbit-go-sdk/interfaces"
 	"github.com/featbit/featbit-go-sdk/internal"
+	"github.com/featbit/featbit-go-sdk/internal/datastorage"
 	"github.com/featbit/featbit-go-sdk/internal/datasynchronization"
 	"github.com/featbit/featbit-go-sdk/internal/dataupdating"
 	"github.com/featbit/featbit-go-sdk/internal/types/data"
 	"github.com/featbit/featbit-go-sdk/internal/types/insight"
 	"github.com/featbit/featbit-go-sdk/internal/util"
 	"github.com/featbit/featbit-go-sdk/internal/util/log"
+	"io"
 	"sync"
 	"time"
 )
 
 type FBClient struct {
-	offline                  bool
-	dataStorage              DataStorage
-	dataSynchronizer         DataSynchronizer
-	dataUpdater              DataUpdater
-	dataUpdateStatusProvider DataUpdateStatusProvider
-	insightProcessor         InsightProcessor
-	evaluator                *evaluator
-	getFlag                  func(key string) *data.FeatureFlag
-	sendEvent                func(Event)
+	offline                   bool
+	dataStorage               DataStorage
+	dataSynchronizer          DataSynchronizer
+	dataUpdater               DataUpdater
+	dataUpdateStatusProvider  DataUpdateStatusProvider
+	dataStorageStatusProvider DataStorageStatusProvider
+	insightProcessor          InsightProcessor
+	flagOverrides             FlagOverrides
+	evaluator                 *evaluator
+	getFlag                   func(key string) *data.FeatureFlag
+	sendEvent                 func(Event)
+	subscribeDataUpdate       func() (<-chan struct{}, func())
+	subscribeChanges          func(bufferSize int) ChangeSubscription
+	history                   *dataupdating.History
+	bigSegments               BigSegments
+	segmentCache              *util.LRUCache
 }
 
 var (
@@ -33,9 +42,15 @@ var (
 	hostInvalid           = fmt.Errorf("invalid streaming url or event url")
 	initializationTimeout = fmt.Errorf("timeout encountered waiting for client initialization")
 	initializationFailed  = fmt.Errorf("client initialization failed")
+	daemonModeInvalid     = fmt.Errorf("daemon mode requires a persistent data storage")
+	daemonModeConflict    = fmt.Errorf("daemon mode doesn't accept a data synchronizer other than factories.DaemonBuilder")
 	clientNotInitialized  = fmt.Errorf("evaluation is called before client is initialized")
 	emptyClient           = fmt.Errorf("empty client, please call constructor")
 	flagNotFound          = fmt.Errorf("feature flag not found")
+	historyDisabled       = fmt.Errorf("local history is disabled")
+	flagVersionNotFound   = fmt.Errorf("feature flag version not found in local history")
+	snapshotInvalid       = fmt.Errorf("invalid snapshot, a full data-sync message is expected")
+	snapshotImportFailed  = fmt.Errorf("failed to import the snapshot into data storage")
 	userInvalid           = fmt.Errorf("invalid user")
 	evalFailed            = fmt.Errorf("evaluation failed")
 	evalWrongType         = fmt.Errorf("flag type doesn't match the request")
@@ -123,6 +138,9 @@ func MakeCustomFBClient(envSecret string, streamingUrl string, eventUrl string,
 			return nil, envSecretInvalid
 		} else if !util.IsUrl(streamingUrl) || !util.IsUrl(eventUrl) {
 			return nil, hostInvalid
+		} else if _, ok := config.DataSynchronizerFactory.(*factories.DaemonBuilder); config.DaemonMode && config.DataSynchronizerFactory != nil && !ok {
+			// the data synchronizer configured by the user would be silently ignored
+			return nil, daemonModeConflict
 		}
 	} else {
 		log.LogInfo("FB GO SDK: SDK is in offline mode")
@@ -146,31 +164,44 @@ func MakeCustomFBClient(envSecret string, streamingUrl string, eventUrl string,
 	if err != nil {
 		return nil, err
 	}
-	//evaluator
-	client.getFlag = func(key string) *data.FeatureFlag {
-		if item, e := client.dataStorage.Get(data.Features, key); e == nil {
-			if flag, ok := item.(*data.FeatureFlag); ok {
-				return flag
-			}
-		}
-		return nil
-	}
-
-	getSegment := func(key string) *data.Segment {
-		if item, e := client.dataStorage.Get(data.Segments, key); e == nil {
-			if segment, ok := item.(*data.Segment); ok {
-				return segment
-			}
-		}
-		return nil
+	if _, ok := datastorage.Persistent(client.dataStorage); config.DaemonMode && !ok {
+		_ = client.dataStorage.Close()
+		return nil, daemonModeInvalid
 	}
-	client.evaluator = newEvaluator(client.getFlag, getSegment)
-
 	// data updater
 	dataUpdater := dataupdating.NewDataUpdaterImpl(client.dataStorage)
 	client.dataUpdater = dataUpdater
+	if config.HistorySize > 0 {
+		client.history = dataUpdater.EnableHistory(config.HistorySize)
+	}
 	// data update status provider
 	client.dataUpdateStatusProvider = dataupdating.NewDataUpdateStatusProviderImpl(dataUpdater)
+	// data storage status provider
+	client.dataStorageStatusProvider = dataupdating.NewDataStorageStatusProviderImpl(dataUpdater)
+	// big segments
+	if config.BigSegmentsFactory != nil {
+		client.bigSegments, err = config.BigSegmentsFactory.CreateBigSegments(ctx)
+		if err != nil {
+			// release the data storage and stop monitoring it
+			_ = client.Close()
+			return nil, err
+		}
+	}
+	//evaluator
+	if config.SegmentCacheSize > 0 {
+		segmentCacheTTL := config.SegmentCacheTTL
+		if segmentCacheTTL <= 0 {
+			segmentCacheTTL = defaultSegmentCacheTTL
+		}
+		client.segmentCache = util.NewLRUCache(config.SegmentCacheSize, segmentCacheTTL)
+	}
+	client.getFlag = flagGetter(client.dataView(client.dataStorage))
+	client.evaluator = newEvaluator(client.getFlag, segmentGetter(client.dataStorage)).
+		withBigSegments(client.bigSegments).
+		withSegmentCache(client.segmentCache)
+
+	client.subscribeDataUpdate = dataUpdater.SubscribeDataUpdate
+	client.subscribeChanges = dataUpdater.SubscribeChanges
 
 	// run insight processor
 	insightProcessorFactory := config.InsightProcessorFactory
@@ -181,6 +212,7 @@ func MakeCustomFBClient(envSecret string, streamingUrl string, eventUrl string,
 	}
 	client.insightProcessor, err = insightProcessorFactory.CreateInsightProcessor(ctx)
 	if err != nil {
+		_ = client.Close()
 		return nil, err
 	}
 
@@ -188,15 +220,30 @@ func MakeCustomFBClient(envSecret string, streamingUrl string, eventUrl string,
 		client.insightProcessor.Send(event)
 	}
 
+	// flag overrides
+	flagOverridesFactory := config.FlagOverridesFactory
+	if flagOverridesFactory == nil {
+		flagOverridesFactory = factories.NewFlagOverridesBuilder()
+	}
+	client.flagOverrides, err = flagOverridesFactory.CreateFlagOverrides(ctx)
+	if err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+
 	// run data synchronizer
 	dataSynchronizerFactory := config.DataSynchronizerFactory
 	if client.offline {
 		dataSynchronizerFactory = factories.ExternalDataSynchronization()
+	} else if _, ok := dataSynchronizerFactory.(*factories.DaemonBuilder); config.DaemonMode && !ok {
+		log.LogInfo("FB GO SDK: SDK is in daemon mode")
+		dataSynchronizerFactory = factories.NewDaemonBuilder()
 	} else if dataSynchronizerFactory == nil {
 		dataSynchronizerFactory = factories.NewStreamingBuilder()
 	}
 	client.dataSynchronizer, err = dataSynchronizerFactory.CreateDataSynchronizer(ctx, dataUpdater)
 	if err != nil {
+		_ = client.Close()
 		return nil, err
 	}
 	ready := client.dataSynchronizer.Start()
@@ -245,6 +292,18 @@ func (client *FBClient) IsInitialized() bool {
 	return client.dataSynchronizer.IsInitialized()
 }
 
+// isReadyToEvaluate returns true if the flags could be evaluated: the data synchronizer is ready, or the storage has got
+// the data elsewhere, such as a local snapshot or a storage shared with other instances
+func (client *FBClient) isReadyToEvaluate() bool {
+	return client.IsInitialized() || (client.dataUpdater != nil && client.dataUpdater.StorageInitialized())
+}
+
+// isStale returns true if the data in storage are not yet refreshed by the data synchronizer
+func (client *FBClient) isStale() bool {
+	staleStatusProvider, ok := client.dataUpdateStatusProvider.(StaleStatusProvider)
+	return ok && staleStatusProvider.IsStale()
+}
+
 // Close shuts down the FBClient. After calling this, the FBClient should no longer be used.
 // The method will block until all pending events (if any) been sent.
 func (client *FBClient) Close() error {
@@ -261,6 +320,12 @@ func (client *FBClient) Close() error {
 	if client.insightProcessor != nil {
 		_ = client.insightProcessor.Close()
 	}
+	if client.flagOverrides != nil {
+		_ = client.flagOverrides.Close()
+	}
+	if client.bigSegments != nil {
+		_ = client.bigSegments.Close()
+	}
 	return nil
 }
 
@@ -275,6 +340,89 @@ func (client *FBClient) GetDataUpdateStatusProvider() DataUpdateStatusProvider {
 	return client.dataUpdateStatusProvider
 }
 
+// GetDataStorageStatusProvider returns an interface for tracking the status of a persistent interfaces.DataStorage.
+//
+// The interfaces.DataStorageStatusProvider has methods for checking whether the storage, such as redis or a database,
+// is currently available and tracking changes in this status. Once an unavailable storage is back, the SDK writes
+// the latest data into it again.
+func (client *FBClient) GetDataStorageStatusProvider() DataStorageStatusProvider {
+	return client.dataStorageStatusProvider
+}
+
+// GetBigSegmentStoreStatusProvider returns an interface for tracking the status of the interfaces.BigSegmentStore,
+// nil if FBConfig.BigSegmentsFactory is not set.
+//
+// The interfaces.BigSegmentStoreStatusProvider has methods for checking whether the store is currently available
+// and whether its data are stale, that is to say not updated for longer than the threshold, and tracking changes in this status.
+func (client *FBClient) GetBigSegmentStoreStatusProvider() BigSegmentStoreStatusProvider {
+	if client.bigSegments == nil {
+		return nil
+	}
+	return client.bigSegments
+}
+
+// SubscribeChanges returns a subscription to the changes of the feature flags and segments applied by the data synchronizer,
+// such as to mirror the data into your own caches or audit systems. Each event has the old and the new item of a key.
+//
+// The events are buffered with the given size, interfaces.ChangeSubscription.Overflow signals that a slow consumer has
+// lost some events, it should then reload all the data. The subscription should be cancelled once it's not used.
+//
+//	sub, _ := client.SubscribeChanges(1000)
+//	defer sub.Cancel()
+//	for event := range sub.Events() {
+//		audit(event.Category.GetName(), event.Key, event.Version)
+//	}
+func (client *FBClient) SubscribeChanges(bufferSize int) (ChangeSubscription, error) {
+	if client.subscribeChanges == nil {
+		return nil, emptyClient
+	}
+	return client.subscribeChanges(bufferSize), nil
+}
+
+// FlagHistory returns the versions of a feature flag kept in the local history, the newest first.
+// The number of versions is set by FBConfig.HistorySize.
+func (client *FBClient) FlagHistory(featureFlagKey string) ([]ItemVersion, error) {
+	if client.history == nil {
+		return nil, historyDisabled
+	}
+	return client.history.Get(data.Features, featureFlagKey), nil
+}
+
+// SegmentHistory returns the versions of a segment kept in the local history, the newest first.
+func (client *FBClient) SegmentHistory(segmentKey string) ([]ItemVersion, error) {
+	if client.history == nil {
+		return nil, historyDisabled
+	}
+	return client.history.Get(data.Segments, segmentKey), nil
+}
+
+// PinFlag pins a feature flag to a previous version kept in the local history, as an emergency rollback of a bad change.
+// The version is the one returned by FBClient.FlagHistory.
+//
+// The pinned flag is evaluated in place of the latest one until a newer version is received from the feature flag center,
+// or the pin is removed by FBClient.UnpinFlag. The pin takes effect only in this SDK instance.
+func (client *FBClient) PinFlag(featureFlagKey string, version int64) error {
+	if client.history == nil {
+		return historyDisabled
+	}
+	if !client.history.Pin(featureFlagKey, version) {
+		return flagVersionNotFound
+	}
+	log.LogWarn("FB GO SDK: flag %s is pinned to version %d", featureFlagKey, version)
+	return nil
+}
+
+// UnpinFlag removes the pin of a feature flag set by FBClient.PinFlag, the latest version is evaluated again
+func (client *FBClient) UnpinFlag(featureFlagKey string) error {
+	if client.history == nil {
+		return historyDisabled
+	}
+	if client.history.Unpin(featureFlagKey) {
+		log.LogInfo("FB GO SDK: flag %s is unpinned", featureFlagKey)
+	}
+	return nil
+}
+
 // IsFlagKnown returns true if feature flag is registered in the feature flag center,
 // false if any error or flag is not existed
 func (client *FBClient) IsFlagKnown(featureFlagKey string) bool {
@@ -284,6 +432,43 @@ func (client *FBClient) IsFlagKnown(featureFlagKey string) bool {
 	return false
 }
 
+// SetOverride forces the value of a feature flag locally, without touching the FeatBit environment.
+// The value could be a string, bool, number or any object serializable in json.
+// If the predicates are given, the override is only applied to the users matching all of them.
+//
+// An overridden evaluation returns the override value with the reason "override", and doesn't send insight event.
+func (client *FBClient) SetOverride(featureFlagKey string, value interface{}, predicates ...UserPredicate) error {
+	if client.flagOverrides == nil {
+		return emptyClient
+	}
+	v, err := util.ValueToString(value)
+	if err != nil {
+		return err
+	}
+	var predicate UserPredicate
+	if len(predicates) > 0 {
+		predicate = func(user FBUser) bool {
+			for _, p := range predicates {
+				if p != nil && !p(user) {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	client.flagOverrides.Set(featureFlagKey, v, predicate)
+	return nil
+}
+
+// RemoveOverride removes the override of a feature flag set by FBClient.SetOverride
+func (client *FBClient) RemoveOverride(featureFlagKey string) error {
+	if client.flagOverrides == nil {
+		return emptyClient
+	}
+	client.flagOverrides.Remove(featureFlagKey)
+	return nil
+}
+
 // Identify register a FBUser
 func (client *FBClient) Identify(user FBUser) error {
 	if client.insightProcessor == nil {
@@ -374,9 +559,31 @@ func (client *FBClient) Flush() error {
 	return nil
 }
 
+// evaluateOverride returns the override value of a flag for a given user, false if the flag is not overridden.
+// The request scoped overrides have priority over the client overrides
+func (client *FBClient) evaluateOverride(featureFlagKey string, user *FBUser, requestOverrides map[string]string) (*evalResult, bool) {
+	if !user.IsValid() {
+		return nil, false
+	}
+	value, ok := requestOverrides[featureFlagKey]
+	if !ok && client.flagOverrides != nil {
+		value, ok = client.flagOverrides.Get(featureFlagKey, *user)
+	}
+	if !ok {
+		return nil, false
+	}
+	return overrideResult(featureFlagKey, value, client.getFlag(featureFlagKey)), true
+}
+
 // evaluateInternal internal use for evaluate flag value
-func (client *FBClient) evaluateInternal(featureFlagKey string, user *FBUser, requiredType string) (*evalResult, error) {
-	if !client.IsInitialized() {
+func (client *FBClient) evaluateInternal(featureFlagKey string, user *FBUser, requiredType string, requestOverrides map[string]string) (*evalResult, error) {
+	if er, ok := client.evaluateOverride(featureFlagKey, user, requestOverrides); ok {
+		if !er.checkType(requiredType) {
+			return errorResult(ReasonWrongType, featureFlagKey, er.name), evalWrongType
+		}
+		return er, nil
+	}
+	if !client.isReadyToEvaluate() {
 		log.LogWarn("FB GO SDK: evaluation is called before GO SDK client is initialized for feature flag, well using the default value")
 		return errorResult(ReasonClientNotReady, featureFlagKey, FlagNameUnknown), clientNotInitialized
 	}
@@ -393,6 +600,7 @@ func (client *FBClient) evaluateInternal(featureFlagKey string, user *FBUser, re
 	eventUser := insight.ConvertFBUserToEventUser(user)
 	event := insight.NewFlagEvent(eventUser)
 	er := client.evaluator.evaluate(flag, user, event)
+	er.stale = er.stale || client.isStale()
 	if !er.checkType(requiredType) {
 		return errorResult(ReasonWrongType, featureFlagKey, er.name), evalWrongType
 	}
@@ -404,8 +612,8 @@ func (client *FBClient) evaluateInternal(featureFlagKey string, user *FBUser, re
 	return errorResult(ReasonError, featureFlagKey, flag.Name), evalFailed
 }
 
-func (client *FBClient) evaluateDetail(featureFlagKey string, user *FBUser, requiredType string, defaultValue interface{}) (EvalDetail, error) {
-	er, err := client.evaluateInternal(featureFlagKey, user, requiredType)
+func (client *FBClient) evaluateDetail(featureFlagKey string, user *FBUser, requiredType string, defaultValue interface{}, requestOverrides map[string]string) (EvalDetail, error) {
+	er, err := client.evaluateInternal(featureFlagKey, user, requiredType, requestOverrides)
 	if err != nil {
 		return EvalDetail{Variation: defaultValue, Reason: er.reason, KeyName: er.keyName, Name: er.name}, err
 	}
@@ -418,7 +626,7 @@ func (client *FBClient) evaluateDetail(featureFlagKey string, user *FBUser, requ
 //
 // The method sends insight events back to feature flag center
 func (client *FBClient) Variation(featureFlagKey string, user FBUser, defaultValue string) (string, EvalDetail, error) {
-	ed, err := client.evaluateDetail(featureFlagKey, &user, FlagStringType, defaultValue)
+	ed, err := client.evaluateDetail(featureFlagKey, &user, FlagStringType, defaultValue, nil)
 	if err != nil {
 		return defaultValue, ed, err
 	}
@@ -432,7 +640,7 @@ func (client *FBClient) Variation(featureFlagKey string, user FBUser, defaultVal
 //
 // The method sends insight events back to feature flag center
 func (client *FBClient) BoolVariation(featureFlagKey string, user FBUser, defaultValue bool) (bool, EvalDetail, error) {
-	ed, err := client.evaluateDetail(featureFlagKey, &user, FlagBoolType, defaultValue)
+	ed, err := client.evaluateDetail(featureFlagKey, &user, FlagBoolType, defaultValue, nil)
 	if err != nil {
 		return defaultValue, ed, err
 	}
@@ -446,7 +654,7 @@ func (client *FBClient) BoolVariation(featureFlagKey string, user FBUser, defaul
 //
 // The method sends insight events back to feature flag center
 func (client *FBClient) IntVariation(featureFlagKey string, user FBUser, defaultValue int) (int, EvalDetail, error) {
-	ed, err := client.evaluateDetail(featureFlagKey, &user, FlagNumericType, defaultValue)
+	ed, err := client.evaluateDetail(featureFlagKey, &user, FlagNumericType, defaultValue, nil)
 	if err != nil {
 		return defaultValue, ed, err
 	}
@@ -460,7 +668,7 @@ func (client *FBClient) IntVariation(featureFlagKey string, user FBUser, default
 //
 // The method sends insight events back to feature flag center
 func (client *FBClient) DoubleVariation(featureFlagKey string, user FBUser, defaultValue float64) (float64, EvalDetail, error) {
-	ed, err := client.evaluateDetail(featureFlagKey, &user, FlagNumericType, defaultValue)
+	ed, err := client.evaluateDetail(featureFlagKey, &user, FlagNumericType, defaultValue, nil)
 	if err != nil {
 		return defaultValue, ed, err
 	}
@@ -474,7 +682,7 @@ func (client *FBClient) DoubleVariation(featureFlagKey string, user FBUser, defa
 //
 // The method sends insight events back to feature flag center
 func (client *FBClient) JsonVariation(featureFlagKey string, user FBUser, defaultValue interface{}) (interface{}, EvalDetail, error) {
-	ed, err := client.evaluateDetail(featureFlagKey, &user, FlagJsonType, defaultValue)
+	ed, err := client.evaluateDetail(featureFlagKey, &user, FlagJsonType, defaultValue, nil)
 	if err != nil {
 		return defaultValue, ed, err
 	}
@@ -489,7 +697,11 @@ func (client *FBClient) JsonVariation(featureFlagKey string, user FBUser, defaul
 //
 // This method does not send insight events back to feature flag center. See interfaces.AllFlagState
 func (client *FBClient) AllLatestFlagsVariations(user FBUser) (AllFlagState, error) {
-	if !client.IsInitialized() {
+	return client.allLatestFlagsVariations(user, nil)
+}
+
+func (client *FBClient) allLatestFlagsVariations(user FBUser, requestOverrides map[string]string) (AllFlagState, error) {
+	if !client.isReadyToEvaluate() {
 		log.LogWarn("FB GO SDK: evaluation is called before GO SDK client is initialized for feature flag, well using the default value")
 		return &allFlagStateImpl{reason: ReasonClientNotReady}, clientNotInitialized
 	}
@@ -497,7 +709,15 @@ func (client *FBClient) AllLatestFlagsVariations(user FBUser) (AllFlagState, err
 		log.LogWarn("FB GO SDK: invalid user")
 		return &allFlagStateImpl{reason: ReasonUserNotSpecified}, userInvalid
 	}
-	items, err := client.dataStorage.GetAll(data.Features)
+	// all the flags are evaluated against the same version of data if the storage provides a consistent view
+	view, eval := client.dataView(client.dataStorage), client.evaluator
+	if storage, ok := client.dataStorage.(ViewableDataStorage); ok {
+		view = client.dataView(storage.View())
+		eval = newEvaluator(flagGetter(view), segmentGetter(view)).
+			withBigSegments(client.bigSegments).
+			withSegmentCache(client.segmentCache)
+	}
+	items, err := view.GetAll(data.Features)
 	if err != nil {
 		return &allFlagStateImpl{reason: ReasonError}, err
 	}
@@ -507,12 +727,20 @@ func (client *FBClient) AllLatestFlagsVariations(user FBUser) (AllFlagState, err
 	}
 
 	ret := &allFlagStateImpl{}
+	stale := client.isStale()
+	// the segments are matched once for all the flags
+	segments := eval.segmentResultsOf(&user)
 	var once sync.Once
 	for key, item := range items {
 		if flag, ok := item.(*data.FeatureFlag); ok {
-			eventUser := insight.ConvertFBUserToEventUser(&user)
-			event := insight.NewFlagEvent(eventUser)
-			er := client.evaluator.evaluate(flag, &user, event)
+			var event *insight.FlagEvent
+			er, overridden := client.evaluateOverride(key, &user, requestOverrides)
+			if !overridden {
+				eventUser := insight.ConvertFBUserToEventUser(&user)
+				event = insight.NewFlagEvent(eventUser)
+				er = eval.evaluateWith(flag, &user, event, segments)
+				er.stale = er.stale || stale
+			}
 			if er.success {
 				once.Do(func() {
 					ret.success = true
@@ -532,6 +760,36 @@ func (client *FBClient) AllLatestFlagsVariations(user FBUser) (AllFlagState, err
 	return ret, nil
 }
 
+// dataView returns the view in which the pinned flags replace the latest ones
+func (client *FBClient) dataView(view DataStorageView) DataStorageView {
+	if client.history == nil {
+		return view
+	}
+	return client.history.View(view)
+}
+
+func flagGetter(view DataStorageView) func(key string) *data.FeatureFlag {
+	return func(key string) *data.FeatureFlag {
+		if item, e := view.Get(data.Features, key); e == nil {
+			if flag, ok := item.(*data.FeatureFlag); ok {
+				return flag
+			}
+		}
+		return nil
+	}
+}
+
+func segmentGetter(view DataStorageView) func(key string) *data.Segment {
+	return func(key string) *data.Segment {
+		if item, e := view.Get(data.Segments, key); e == nil {
+			if segment, ok := item.(*data.Segment); ok {
+				return segment
+			}
+		}
+		return nil
+	}
+}
+
 // InitializeFromExternalJson initializes FeatBit client in the offline mode
 //
 // Return false if the json can't be parsed or client is not in the offline mode
@@ -551,3 +809,45 @@ func (client *FBClient) InitializeFromExternalJson(jsonStr string) (bool, error)
 	}
 	return false, nil
 }
+
+// ExportSnapshot writes the feature flags and segments held by the client to w, in the format of the full data-sync message
+// sent by the feature flag center, such as to debug an SDK instance or to reproduce its evaluations in a test.
+// The archived items are included if the data storage is in memory.
+//
+// The snapshot could be loaded by FBClient.ImportSnapshot or FBClient.InitializeFromExternalJson
+func (client *FBClient) ExportSnapshot(w io.Writer) error {
+	if client.dataStorage == nil {
+		return emptyClient
+	}
+	allData, _, err := datastorage.AllData(client.dataStorage)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(data.NewAll(data.FromStorageType(allData)))
+}
+
+// ImportSnapshot loads a snapshot written by FBClient.ExportSnapshot, or any full data-sync message, into the data storage.
+//
+// In the offline mode, the client is ready to evaluate the flags once the snapshot is loaded. Otherwise, the snapshot is
+// ignored if the storage has already got newer data, and it's replaced by the data synchronizer once newer data are received.
+func (client *FBClient) ImportSnapshot(r io.Reader) error {
+	if client.dataUpdater == nil {
+		return emptyClient
+	}
+	var all data.All
+	if err := json.NewDecoder(r).Decode(&all); err != nil {
+		return err
+	}
+	if !all.IsSyncMessage() || all.Data.EventType != data.FullOp {
+		return snapshotInvalid
+	}
+	d := all.Data
+	if !client.dataUpdater.Init(d.ToStorageType(), d.GetTimestamp()) {
+		return snapshotImportFailed
+	}
+	if client.offline {
+		client.dataUpdater.UpdateStatus(OKState())
+	}
+	log.LogInfo("FB GO SDK: snapshot is imported, version = %d", d.GetTimestamp())
+	return nil
+}
@@ -1,17 +1,24 @@
 package featbit
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"github.com/featbit/featbit-go-sdk/factories"
 	"github.com/featbit/featbit-go-sdk/fixtures"
 	"github.com/featbit/featbit-go-sdk/interfaces"
 	"github.com/featbit/featbit-go-sdk/internal/datastorage"
 	"github.com/featbit/featbit-go-sdk/internal/datasynchronization"
 	insight2 "github.com/featbit/featbit-go-sdk/internal/insight"
+	"github.com/featbit/featbit-go-sdk/internal/types/data"
 	"github.com/featbit/featbit-go-sdk/internal/types/insight"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -31,6 +38,38 @@ var testUser5, _ = interfaces.NewUserBuilder("18555358000").UserName("test-user-
 var testUser6, _ = interfaces.NewUserBuilder("0603111111").UserName("test-user-6").Build()
 var testUser7, _ = interfaces.NewUserBuilder("test-user-7@featbit.com").UserName("test-user-7").Build()
 
+// closeTrackingStorage is an in-memory storage created by itself, which records whether it's closed
+type closeTrackingStorage struct {
+	*datastorage.InMemoryDataStorage
+	closed bool
+}
+
+func (s *closeTrackingStorage) CreateDataStorage(_ interfaces.Context) (interfaces.DataStorage, error) {
+	return s, nil
+}
+
+func (s *closeTrackingStorage) Close() error {
+	s.closed = true
+	return nil
+}
+
+type failingBigSegmentsFactory struct{}
+
+func (f failingBigSegmentsFactory) CreateBigSegments(_ interfaces.Context) (interfaces.BigSegments, error) {
+	return nil, fmt.Errorf("big segment store unreachable")
+}
+
+// failingFactory fails to create the flag overrides and the data synchronizer
+type failingFactory struct{}
+
+func (f failingFactory) CreateFlagOverrides(_ interfaces.Context) (interfaces.FlagOverrides, error) {
+	return nil, fmt.Errorf("invalid flag overrides")
+}
+
+func (f failingFactory) CreateDataSynchronizer(_ interfaces.Context, _ interfaces.DataUpdater) (interfaces.DataSynchronizer, error) {
+	return nil, fmt.Errorf("invalid data synchronizer")
+}
+
 func TestFBClientBootStrap(t *testing.T) {
 	t.Run("empty env secret", func(t *testing.T) {
 		_, err := NewFBClient("", "ws://fake-url", "http://fake-url")
@@ -138,6 +177,109 @@ func TestFBClientBootStrap(t *testing.T) {
 		}
 		_ = client.Close()
 	})
+	t.Run("daemon mode without persistent storage", func(t *testing.T) {
+		config := FBConfig{
+			DaemonMode:              true,
+			StartWait:               200 * time.Millisecond,
+			InsightProcessorFactory: factories.ExternalEventTrack(),
+		}
+		_, err := MakeCustomFBClient(fakeEnvSecret, "ws://fake-url", "http://fake-url", config)
+		assert.Equal(t, err, daemonModeInvalid)
+	})
+	t.Run("daemon mode with another data synchronizer", func(t *testing.T) {
+		config := FBConfig{
+			DaemonMode:              true,
+			StartWait:               200 * time.Millisecond,
+			DataStorageFactory:      factories.NewSQLStorageBuilder(nil),
+			DataSynchronizerFactory: factories.NewPollingBuilder(),
+			InsightProcessorFactory: factories.ExternalEventTrack(),
+		}
+		_, err := MakeCustomFBClient(fakeEnvSecret, "ws://fake-url", "http://fake-url", config)
+		assert.Equal(t, err, daemonModeConflict)
+	})
+	t.Run("daemon mode with snapshot over in-memory storage", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "fb-snapshot")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		config := FBConfig{
+			DaemonMode:              true,
+			StartWait:               200 * time.Millisecond,
+			DataStorageFactory:      factories.NewSnapshotStorageBuilder(filepath.Join(dir, "snapshot.json")),
+			InsightProcessorFactory: factories.ExternalEventTrack(),
+		}
+		_, err = MakeCustomFBClient(fakeEnvSecret, "ws://fake-url", "http://fake-url", config)
+		assert.Equal(t, err, daemonModeInvalid)
+	})
+	t.Run("big segments failure releases the data storage", func(t *testing.T) {
+		storage := &closeTrackingStorage{InMemoryDataStorage: datastorage.NewInMemoryDataStorage()}
+		config := FBConfig{
+			StartWait:               200 * time.Millisecond,
+			DataStorageFactory:      storage,
+			BigSegmentsFactory:      failingBigSegmentsFactory{},
+			InsightProcessorFactory: factories.ExternalEventTrack(),
+		}
+		client, err := MakeCustomFBClient(fakeEnvSecret, "ws://fake-url", "http://fake-url", config)
+		assert.Nil(t, client)
+		assert.Error(t, err)
+		assert.True(t, storage.closed)
+	})
+	t.Run("flag overrides failure releases the data storage", func(t *testing.T) {
+		storage := &closeTrackingStorage{InMemoryDataStorage: datastorage.NewInMemoryDataStorage()}
+		config := FBConfig{
+			StartWait:               200 * time.Millisecond,
+			DataStorageFactory:      storage,
+			FlagOverridesFactory:    failingFactory{},
+			InsightProcessorFactory: factories.ExternalEventTrack(),
+		}
+		client, err := MakeCustomFBClient(fakeEnvSecret, "ws://fake-url", "http://fake-url", config)
+		assert.Nil(t, client)
+		assert.Error(t, err)
+		assert.True(t, storage.closed)
+	})
+	t.Run("data synchronizer failure releases the data storage", func(t *testing.T) {
+		storage := &closeTrackingStorage{InMemoryDataStorage: datastorage.NewInMemoryDataStorage()}
+		config := FBConfig{
+			StartWait:               200 * time.Millisecond,
+			DataStorageFactory:      storage,
+			DataSynchronizerFactory: failingFactory{},
+			InsightProcessorFactory: factories.ExternalEventTrack(),
+		}
+		client, err := MakeCustomFBClient(fakeEnvSecret, "ws://fake-url", "http://fake-url", config)
+		assert.Nil(t, client)
+		assert.Error(t, err)
+		assert.True(t, storage.closed)
+	})
+	t.Run("warm start from snapshot", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "fb-snapshot")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		snapshot := filepath.Join(dir, "snapshot.json")
+		config := FBConfig{
+			StartWait:               200 * time.Millisecond,
+			DataStorageFactory:      factories.NewSnapshotStorageBuilder(snapshot),
+			DataSynchronizerFactory: datasynchronization.NewMockStreamingBuilder(true, true, 10*time.Millisecond),
+			InsightProcessorFactory: factories.ExternalEventTrack(),
+		}
+		client, err := MakeCustomFBClient(fakeEnvSecret, "ws://fake-url", "http://fake-url", config)
+		require.NoError(t, err)
+		_ = client.Close()
+
+		config.StartWait = 50 * time.Millisecond
+		config.DataSynchronizerFactory = datasynchronization.NewMockStreamingBuilder(true, true, 200*time.Millisecond)
+		client, err = MakeCustomFBClient(fakeEnvSecret, "ws://fake-url", "http://fake-url", config)
+		assert.Equal(t, err, initializationTimeout)
+		defer client.Close()
+		assert.False(t, client.IsInitialized())
+		assert.True(t, client.isStale())
+		res, detail, err := client.Variation("ff-test-string", testUser1, "error")
+		require.NoError(t, err)
+		assert.Equal(t, "others", res)
+		assert.True(t, detail.Stale)
+		require.True(t, client.GetDataUpdateStatusProvider().WaitForOKState(time.Second))
+		assert.False(t, client.isStale())
+		_, detail, _ = client.Variation("ff-test-string", testUser1, "error")
+		assert.False(t, detail.Stale)
+	})
 }
 
 func TestFBEvaluation(t *testing.T) {
@@ -269,6 +411,20 @@ func TestFBEvaluation(t *testing.T) {
 		assert.Equal(t, "error", res6)
 		assert.Equal(t, ReasonFlagNotFound, detail6.Reason)
 	})
+	t.Run("all latest flag values from a consistent view", func(t *testing.T) {
+		config := FBConfig{Offline: true, StartWait: 1 * time.Millisecond, DataStorageFactory: factories.NewCopyOnWriteStorageBuilder()}
+		cowClient, _ := MakeCustomFBClient(fakeEnvSecret, "ws://fake-url", "http://fake-url", config)
+		defer cowClient.Close()
+		_, err := cowClient.InitializeFromExternalJson(string(jsonBytes))
+		require.NoError(t, err)
+		allState, err := cowClient.AllLatestFlagsVariations(testUser1)
+		require.NoError(t, err)
+		res, detail, _ := allState.GetStringVariation("ff-test-seg", "error")
+		assert.Equal(t, "teamA", res)
+		assert.Equal(t, ReasonRuleMatch, detail.Reason)
+		res1, _, _ := cowClient.Variation("ff-test-seg", testUser1, "error")
+		assert.Equal(t, res, res1)
+	})
 	t.Run("argument error", func(t *testing.T) {
 		res, detail, _ := client.Variation("ff-not-existed", testUser1, "error")
 		assert.Equal(t, "error", res)
@@ -405,3 +561,153 @@ func TestFBTrackEvent(t *testing.T) {
 		_ = client.Close()
 	})
 }
+
+func TestFBOverrides(t *testing.T) {
+	config := FBConfig{
+		Offline:              true,
+		StartWait:            1 * time.Millisecond,
+		FlagOverridesFactory: factories.NewFlagOverridesBuilder().Values(map[string]interface{}{"ff-test-bool": false, "ff-local-only": 3}),
+	}
+	client, _ := MakeCustomFBClient(fakeEnvSecret, "ws://fake-url", "http://fake-url", config)
+	jsonBytes, _ := fixtures.LoadFBClientTestData()
+	_, _ = client.InitializeFromExternalJson(string(jsonBytes))
+	t.Run("overrides from config", func(t *testing.T) {
+		res, detail, err := client.BoolVariation("ff-test-bool", testUser1, true)
+		require.NoError(t, err)
+		assert.False(t, res)
+		assert.Equal(t, ReasonOverride, detail.Reason)
+		assert.Equal(t, "ff-test-bool", detail.Name)
+		res1, detail1, err1 := client.IntVariation("ff-local-only", testUser1, -1)
+		require.NoError(t, err1)
+		assert.Equal(t, 3, res1)
+		assert.Equal(t, ReasonOverride, detail1.Reason)
+		assert.Equal(t, FlagNameUnknown, detail1.Name)
+	})
+	t.Run("programmatic overrides", func(t *testing.T) {
+		require.NoError(t, client.SetOverride("ff-test-string", "overridden", func(user interfaces.FBUser) bool {
+			return user.Get("country") == "us"
+		}))
+		res, detail, _ := client.Variation("ff-test-string", testUser1, "error")
+		assert.Equal(t, "overridden", res)
+		assert.Equal(t, ReasonOverride, detail.Reason)
+		res, detail, _ = client.Variation("ff-test-string", testUser2, "error")
+		assert.Equal(t, "others", res)
+		assert.Equal(t, ReasonFallthrough, detail.Reason)
+		allState, _ := client.AllLatestFlagsVariations(testUser1)
+		res, detail, _ = allState.GetStringVariation("ff-test-string", "error")
+		assert.Equal(t, "overridden", res)
+		assert.Equal(t, ReasonOverride, detail.Reason)
+		require.NoError(t, client.RemoveOverride("ff-test-string"))
+		res, _, _ = client.Variation("ff-test-string", testUser1, "error")
+		assert.Equal(t, "others", res)
+	})
+	t.Run("wrong type", func(t *testing.T) {
+		_ = client.SetOverride("ff-test-number", "not a number")
+		res, detail, err := client.IntVariation("ff-test-number", testUser1, -1)
+		assert.Equal(t, evalWrongType, err)
+		assert.Equal(t, -1, res)
+		assert.Equal(t, ReasonWrongType, detail.Reason)
+	})
+	_ = client.Close()
+}
+
+func TestFBFlagHistory(t *testing.T) {
+	config := FBConfig{Offline: true, StartWait: 1 * time.Millisecond, HistorySize: 10}
+	client, _ := MakeCustomFBClient(fakeEnvSecret, "ws://fake-url", "http://fake-url", config)
+	defer client.Close()
+	jsonBytes, _ := fixtures.LoadFBClientTestData()
+	_, _ = client.InitializeFromExternalJson(string(jsonBytes))
+	// a bad change that disables the flag
+	original := client.getFlag("ff-test-bool")
+	js, _ := json.Marshal(original)
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(js, &fields))
+	fields["isEnabled"] = false
+	fields["updatedAt"] = time.Now().Format(time.RFC3339Nano)
+	js, _ = json.Marshal(fields)
+	var disabled data.FeatureFlag
+	require.NoError(t, json.Unmarshal(js, &disabled))
+	require.True(t, client.dataUpdater.Upsert(data.Features, disabled.GetId(), &disabled, disabled.GetTimestamp()))
+
+	versions, err := client.FlagHistory("ff-test-bool")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(versions))
+	assert.Equal(t, disabled.GetTimestamp(), versions[0].Version)
+	assert.Equal(t, original.GetTimestamp(), versions[1].Version)
+	_, detail, _ := client.BoolVariation("ff-test-bool", testUser1, false)
+	assert.Equal(t, ReasonFlagOff, detail.Reason)
+
+	assert.Equal(t, flagVersionNotFound, client.PinFlag("ff-test-bool", 1))
+	require.NoError(t, client.PinFlag("ff-test-bool", versions[1].Version))
+	res, detail, _ := client.BoolVariation("ff-test-bool", testUser1, false)
+	assert.True(t, res)
+	assert.Equal(t, ReasonTargetMatch, detail.Reason)
+	allState, _ := client.AllLatestFlagsVariations(testUser1)
+	res, _, _ = allState.GetBoolVariation("ff-test-bool", false)
+	assert.True(t, res)
+
+	require.NoError(t, client.UnpinFlag("ff-test-bool"))
+	_, detail, _ = client.BoolVariation("ff-test-bool", testUser1, false)
+	assert.Equal(t, ReasonFlagOff, detail.Reason)
+
+	config.HistorySize = 0
+	noHistoryClient, _ := MakeCustomFBClient(fakeEnvSecret, "ws://fake-url", "http://fake-url", config)
+	defer noHistoryClient.Close()
+	_, err = noHistoryClient.FlagHistory("ff-test-bool")
+	assert.Equal(t, historyDisabled, err)
+	assert.Equal(t, historyDisabled, noHistoryClient.PinFlag("ff-test-bool", 0))
+}
+
+func TestFBSnapshot(t *testing.T) {
+	config := FBConfig{Offline: true, StartWait: 1 * time.Millisecond}
+	client, _ := MakeCustomFBClient(fakeEnvSecret, "ws://fake-url", "http://fake-url", config)
+	defer client.Close()
+	jsonBytes, _ := fixtures.LoadFBClientTestData()
+	_, _ = client.InitializeFromExternalJson(string(jsonBytes))
+	archivedAt := client.dataUpdater.GetVersion() + 1
+	require.True(t, client.dataUpdater.Upsert(data.Features, "ff-test-string", data.NewArchivedItem("ff-test-string", archivedAt), archivedAt))
+
+	var buf bytes.Buffer
+	require.NoError(t, client.ExportSnapshot(&buf))
+	snapshot := buf.String()
+	buf.Reset()
+	require.NoError(t, client.ExportSnapshot(&buf))
+	assert.Equal(t, snapshot, buf.String(), "the snapshot is deterministic")
+
+	t.Run("import in offline mode", func(t *testing.T) {
+		imported, _ := MakeCustomFBClient(fakeEnvSecret, "ws://fake-url", "http://fake-url", config)
+		defer imported.Close()
+		require.NoError(t, imported.ImportSnapshot(strings.NewReader(snapshot)))
+		assert.True(t, imported.IsInitialized())
+		assert.Equal(t, archivedAt, imported.dataStorage.GetVersion())
+		expected, _, _ := datastorage.AllData(client.dataStorage)
+		actual, _, _ := datastorage.AllData(imported.dataStorage)
+		assert.Equal(t, expected, actual)
+		assert.False(t, imported.IsFlagKnown("ff-test-string"))
+		res, _, err := imported.BoolVariation("ff-test-bool", testUser1, false)
+		require.NoError(t, err)
+		assert.True(t, res)
+	})
+
+	t.Run("import in online mode", func(t *testing.T) {
+		onlineConfig := FBConfig{
+			DataSynchronizerFactory: datasynchronization.NewMockStreamingBuilder(true, false, time.Hour),
+			InsightProcessorFactory: factories.ExternalEventTrack(),
+		}
+		imported, _ := MakeCustomFBClient(fakeEnvSecret, "ws://fake-url", "http://fake-url", onlineConfig)
+		defer imported.Close()
+		require.NoError(t, imported.ImportSnapshot(strings.NewReader(snapshot)))
+		assert.Equal(t, archivedAt, imported.dataStorage.GetVersion())
+		res, _, err := imported.BoolVariation("ff-test-bool", testUser1, false)
+		require.NoError(t, err)
+		assert.True(t, res)
+	})
+
+	t.Run("invalid snapshot", func(t *testing.T) {
+		imported, _ := MakeCustomFBClient(fakeEnvSecret, "ws://fake-url", "http://fake-url", config)
+		defer imported.Close()
+		assert.Error(t, imported.ImportSnapshot(strings.NewReader("not json")))
+		assert.Equal(t, snapshotInvalid, imported.ImportSnapshot(strings.NewReader(`{"messageType":"ping","data":{}}`)))
+		assert.False(t, imported.dataUpdater.StorageInitialized())
+	})
+}
@@ -20,6 +20,12 @@ const (
 type FBConfig struct {
 	// Offline whether SDK is offline
 	Offline bool
+	// DaemonMode whether SDK evaluates the flags purely from a persistent storage populated by another process, such as a relay,
+	// no connection is opened to feature flag center for the data synchronization.
+	//
+	// DataStorageFactory must create a persistent storage, such as redis.StorageBuilder or factories.SQLStorageBuilder.
+	// DataSynchronizerFactory should be nil or a factories.DaemonBuilder, the client is not created with another one.
+	DaemonMode bool
 	// StartWait how long the constructor will block awaiting a successful data sync
 	//
 	// Setting this to a zero or negative duration will not block and cause the constructor to return immediately.
@@ -40,10 +46,36 @@ type FBConfig struct {
 	//
 	// Depending on the implementation, the factory may be a builder that allows you to set other configuration options as well.
 	InsightProcessorFactory InsightProcessorFactory
+	// FlagOverridesFactory a factory object which sets the implementation of interfaces.FlagOverrides, a layer consulted before
+	// the evaluation to force the flag values locally, without touching the FeatBit environment.
+	//
+	// Depending on the implementation, the factory may be a builder that allows you to set other configuration options as well.
+	FlagOverridesFactory FlagOverridesFactory
+	// HistorySize the number of versions of each feature flag and segment kept in the local history, see FBClient.FlagHistory
+	// and FBClient.PinFlag.
+	//
+	// The history is disabled by default, if the size is zero or negative.
+	HistorySize int
+	// BigSegmentsFactory a factory object which sets the implementation of interfaces.BigSegments, which gives the membership
+	// of the users in the big segments kept in an external store, such as factories.BigSegmentsBuilder.
+	//
+	// The big segments are not supported if it's nil, the evaluations depending on them have the reason "big segments unavailable".
+	BigSegmentsFactory BigSegmentsFactory
+	// SegmentCacheSize the number of users whose results of the segments are kept between the evaluations, which saves
+	// matching the same segments again for the users evaluated repeatedly, such as by FBClient.AllLatestFlagsVariations.
+	// A result is discarded once the segment is updated.
+	//
+	// The results are only kept during an evaluation if it's zero.
+	SegmentCacheSize int
+	// SegmentCacheTTL how long the results of the segments of a user are kept, the default TTL is 5 seconds if it's zero
+	SegmentCacheTTL time.Duration
 	// LogLevel FeaBit log level
 	LogLevel int
 }
 
+// the default TTL of the results of the segments of a user, see FBConfig.SegmentCacheTTL
+const defaultSegmentCacheTTL = 5 * time.Second
+
 // DefaultFBConfig FeatBit default configuration
 var DefaultFBConfig *FBConfig = &FBConfig{
 	Offline:                 false,
@@ -3,7 +3,11 @@ module github.com/featbit/featbit-go-sdk
 go 1.13
 
 require (
+	github.com/alicebob/miniredis/v2 v2.30.0
+	github.com/glebarez/go-sqlite v1.21.2
+	github.com/gomodule/redigo v1.8.9
 	github.com/google/uuid v1.3.0
 	github.com/gorilla/websocket v1.5.0
 	github.com/stretchr/testify v1.8.1
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,73 @@
+package interfaces
+
+import (
+	"io"
+	"time"
+)
+
+// BigSegmentMembership is the membership of a user in the big segments: the ids of the segments including the user
+// are mapped to true, the ids of the segments excluding the user are mapped to false.
+// The user is matched by the rules of the segments not in the membership.
+type BigSegmentMembership map[string]bool
+
+// BigSegmentStoreMetadata is the information about the data of a BigSegmentStore
+type BigSegmentStoreMetadata struct {
+	// LastUpdated is the last time the memberships were synchronized into the store
+	LastUpdated time.Time
+}
+
+// BigSegmentStore is an external store of the membership of the big segments, the cohorts of millions of users
+// that are too large to be sent by the data synchronization.
+//
+// The store is populated by another process, the SDK only reads the membership of the evaluated users.
+// Note that all implementations should permit concurrent access.
+type BigSegmentStore interface {
+	io.Closer
+
+	// GetMembership returns the membership of a user, an empty membership if the user is not in any big segment
+	GetMembership(userKey string) (BigSegmentMembership, error)
+
+	// GetMetadata returns the information about the data of the store
+	GetMetadata() (BigSegmentStoreMetadata, error)
+}
+
+// BigSegmentStoreFactory Interface for a factory that creates some implementation of BigSegmentStore
+type BigSegmentStoreFactory interface {
+	// CreateBigSegmentStore creates an implementation of BigSegmentStore
+	CreateBigSegmentStore(context Context) (BigSegmentStore, error)
+}
+
+// BigSegmentStoreStatus is information about the availability and the freshness of a BigSegmentStore
+type BigSegmentStoreStatus struct {
+	// Available is true if the store could be reached at the last check or query
+	Available bool
+	// Stale is true if the store has not been updated for longer than the staleness threshold
+	Stale bool
+}
+
+// BigSegmentStoreStatusProvider interface to query the status of the BigSegmentStore
+type BigSegmentStoreStatusProvider interface {
+	// GetStatus returns the current status of the store
+	GetStatus() BigSegmentStoreStatus
+
+	// AddStatusListener returns a channel that receives the status each time it changes,
+	// and a function to remove the listener. A listener that doesn't consume the channel may miss some changes.
+	AddStatusListener() (<-chan BigSegmentStoreStatus, func())
+}
+
+// BigSegments gives the membership of the users in the big segments to the evaluations, the memberships are read from
+// a BigSegmentStore and cached by user.
+type BigSegments interface {
+	io.Closer
+	BigSegmentStoreStatusProvider
+
+	// GetMembership returns the membership of a user and the status of the store,
+	// the membership is nil if the store is unavailable
+	GetMembership(userKey string) (BigSegmentMembership, BigSegmentStoreStatus)
+}
+
+// BigSegmentsFactory Interface for a factory that creates some implementation of BigSegments
+type BigSegmentsFactory interface {
+	// CreateBigSegments creates an implementation of BigSegments
+	CreateBigSegments(context Context) (BigSegments, error)
+}
@@ -10,6 +10,12 @@ type BasicConfig interface {
 	GetEventUri() string
 }
 
+// PollingConfig is implemented by the Context that gives the url to poll the data over HTTP
+type PollingConfig interface {
+	// GetPollingUri returns the url to poll the data over HTTP
+	GetPollingUri() string
+}
+
 // Context is used to create components, context information provided by the FeatBit GO SDK
 // This is passed as parameter to component factories. Component factories do not receive the entire FeatBit config
 // because it contains only factory implementations.
@@ -1,6 +1,9 @@
 package interfaces
 
-import "io"
+import (
+	"io"
+	"time"
+)
 
 // Category represents a separated namespace of storable data items.
 // The SDK passes instances of this type to the data store to specify whether it is referring to
@@ -34,9 +37,10 @@ type DataStorage interface {
 	// Init Overwrites the storage with a set of items for each collection, if the new version > the old one
 	Init(allData map[Category]map[string]Item, version int64) error
 
-	// Upsert updates or inserts an item in the specified collection. For updates, the object will only be
-	// updated if the existing version is less than the new version; for inserts, if the version > the existing one, it will replace
-	// the existing one.
+	// Upsert updates or inserts an item in the specified collection. The items are versioned by their timestamps:
+	// for updates, the object will only be updated if the existing item is older than the new one; for inserts, the item is
+	// always inserted, even if the version is older than the one of storage, such as a delayed patch of another item.
+	// The version of storage is the latest version applied.
 	// The SDK may pass an Item that contains an archived object, in that case, assuming the version is greater than any existing version of that item,
 	// the store should retain a placeholder rather than simply not storing anything.
 	Upsert(category Category, key string, item Item, version int64) (bool, error)
@@ -56,6 +60,128 @@ type DataStorage interface {
 	GetVersion() int64
 }
 
+// BatchDataStorage is implemented by the DataStorage that applies several items atomically,
+// the readers never observe a part of the items, such as a flag updated without the segment it references.
+type BatchDataStorage interface {
+	// UpsertBatch updates or inserts the items of the specified collections in a single operation, if the version > the existing one.
+	// Each item is only updated if the existing one is older, it returns true if any item is updated.
+	UpsertBatch(items map[Category]map[string]Item, version int64) (bool, error)
+}
+
+// StaleDataStorage is implemented by the DataStorage that could serve the stale data, such as the data loaded from
+// a local snapshot at startup, the data are stale until the DataSynchronizer delivers fresh data.
+type StaleDataStorage interface {
+	// IsStale returns true if the data are not yet refreshed by the DataSynchronizer
+	IsStale() bool
+
+	// MarkFresh informs the storage that the data are refreshed by the DataSynchronizer
+	MarkFresh()
+}
+
+// PersistentDataStorage is implemented by the DataStorage that persists the data outside the SDK, such as redis or
+// a database, the data could be shared by the SDK instances.
+type PersistentDataStorage interface {
+	// CheckAvailability returns an error if the storage can't be reached
+	CheckAvailability() error
+}
+
+// DataStorageView is a read-only and consistent view of the data in a DataStorage at a point in time,
+// the later updates of the storage are not visible in the view.
+type DataStorageView interface {
+	// Get retrieves an item from the specified collection, it returns nil if the item is not found or archived
+	Get(category Category, key string) (Item, error)
+
+	// GetAll retrieves all items but the archived ones from the specified collection
+	GetAll(category Category) (map[string]Item, error)
+
+	// GetVersion returns the version of the data in the view
+	GetVersion() int64
+}
+
+// ViewableDataStorage is implemented by the DataStorage that provides consistent views of its data, the SDK uses
+// a view to evaluate all the flags against the same version of the flags and segments.
+type ViewableDataStorage interface {
+	// View returns a view of the current data
+	View() DataStorageView
+}
+
+// ChangeEvent is a change of an item in the DataStorage, as seen by the evaluations
+type ChangeEvent struct {
+	// Category is the collection of the item
+	Category Category
+	// Key is the key of the item
+	Key string
+	// OldItem is the item before the change, nil if the item didn't exist or was archived
+	OldItem Item
+	// NewItem is the item after the change, nil if the item is archived or removed
+	NewItem Item
+	// Archived is true if the item is archived or removed by the change
+	Archived bool
+	// Version is the version of the update that makes the change
+	Version int64
+}
+
+// ChangeSubscription is a subscription to the changes of the DataStorage.
+//
+// The events are buffered, a consumer that doesn't keep up loses the events rather than blocking the DataSynchronizer:
+// the dropped events are signalled by Overflow, then the consumer should reload all the items to get back in sync.
+type ChangeSubscription interface {
+	// Events returns the channel of the changes, it's closed once the subscription is cancelled
+	Events() <-chan ChangeEvent
+
+	// Overflow returns a channel that receives a signal when some events are dropped because the buffer is full,
+	// the signals are coalesced
+	Overflow() <-chan struct{}
+
+	// Dropped returns the number of the events dropped since the subscription
+	Dropped() uint64
+
+	// Cancel cancels the subscription
+	Cancel()
+}
+
+// ItemVersion is a version of an item kept in the local history
+type ItemVersion struct {
+	// Version is the timestamp of the item
+	Version int64
+	// ReceivedAt is the time when the version was applied to the DataStorage
+	ReceivedAt time.Time
+	// Item is the item of this version
+	Item Item
+	// Pinned is true if the item is pinned locally in place of the latest version
+	Pinned bool
+}
+
+// DataStorageStatus is information about the availability of a PersistentDataStorage
+type DataStorageStatus struct {
+	// Available is true if the storage could be reached at the last check or operation
+	Available bool
+	// NeedsRefresh is true if the storage may have missed some updates while it was unavailable,
+	// it's reset once the SDK has written the latest data into the storage again
+	NeedsRefresh bool
+	// Since is the time that the value of Available most recently changed
+	Since time.Time
+	// LastError is the message of the last error of the storage, empty if no error has ever occurred
+	LastError string
+}
+
+// DataStorageStatusProvider interface to query the status of a PersistentDataStorage
+//
+// The SDK periodically checks the availability of a persistent storage, such as redis or a database, and
+// the write errors also mark the storage as unavailable. Once the storage is back, the SDK writes the latest data
+// received by the DataSynchronizer into the storage again, so that the updates missed during the outage are not lost.
+type DataStorageStatusProvider interface {
+	// GetStatus returns the current status of the storage, a storage that is not persistent is always available
+	GetStatus() DataStorageStatus
+
+	// IsStatusMonitoringEnabled returns true if the storage is persistent and its availability is monitored
+	IsStatusMonitoringEnabled() bool
+
+	// AddStatusListener returns a channel that receives the status each time it changes,
+	// and a function to remove the listener. A listener that doesn't consume the channel may miss some changes.
+	AddStatusListener() (<-chan DataStorageStatus, func())
+}
+
 // DataStorageFactory Interface for a factory that creates some implementation of DataStorage
 type DataStorageFactory interface {
 	// CreateDataStorage create an implementation of DataStorage
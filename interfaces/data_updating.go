@@ -9,6 +9,7 @@ import (
 const (
 	DataStorageInitError   = "Data Storage init error"
 	DataStorageUpdateError = "Data Storage update error"
+	DataStorageUnavailable = "Data Storage unavailable"
 	RequestInvalidError    = "Request invalid"
 	DataInvalidError       = "Received Data invalid"
 	WebsocketError         = "WebSocket error"
@@ -18,6 +19,14 @@ const (
 	UnknownCloseCode       = "Unknown close code"
 )
 
+// the built-in sources of data, reported by ActiveSourceProvider.GetActiveSource
+const (
+	StreamingSource = "streaming"
+	PollingSource   = "polling"
+	FileSource      = "file"
+	StorageSource   = "storage"
+)
+
 type StateType string
 
 const (
@@ -166,3 +175,40 @@ type DataUpdateStatusProvider interface {
 	// WaitForOKState alias of WaitFor in OK state
 	WaitForOKState(timeout time.Duration) bool
 }
+
+// BatchDataUpdater is implemented by the DataUpdater that updates the items of a patch in a single operation.
+type BatchDataUpdater interface {
+	// UpsertBatch updates or inserts the items of a patch atomically if the DataStorage implements BatchDataStorage,
+	// otherwise the items are upserted one by one. The version is the latest timestamp of the items.
+	// It returns true if any item is updated, the errors of the underlying data storage are handled as Upsert does.
+	UpsertBatch(items map[Category]map[string]Item, version int64) bool
+}
+
+// ResyncDataUpdater is implemented by the DataUpdater that detects the inconsistencies in the data.
+type ResyncDataUpdater interface {
+	// ResyncRequested returns true if an inconsistency is detected in the data, such as the reordered patches
+	// or a storage that has lost the latest updates. The DataSynchronizer should request the full data again,
+	// the request is reset once the full data are pushed by Init.
+	ResyncRequested() bool
+}
+
+// ActiveSourceUpdater is implemented by the DataUpdater that tracks the source of the data.
+type ActiveSourceUpdater interface {
+	// UpdateActiveSource informs the SDK of the source that currently feeds the data, such as StreamingSource or PollingSource.
+	// The DataSynchronizer implementations made of several sources should use this method when they switch from one to another.
+	UpdateActiveSource(source string)
+}
+
+// ActiveSourceProvider is implemented by the DataUpdateStatusProvider that reports the source of the data.
+type ActiveSourceProvider interface {
+	// GetActiveSource returns the source that currently feeds the data, such as StreamingSource or PollingSource,
+	// or an empty string if the DataSynchronizer doesn't report it.
+	GetActiveSource() string
+}
+
+// StaleStatusProvider is implemented by the DataUpdateStatusProvider that reports whether the data are stale.
+type StaleStatusProvider interface {
+	// IsStale returns true if the flags are evaluated from the stale data, such as the data loaded from a local snapshot,
+	// before the DataSynchronizer delivers fresh data.
+	IsStale() bool
+}
@@ -15,6 +15,9 @@ type EvalDetail struct {
 	KeyName string `json:"keyName"`
 	// GetName returns the name of the latest evaluated feature flag
 	Name string `json:"name"`
+	// Stale is true if the flag is evaluated from the stale data, such as the data loaded from a local snapshot,
+	// before the data synchronizer delivers fresh data
+	Stale bool `json:"stale,omitempty"`
 }
 
 // AllFlagState provides a standard return responding the request of getting all flag values from SDK
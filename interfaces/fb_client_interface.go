@@ -1,6 +1,7 @@
 package interfaces
 
 import (
+	"context"
 	"io"
 )
 
@@ -81,4 +82,13 @@ type FBClientBehaviors interface {
 
 	// InitializeFromExternalJson initialize FeatBit client in the offline mode
 	InitializeFromExternalJson(jsonStr string) (bool, error)
+
+	// SetOverride forces the value of a feature flag locally, for the users matching all the predicates if any
+	SetOverride(featureFlagKey string, value interface{}, predicates ...UserPredicate) error
+
+	// RemoveOverride removes the local override of a feature flag
+	RemoveOverride(featureFlagKey string) error
+
+	// WithContext returns a FBEvaluation whose evaluations honour the request scoped information carried by the context
+	WithContext(ctx context.Context) FBEvaluation
 }
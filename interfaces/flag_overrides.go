@@ -0,0 +1,33 @@
+package interfaces
+
+import "io"
+
+// UserPredicate is a function to check if an override should be applied to a given user
+type UserPredicate func(user FBUser) bool
+
+// FlagOverrides Interface for a layer that forces the values of feature flags locally, without touching the FeatBit environment.
+// It's consulted before the evaluation of feature flags, an overridden flag returns the override value and no insight event
+// is sent to feature flag center.
+//
+// This is normally used for the local development or QA.
+// Note that all implementations should permit concurrent access and updates.
+type FlagOverrides interface {
+	io.Closer
+
+	// Get returns the overridden value of a feature flag for a given user and true,
+	// or false if the feature flag is not overridden for this user.
+	Get(flagKey string, user FBUser) (string, bool)
+
+	// Set overrides the value of a feature flag, replacing the previous programmatic override of this flag if any.
+	// If a predicate is given, the override is only applied to the users matching the predicate.
+	Set(flagKey string, value string, predicate UserPredicate)
+
+	// Remove removes the programmatic override of a feature flag, the overrides from other sources are not removed.
+	Remove(flagKey string)
+}
+
+// FlagOverridesFactory Interface for a factory that creates some implementation of FlagOverrides
+type FlagOverridesFactory interface {
+	// CreateFlagOverrides creates an implementation of FlagOverrides
+	CreateFlagOverrides(Context) (FlagOverrides, error)
+}
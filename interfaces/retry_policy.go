@@ -0,0 +1,39 @@
+package interfaces
+
+import (
+	"net/http"
+	"time"
+)
+
+// ConnectionFailure describes a failed attempt of the data synchronizer to connect to the feature flag center
+type ConnectionFailure struct {
+	// Err is the error of the attempt
+	Err error
+	// StatusCode is the http status code of the response, 0 if there is no response
+	StatusCode int
+}
+
+// IsAuthFailure returns true if the feature flag center rejected the env secret
+func (f ConnectionFailure) IsAuthFailure() bool {
+	return f.StatusCode == http.StatusUnauthorized || f.StatusCode == http.StatusForbidden
+}
+
+// RetryPolicy decides when the data synchronizer tries to connect again after a failure, and whether it gives up.
+//
+// A RetryPolicy holds the state of one data synchronizer, such as the number of consecutive failures,
+// it's called by a single go routine.
+type RetryPolicy interface {
+	// OnSuccess is called once the data synchronizer is connected, the policy may reset its state
+	OnSuccess()
+
+	// OnFailure is called after a failed connection, returns the delay before the next attempt,
+	// or false if the data synchronizer should stop retrying
+	OnFailure(failure ConnectionFailure) (time.Duration, bool)
+}
+
+// RetryPolicyFactory Interface for a factory that creates some implementation of RetryPolicy, each data synchronizer
+// has its own RetryPolicy
+type RetryPolicyFactory interface {
+	// CreateRetryPolicy creates an implementation of RetryPolicy
+	CreateRetryPolicy(context Context) (RetryPolicy, error)
+}
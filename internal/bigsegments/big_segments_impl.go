@@ -0,0 +1,162 @@
+package bigsegments
+
+import (
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/util"
+	"github.com/featbit/featbit-go-sdk/internal/util/log"
+	"sync"
+	"time"
+)
+
+const defaultListenerNums = 10
+
+// BigSegmentsImpl reads the membership of the users from a BigSegmentStore, the memberships are cached by user for a TTL.
+//
+// The metadata of the store are polled periodically to track its availability and its freshness: the store is stale
+// if it has not been updated for longer than the threshold. While the store is unavailable, the users not in the cache
+// are not queried until the store is back.
+type BigSegmentsImpl struct {
+	store        BigSegmentStore
+	cache        *util.LRUCache
+	staleAfter   time.Duration
+	pollInterval time.Duration
+	lock         sync.Mutex
+	status       BigSegmentStoreStatus
+	listeners    []chan BigSegmentStoreStatus
+	closeOnce    sync.Once
+	closeCh      chan struct{}
+}
+
+// NewBigSegmentsImpl creates a BigSegmentsImpl, the status of store is checked before it returns
+func NewBigSegmentsImpl(store BigSegmentStore, userCacheSize int, userCacheTTL time.Duration, staleAfter time.Duration, pollInterval time.Duration) *BigSegmentsImpl {
+	b := &BigSegmentsImpl{
+		store:        store,
+		cache:        util.NewLRUCache(userCacheSize, userCacheTTL),
+		staleAfter:   staleAfter,
+		pollInterval: pollInterval,
+		status:       BigSegmentStoreStatus{Available: true},
+		closeCh:      make(chan struct{}),
+	}
+	b.poll()
+	go b.pollRoutine()
+	return b
+}
+
+func (b *BigSegmentsImpl) pollRoutine() {
+	ticker := time.NewTicker(b.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			b.poll()
+		case <-b.closeCh:
+			return
+		}
+	}
+}
+
+// poll checks the availability and the freshness of the store
+func (b *BigSegmentsImpl) poll() {
+	metadata, err := b.store.GetMetadata()
+	if err != nil {
+		b.setUnavailable(err)
+		return
+	}
+	stale := b.staleAfter > 0 && (metadata.LastUpdated.IsZero() || time.Since(metadata.LastUpdated) > b.staleAfter)
+	b.setStatus(BigSegmentStoreStatus{Available: true, Stale: stale})
+}
+
+func (b *BigSegmentsImpl) setUnavailable(err error) {
+	b.lock.Lock()
+	available := b.status.Available
+	stale := b.status.Stale
+	b.lock.Unlock()
+	if available {
+		log.LogWarn("FB GO SDK: big segment store is unavailable: %v", err)
+	}
+	b.setStatus(BigSegmentStoreStatus{Available: false, Stale: stale})
+}
+
+func (b *BigSegmentsImpl) setStatus(status BigSegmentStoreStatus) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.status == status {
+		return
+	}
+	if status.Available && !b.status.Available {
+		log.LogInfo("FB GO SDK: big segment store is available")
+	}
+	if status.Stale && !b.status.Stale {
+		log.LogWarn("FB GO SDK: big segment store has not been updated for %v", b.staleAfter)
+	}
+	b.status = status
+	for _, ch := range b.listeners {
+		select {
+		case ch <- status:
+		default:
+		}
+	}
+}
+
+func (b *BigSegmentsImpl) GetMembership(userKey string) (BigSegmentMembership, BigSegmentStoreStatus) {
+	status := b.GetStatus()
+	if membership, ok := b.cache.Get(userKey); ok {
+		return membership.(BigSegmentMembership), status
+	}
+	if !status.Available {
+		return nil, status
+	}
+	membership, err := b.store.GetMembership(userKey)
+	if err != nil {
+		b.setUnavailable(err)
+		return nil, b.GetStatus()
+	}
+	if membership == nil {
+		membership = BigSegmentMembership{}
+	}
+	b.cache.Add(userKey, membership)
+	return membership, status
+}
+
+func (b *BigSegmentsImpl) GetStatus() BigSegmentStoreStatus {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.status
+}
+
+func (b *BigSegmentsImpl) AddStatusListener() (<-chan BigSegmentStoreStatus, func()) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	listener := make(chan BigSegmentStoreStatus, defaultListenerNums)
+	b.listeners = append(b.listeners, listener)
+	var once sync.Once
+	remove := func() {
+		once.Do(func() {
+			b.lock.Lock()
+			defer b.lock.Unlock()
+			for i, ch := range b.listeners {
+				if ch == listener {
+					b.listeners = append(b.listeners[:i], b.listeners[i+1:]...)
+					close(ch)
+					break
+				}
+			}
+		})
+	}
+	return listener, remove
+}
+
+// Close stops the polling and closes the store
+func (b *BigSegmentsImpl) Close() (err error) {
+	b.closeOnce.Do(func() {
+		close(b.closeCh)
+		b.lock.Lock()
+		for _, ch := range b.listeners {
+			close(ch)
+		}
+		b.listeners = nil
+		b.lock.Unlock()
+		err = b.store.Close()
+	})
+	return
+}
@@ -0,0 +1,149 @@
+package bigsegments
+
+import (
+	"fmt"
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type mockBigSegmentStore struct {
+	lock        sync.Mutex
+	err         error
+	lastUpdated time.Time
+	memberships map[string]BigSegmentMembership
+	queries     int
+	closed      bool
+}
+
+func (m *mockBigSegmentStore) setError(err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.err = err
+}
+
+func (m *mockBigSegmentStore) getQueries() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.queries
+}
+
+func (m *mockBigSegmentStore) Close() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.closed = true
+	return nil
+}
+
+func (m *mockBigSegmentStore) GetMembership(userKey string) (BigSegmentMembership, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.queries++
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.memberships[userKey], nil
+}
+
+func (m *mockBigSegmentStore) GetMetadata() (BigSegmentStoreMetadata, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.err != nil {
+		return BigSegmentStoreMetadata{}, m.err
+	}
+	return BigSegmentStoreMetadata{LastUpdated: m.lastUpdated}, nil
+}
+
+func TestBigSegmentsImpl(t *testing.T) {
+	t.Run("membership is cached", func(t *testing.T) {
+		store := &mockBigSegmentStore{
+			lastUpdated: time.Now(),
+			memberships: map[string]BigSegmentMembership{"u1": {"seg1": true, "seg2": false}},
+		}
+		b := NewBigSegmentsImpl(store, 10, time.Minute, time.Minute, time.Hour)
+		defer b.Close()
+		membership, status := b.GetMembership("u1")
+		assert.Equal(t, BigSegmentMembership{"seg1": true, "seg2": false}, membership)
+		assert.Equal(t, BigSegmentStoreStatus{Available: true}, status)
+		membership, _ = b.GetMembership("u1")
+		assert.Equal(t, BigSegmentMembership{"seg1": true, "seg2": false}, membership)
+		assert.Equal(t, 1, store.getQueries())
+		membership, _ = b.GetMembership("u2")
+		assert.Equal(t, BigSegmentMembership{}, membership)
+		assert.Equal(t, 2, store.getQueries())
+	})
+	t.Run("unavailable store", func(t *testing.T) {
+		store := &mockBigSegmentStore{lastUpdated: time.Now()}
+		b := NewBigSegmentsImpl(store, 10, time.Minute, time.Minute, 10*time.Millisecond)
+		defer b.Close()
+		statusCh, remove := b.AddStatusListener()
+		defer remove()
+		store.setError(fmt.Errorf("connection refused"))
+		membership, status := b.GetMembership("u1")
+		assert.Nil(t, membership)
+		assert.False(t, status.Available)
+		assert.Equal(t, BigSegmentStoreStatus{Available: false}, <-statusCh)
+		// the store is not queried until it's back
+		_, _ = b.GetMembership("u1")
+		assert.Equal(t, 1, store.getQueries())
+		store.setError(nil)
+		select {
+		case status = <-statusCh:
+			assert.Equal(t, BigSegmentStoreStatus{Available: true}, status)
+		case <-time.After(time.Second):
+			assert.Fail(t, "the store should be back")
+		}
+		membership, _ = b.GetMembership("u1")
+		assert.NotNil(t, membership)
+	})
+	t.Run("stale store", func(t *testing.T) {
+		store := &mockBigSegmentStore{lastUpdated: time.Now().Add(-time.Hour)}
+		b := NewBigSegmentsImpl(store, 10, time.Minute, time.Minute, time.Hour)
+		_, status := b.GetMembership("u1")
+		assert.Equal(t, BigSegmentStoreStatus{Available: true, Stale: true}, status)
+		assert.Equal(t, status, b.GetStatus())
+		assert.NoError(t, b.Close())
+		assert.NoError(t, b.Close())
+		assert.True(t, store.closed)
+	})
+}
+
+func TestFileBigSegmentStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "big-segments")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "big_segments.json")
+	store := NewFileBigSegmentStore(path)
+	defer store.Close()
+
+	_, err = store.GetMembership("u1")
+	assert.Error(t, err)
+
+	content := `{"lastUpdated":"2023-01-19T07:49:19Z","users":{"u1":{"included":["seg1","seg2"],"excluded":["seg2"]}}}`
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	membership, err := store.GetMembership("u1")
+	require.NoError(t, err)
+	assert.Equal(t, BigSegmentMembership{"seg1": true, "seg2": false}, membership)
+	membership, err = store.GetMembership("u2")
+	require.NoError(t, err)
+	assert.Empty(t, membership)
+	metadata, err := store.GetMetadata()
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2023, 1, 19, 7, 49, 19, 0, time.UTC), metadata.LastUpdated.UTC())
+
+	// the file is reloaded once it's changed
+	content = `{"users":{"u2":{"included":["seg3"]}}}`
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	metadata, err = store.GetMetadata()
+	require.NoError(t, err)
+	assert.False(t, metadata.LastUpdated.IsZero())
+	membership, err = store.GetMembership("u2")
+	require.NoError(t, err)
+	assert.Equal(t, BigSegmentMembership{"seg3": true}, membership)
+}
@@ -0,0 +1,113 @@
+package bigsegments
+
+import (
+	"encoding/json"
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/util/log"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+type fileUserMembership struct {
+	Included []string `json:"included"`
+	Excluded []string `json:"excluded"`
+}
+
+// fileContent is the format of the file:
+//
+//	{"lastUpdated": "2023-01-19T07:49:19Z", "users": {"user-key": {"included": ["segment-id"], "excluded": []}}}
+type fileContent struct {
+	LastUpdated time.Time                     `json:"lastUpdated"`
+	Users       map[string]fileUserMembership `json:"users"`
+}
+
+// FileBigSegmentStore is a BigSegmentStore reading the memberships from a json file, for the tests and the local development.
+//
+// The file is reloaded when its modification time or size changes, which is checked by GetMetadata.
+// If the file doesn't set the last update time, the modification time of the file is used.
+type FileBigSegmentStore struct {
+	path        string
+	lock        sync.RWMutex
+	modTime     time.Time
+	size        int64
+	loaded      bool
+	lastUpdated time.Time
+	users       map[string]BigSegmentMembership
+}
+
+// NewFileBigSegmentStore creates a FileBigSegmentStore, the file is loaded at the first query
+func NewFileBigSegmentStore(path string) *FileBigSegmentStore {
+	return &FileBigSegmentStore{path: path}
+}
+
+// reload reads the file if it has changed since the last loading
+func (f *FileBigSegmentStore) reload() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+	f.lock.RLock()
+	unchanged := f.loaded && info.ModTime().Equal(f.modTime) && info.Size() == f.size
+	f.lock.RUnlock()
+	if unchanged {
+		return nil
+	}
+	bytes, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	var content fileContent
+	if err = json.Unmarshal(bytes, &content); err != nil {
+		return err
+	}
+	users := make(map[string]BigSegmentMembership, len(content.Users))
+	for userKey, m := range content.Users {
+		membership := make(BigSegmentMembership, len(m.Included)+len(m.Excluded))
+		for _, id := range m.Included {
+			membership[id] = true
+		}
+		for _, id := range m.Excluded {
+			membership[id] = false
+		}
+		users[userKey] = membership
+	}
+	lastUpdated := content.LastUpdated
+	if lastUpdated.IsZero() {
+		lastUpdated = info.ModTime()
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.modTime, f.size, f.loaded = info.ModTime(), info.Size(), true
+	f.lastUpdated, f.users = lastUpdated, users
+	log.LogDebug("big segments are loaded from %s, %d users", f.path, len(users))
+	return nil
+}
+
+func (f *FileBigSegmentStore) Close() error {
+	return nil
+}
+
+func (f *FileBigSegmentStore) GetMembership(userKey string) (BigSegmentMembership, error) {
+	f.lock.RLock()
+	loaded := f.loaded
+	f.lock.RUnlock()
+	if !loaded {
+		if err := f.reload(); err != nil {
+			return nil, err
+		}
+	}
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return f.users[userKey], nil
+}
+
+func (f *FileBigSegmentStore) GetMetadata() (BigSegmentStoreMetadata, error) {
+	if err := f.reload(); err != nil {
+		return BigSegmentStoreMetadata{}, err
+	}
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return BigSegmentStoreMetadata{LastUpdated: f.lastUpdated}, nil
+}
@@ -8,6 +8,7 @@ import (
 const (
 	streamingPath = "/streaming"
 	eventPath     = "/api/public/insight/track"
+	pollingPath   = "/api/public/sdk/server/latest-all"
 )
 
 type SDKContext struct {
@@ -40,6 +41,11 @@ func (c *SDKContext) GetEventUri() string {
 	return strings.Join([]string{url, eventPath}, "")
 }
 
+func (c *SDKContext) GetPollingUri() string {
+	url := strings.TrimRight(c.eventUrl, "/")
+	return strings.Join([]string{url, pollingPath}, "")
+}
+
 func (c *SDKContext) GetNetwork() Network {
 	return c.network
 }
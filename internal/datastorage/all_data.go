@@ -0,0 +1,55 @@
+package datastorage
+
+import (
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/types/data"
+)
+
+// allDataStorage is implemented by the storages that return all the items at once, including the archived ones
+type allDataStorage interface {
+	AllData() (map[Category]map[string]Item, int64)
+}
+
+// decorator is implemented by the storages that wrap another one
+type decorator interface {
+	unwrap() DataStorage
+}
+
+// innermost returns the storage wrapped by all the decorators
+func innermost(storage DataStorage) DataStorage {
+	for {
+		d, ok := storage.(decorator)
+		if !ok {
+			return storage
+		}
+		storage = d.unwrap()
+	}
+}
+
+// Persistent returns the PersistentDataStorage wrapped by the decorators of a storage, such as CachedDataStorage
+// or SnapshotDataStorage, false if the data are only kept in memory
+func Persistent(storage DataStorage) (PersistentDataStorage, bool) {
+	persistent, ok := innermost(storage).(PersistentDataStorage)
+	return persistent, ok
+}
+
+// AllData returns the feature flags and segments of a storage and its version.
+//
+// The archived items are included if the storage keeps them in memory, a persistent storage returns only the active items.
+func AllData(storage DataStorage) (map[Category]map[string]Item, int64, error) {
+	storage = innermost(storage)
+	if s, ok := storage.(allDataStorage); ok {
+		allData, version := s.AllData()
+		return allData, version, nil
+	}
+	version := storage.GetVersion()
+	allData := make(map[Category]map[string]Item, 2)
+	for _, cat := range []Category{data.Features, data.Segments} {
+		items, err := storage.GetAll(cat)
+		if err != nil {
+			return nil, 0, err
+		}
+		allData[cat] = items
+	}
+	return allData, version, nil
+}
@@ -0,0 +1,40 @@
+package datastorage
+
+import (
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"sort"
+)
+
+type batchItem struct {
+	category Category
+	key      string
+	item     Item
+}
+
+// UpsertBatch applies the items atomically if the storage implements BatchDataStorage, otherwise the items are
+// upserted one by one in the order of their timestamps, each timestamp being the version of its upsert.
+func UpsertBatch(storage DataStorage, items map[Category]map[string]Item, version int64) (bool, error) {
+	if batchStorage, ok := storage.(BatchDataStorage); ok {
+		return batchStorage.UpsertBatch(items, version)
+	}
+	var batch []batchItem
+	for cat, catItems := range items {
+		for key, item := range catItems {
+			if item != nil {
+				batch = append(batch, batchItem{category: cat, key: key, item: item})
+			}
+		}
+	}
+	sort.Slice(batch, func(i, j int) bool {
+		return batch[i].item.GetTimestamp() < batch[j].item.GetTimestamp()
+	})
+	var updated bool
+	for _, b := range batch {
+		ok, err := storage.Upsert(b.category, b.key, b.item, b.item.GetTimestamp())
+		if err != nil {
+			return updated, err
+		}
+		updated = updated || ok
+	}
+	return updated, nil
+}
@@ -0,0 +1,90 @@
+package datastorage
+
+import (
+	"database/sql"
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/types/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// nonBatchDataStorage hides the UpsertBatch of the underlying storage
+type nonBatchDataStorage struct {
+	DataStorage
+}
+
+func testUpsertBatch(t *testing.T, storage DataStorage) {
+	flagValue := func(key string) string {
+		item, err := storage.Get(data.Features, key)
+		require.NoError(t, err)
+		require.NotNil(t, item)
+		return item.(*data.FeatureFlag).GetFlagValue("v1")
+	}
+	require.NoError(t, storage.Init(map[Category]map[string]Item{
+		data.Features: {"ff1": newFlag(t, "ff1", "a", 10, false)},
+		data.Segments: {"seg1": newSegment(t, "seg1", 10)},
+	}, 10))
+
+	ok, err := UpsertBatch(storage, map[Category]map[string]Item{
+		data.Features: {"ff1": newFlag(t, "ff1", "b", 20, false)},
+		data.Segments: {"seg2": newSegment(t, "seg2", 19)},
+	}, 20)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(20), storage.GetVersion())
+	assert.Equal(t, "b", flagValue("ff1"))
+	item, _ := storage.Get(data.Segments, "seg2")
+	assert.NotNil(t, item)
+
+	// the older items are ignored, a delayed item is applied
+	ok, _ = UpsertBatch(storage, map[Category]map[string]Item{data.Features: {"ff3": newFlag(t, "ff3", "e", 12, false)}}, 12)
+	assert.True(t, ok)
+	assert.Equal(t, int64(20), storage.GetVersion())
+	assert.Equal(t, "e", flagValue("ff3"))
+	ok, _ = UpsertBatch(storage, map[Category]map[string]Item{
+		data.Features: {"ff1": newFlag(t, "ff1", "c", 15, false), "ff2": newFlag(t, "ff2", "c", 30, false)},
+	}, 30)
+	assert.True(t, ok)
+	assert.Equal(t, "b", flagValue("ff1"))
+	assert.Equal(t, "c", flagValue("ff2"))
+	ok, _ = UpsertBatch(storage, map[Category]map[string]Item{data.Features: {"ff1": newFlag(t, "ff1", "d", 15, false)}}, 40)
+	assert.False(t, ok)
+	assert.Equal(t, int64(30), storage.GetVersion())
+
+	// archived
+	ok, _ = UpsertBatch(storage, map[Category]map[string]Item{data.Features: {"ff2": newFlag(t, "ff2", "c", 50, true).ToArchivedItem()}}, 50)
+	assert.True(t, ok)
+	item, _ = storage.Get(data.Features, "ff2")
+	assert.Nil(t, item)
+	all, _ := storage.GetAll(data.Features)
+	assert.Equal(t, 2, len(all))
+}
+
+func TestUpsertBatch(t *testing.T) {
+	t.Run("in memory", func(t *testing.T) {
+		testUpsertBatch(t, NewInMemoryDataStorage())
+	})
+	t.Run("copy on write", func(t *testing.T) {
+		testUpsertBatch(t, NewCopyOnWriteDataStorage())
+	})
+	t.Run("one by one", func(t *testing.T) {
+		testUpsertBatch(t, nonBatchDataStorage{NewInMemoryDataStorage()})
+	})
+	t.Run("cached", func(t *testing.T) {
+		testUpsertBatch(t, NewCachedDataStorage(NewInMemoryDataStorage(), time.Hour))
+	})
+	t.Run("sql", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "fb-sql")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		db, err := sql.Open("sqlite", filepath.Join(dir, "featbit.db"))
+		require.NoError(t, err)
+		defer db.Close()
+		testUpsertBatch(t, newTestSQLStorage(t, db, "batch_"))
+	})
+}
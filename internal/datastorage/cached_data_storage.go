@@ -0,0 +1,145 @@
+package datastorage
+
+import (
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"sync"
+	"time"
+)
+
+type cachedItem struct {
+	item      Item
+	expiredAt time.Time
+}
+
+type cachedItems struct {
+	items     map[string]Item
+	expiredAt time.Time
+}
+
+// CachedDataStorage is a read-through cache in front of a persistent DataStorage, the items read from the storage
+// are kept in memory for a TTL, so that the evaluations don't hit the storage each time.
+//
+// The cache is invalidated by the updates of this SDK instance, the updates of the other instances sharing
+// the same storage are visible once the TTL has elapsed.
+type CachedDataStorage struct {
+	storage DataStorage
+	ttl     time.Duration
+	lock    sync.RWMutex
+	items   map[Category]map[string]cachedItem
+	all     map[Category]cachedItems
+	// increased by each update, a value read before an update is not cached
+	generation uint64
+}
+
+func NewCachedDataStorage(storage DataStorage, ttl time.Duration) *CachedDataStorage {
+	return &CachedDataStorage{
+		storage: storage,
+		ttl:     ttl,
+		items:   make(map[Category]map[string]cachedItem),
+		all:     make(map[Category]cachedItems),
+	}
+}
+
+func (c *CachedDataStorage) Close() error {
+	return c.storage.Close()
+}
+
+func (c *CachedDataStorage) invalidate() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.generation++
+	c.items = make(map[Category]map[string]cachedItem)
+	c.all = make(map[Category]cachedItems)
+}
+
+func (c *CachedDataStorage) invalidateItem(category Category, key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.generation++
+	if items, ok := c.items[category]; ok {
+		delete(items, key)
+	}
+	delete(c.all, category)
+}
+
+func (c *CachedDataStorage) Init(allData map[Category]map[string]Item, version int64) error {
+	defer c.invalidate()
+	return c.storage.Init(allData, version)
+}
+
+func (c *CachedDataStorage) Upsert(category Category, key string, item Item, version int64) (bool, error) {
+	// the item could be updated by another instance even if it's rejected
+	defer c.invalidateItem(category, key)
+	return c.storage.Upsert(category, key, item, version)
+}
+
+func (c *CachedDataStorage) UpsertBatch(items map[Category]map[string]Item, version int64) (bool, error) {
+	defer c.invalidate()
+	return UpsertBatch(c.storage, items, version)
+}
+
+func (c *CachedDataStorage) Get(category Category, key string) (Item, error) {
+	now := time.Now()
+	c.lock.RLock()
+	cached, ok := c.items[category][key]
+	generation := c.generation
+	c.lock.RUnlock()
+	if ok && now.Before(cached.expiredAt) {
+		return cached.item, nil
+	}
+	item, err := c.storage.Get(category, key)
+	if err != nil {
+		return nil, err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if generation != c.generation {
+		return item, nil
+	}
+	items, ok := c.items[category]
+	if !ok {
+		items = make(map[string]cachedItem)
+		c.items[category] = items
+	}
+	// the missing items are also cached
+	items[key] = cachedItem{item: item, expiredAt: now.Add(c.ttl)}
+	return item, nil
+}
+
+func (c *CachedDataStorage) GetAll(category Category) (map[string]Item, error) {
+	now := time.Now()
+	c.lock.RLock()
+	cached, ok := c.all[category]
+	generation := c.generation
+	c.lock.RUnlock()
+	if !ok || !now.Before(cached.expiredAt) {
+		items, err := c.storage.GetAll(category)
+		if err != nil {
+			return nil, err
+		}
+		cached = cachedItems{items: items, expiredAt: now.Add(c.ttl)}
+		c.lock.Lock()
+		if generation == c.generation {
+			c.all[category] = cached
+		}
+		c.lock.Unlock()
+	}
+	// the caller could modify the result
+	res := make(map[string]Item, len(cached.items))
+	for k, v := range cached.items {
+		res[k] = v
+	}
+	return res, nil
+}
+
+func (c *CachedDataStorage) IsInitialized() bool {
+	return c.storage.IsInitialized()
+}
+
+func (c *CachedDataStorage) GetVersion() int64 {
+	return c.storage.GetVersion()
+}
+
+func (c *CachedDataStorage) unwrap() DataStorage {
+	return c.storage
+}
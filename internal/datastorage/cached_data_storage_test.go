@@ -0,0 +1,109 @@
+package datastorage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/types/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newFlag(t *testing.T, key string, value string, updatedAt int64, archived bool) *data.FeatureFlag {
+	jsonStr := fmt.Sprintf(`{"id":"%[1]s","key":"%[1]s","name":"%[1]s","isArchived":%[4]t,"isEnabled":false,"disabledVariationId":"v1","variations":[{"id":"v1","value":"%[2]s"}],"updatedAt":"%[3]s"}`,
+		key, value, time.Unix(0, updatedAt*int64(time.Millisecond)).UTC().Format(time.RFC3339Nano), archived)
+	var flag data.FeatureFlag
+	require.NoError(t, json.Unmarshal([]byte(jsonStr), &flag))
+	return &flag
+}
+
+func newSegment(t *testing.T, id string, updatedAt int64) *data.Segment {
+	jsonStr := fmt.Sprintf(`{"id":"%s","included":["u1","u2"],"excluded":["u3"],"rules":[],"updatedAt":"%s"}`,
+		id, time.Unix(0, updatedAt*int64(time.Millisecond)).UTC().Format(time.RFC3339Nano))
+	var segment data.Segment
+	require.NoError(t, json.Unmarshal([]byte(jsonStr), &segment))
+	return &segment
+}
+
+func newTestDB(t *testing.T) (*sql.DB, func()) {
+	dir, err := ioutil.TempDir("", "fb-sql")
+	require.NoError(t, err)
+	db, err := sql.Open("sqlite", filepath.Join(dir, "featbit.db"))
+	require.NoError(t, err)
+	return db, func() {
+		_ = db.Close()
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func TestCachedDataStorage(t *testing.T) {
+	db, closeDB := newTestDB(t)
+	defer closeDB()
+	sqlStorage := newTestSQLStorage(t, db, "cached_")
+	storage := NewCachedDataStorage(sqlStorage, time.Hour)
+	defer storage.Close()
+	// another instance sharing the same database
+	other := newTestSQLStorage(t, db, "cached_")
+	defer other.Close()
+
+	require.NoError(t, storage.Init(map[Category]map[string]Item{data.Features: {"ff1": newFlag(t, "ff1", "a", 10, false)}}, 10))
+	item, _ := storage.Get(data.Features, "ff1")
+	assert.Equal(t, "a", item.(*data.FeatureFlag).GetFlagValue("v1"))
+	item, _ = storage.Get(data.Features, "ff2")
+	assert.Nil(t, item)
+	all, _ := storage.GetAll(data.Features)
+	assert.Equal(t, 1, len(all))
+
+	// the updates of the other instance are not visible before the TTL
+	_, _ = other.Upsert(data.Features, "ff1", newFlag(t, "ff1", "b", 20, false), 20)
+	_, _ = other.Upsert(data.Features, "ff2", newFlag(t, "ff2", "b", 20, false), 21)
+	item, _ = storage.Get(data.Features, "ff1")
+	assert.Equal(t, "a", item.(*data.FeatureFlag).GetFlagValue("v1"))
+	item, _ = storage.Get(data.Features, "ff2")
+	assert.Nil(t, item)
+	all, _ = storage.GetAll(data.Features)
+	assert.Equal(t, 1, len(all))
+
+	// the updates of this instance invalidate the cache
+	ok, _ := storage.Upsert(data.Features, "ff1", newFlag(t, "ff1", "c", 30, false), 30)
+	assert.True(t, ok)
+	item, _ = storage.Get(data.Features, "ff1")
+	assert.Equal(t, "c", item.(*data.FeatureFlag).GetFlagValue("v1"))
+	all, _ = storage.GetAll(data.Features)
+	assert.Equal(t, 2, len(all))
+
+	t.Run("expired", func(t *testing.T) {
+		storage := NewCachedDataStorage(sqlStorage, 10*time.Millisecond)
+		item, _ := storage.Get(data.Features, "ff1")
+		assert.Equal(t, "c", item.(*data.FeatureFlag).GetFlagValue("v1"))
+		_, _ = other.Upsert(data.Features, "ff1", newFlag(t, "ff1", "d", 40, false), 40)
+		time.Sleep(20 * time.Millisecond)
+		item, _ = storage.Get(data.Features, "ff1")
+		assert.Equal(t, "d", item.(*data.FeatureFlag).GetFlagValue("v1"))
+	})
+}
+
+func TestPersistent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fb-snapshot")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "snapshot.json")
+	db, closeDB := newTestDB(t)
+	defer closeDB()
+	sqlStorage := newTestSQLStorage(t, db, "persistent_")
+	defer sqlStorage.Close()
+
+	persistent, ok := Persistent(NewSnapshotDataStorage(NewCachedDataStorage(sqlStorage, time.Minute), path, "env-secret"))
+	assert.True(t, ok)
+	assert.Equal(t, sqlStorage, persistent)
+	_, ok = Persistent(NewSnapshotDataStorage(NewInMemoryDataStorage(), path, "env-secret"))
+	assert.False(t, ok)
+	_, ok = Persistent(NewInMemoryDataStorage())
+	assert.False(t, ok)
+}
@@ -0,0 +1,198 @@
+package datastorage
+
+import (
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"sync"
+	"sync/atomic"
+)
+
+// cowState is an immutable state of CopyOnWriteDataStorage, it's never modified once it's published
+type cowState struct {
+	version     int64
+	initialized bool
+	allData     map[Category]map[string]Item
+}
+
+func (s *cowState) Get(category Category, key string) (Item, error) {
+	item, ok := s.allData[category][key]
+	if !ok || item.IsArchived() {
+		return nil, nil
+	}
+	return item, nil
+}
+
+func (s *cowState) GetAll(category Category) (map[string]Item, error) {
+	items, ok := s.allData[category]
+	if !ok {
+		return nil, nil
+	}
+	// the caller could modify the result
+	res := make(map[string]Item, len(items))
+	for k, v := range items {
+		if !v.IsArchived() {
+			res[k] = v
+		}
+	}
+	return res, nil
+}
+
+func (s *cowState) GetVersion() int64 {
+	return s.version
+}
+
+// CopyOnWriteDataStorage is an in-memory DataStorage optimized for the reads: the data are held in an immutable state
+// swapped atomically by Init and Upsert, the reads never wait for a lock.
+//
+// Upsert copies the items of the updated category, so the writes are slower than InMemoryDataStorage,
+// which is a good trade-off as the flags are evaluated much more often than they are updated.
+// View returns the current state as a consistent view of all the items.
+type CopyOnWriteDataStorage struct {
+	state atomic.Value
+	// serializes the writes
+	writeLock sync.Mutex
+}
+
+func NewCopyOnWriteDataStorage() *CopyOnWriteDataStorage {
+	c := &CopyOnWriteDataStorage{}
+	c.state.Store(&cowState{})
+	return c
+}
+
+func (c *CopyOnWriteDataStorage) load() *cowState {
+	return c.state.Load().(*cowState)
+}
+
+func (c *CopyOnWriteDataStorage) Close() error {
+	return nil
+}
+
+func (c *CopyOnWriteDataStorage) Init(allData map[Category]map[string]Item, version int64) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	if version <= c.load().version || len(allData) == 0 {
+		return nil
+	}
+	newData := make(map[Category]map[string]Item, len(allData))
+	for cat, items := range allData {
+		_items := make(map[string]Item, len(items))
+		for key, value := range items {
+			_items[key] = value
+		}
+		newData[cat] = _items
+	}
+	c.state.Store(&cowState{version: version, initialized: true, allData: newData})
+	return nil
+}
+
+func (c *CopyOnWriteDataStorage) Upsert(category Category, key string, item Item, version int64) (bool, error) {
+	if version <= 0 || item == nil || category == nil || key == "" {
+		return false, nil
+	}
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	current := c.load()
+	oldItems := current.allData[category]
+	if oldItem, ok := oldItems[key]; ok && oldItem.GetTimestamp() >= item.GetTimestamp() {
+		return false, nil
+	}
+	newItems := make(map[string]Item, len(oldItems)+1)
+	for k, v := range oldItems {
+		newItems[k] = v
+	}
+	newItems[key] = item
+	// the other categories are shared with the current state
+	newData := make(map[Category]map[string]Item, len(current.allData)+1)
+	for cat, items := range current.allData {
+		newData[cat] = items
+	}
+	newData[category] = newItems
+	c.state.Store(&cowState{version: maxVersion(current.version, version), initialized: true, allData: newData})
+	return true, nil
+}
+
+// UpsertBatch publishes all the updated items in a single state
+func (c *CopyOnWriteDataStorage) UpsertBatch(items map[Category]map[string]Item, version int64) (bool, error) {
+	if version <= 0 {
+		return false, nil
+	}
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	current := c.load()
+	var newData map[Category]map[string]Item
+	for cat, catItems := range items {
+		oldItems := current.allData[cat]
+		var newItems map[string]Item
+		for key, item := range catItems {
+			if item == nil || cat == nil || key == "" {
+				continue
+			}
+			if oldItem, ok := oldItems[key]; ok && oldItem.GetTimestamp() >= item.GetTimestamp() {
+				continue
+			}
+			if newItems == nil {
+				newItems = make(map[string]Item, len(oldItems)+len(catItems))
+				for k, v := range oldItems {
+					newItems[k] = v
+				}
+			}
+			newItems[key] = item
+		}
+		if newItems == nil {
+			continue
+		}
+		if newData == nil {
+			// the categories not updated are shared with the current state
+			newData = make(map[Category]map[string]Item, len(current.allData)+len(items))
+			for k, v := range current.allData {
+				newData[k] = v
+			}
+		}
+		newData[cat] = newItems
+	}
+	if newData == nil {
+		return false, nil
+	}
+	c.state.Store(&cowState{version: maxVersion(current.version, version), initialized: true, allData: newData})
+	return true, nil
+}
+
+func maxVersion(v1, v2 int64) int64 {
+	if v1 > v2 {
+		return v1
+	}
+	return v2
+}
+
+func (c *CopyOnWriteDataStorage) Get(category Category, key string) (Item, error) {
+	return c.load().Get(category, key)
+}
+
+func (c *CopyOnWriteDataStorage) GetAll(category Category) (map[string]Item, error) {
+	return c.load().GetAll(category)
+}
+
+func (c *CopyOnWriteDataStorage) IsInitialized() bool {
+	return c.load().initialized
+}
+
+func (c *CopyOnWriteDataStorage) GetVersion() int64 {
+	return c.load().version
+}
+
+// AllData returns a copy of all the items, including the archived ones, and the version of the storage
+func (c *CopyOnWriteDataStorage) AllData() (map[Category]map[string]Item, int64) {
+	state := c.load()
+	res := make(map[Category]map[string]Item, len(state.allData))
+	for cat, items := range state.allData {
+		_items := make(map[string]Item, len(items))
+		for key, value := range items {
+			_items[key] = value
+		}
+		res[cat] = _items
+	}
+	return res, state.version
+}
+
+func (c *CopyOnWriteDataStorage) View() DataStorageView {
+	return c.load()
+}
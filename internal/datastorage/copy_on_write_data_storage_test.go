@@ -0,0 +1,152 @@
+package datastorage
+
+import (
+	"fmt"
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/types/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCopyOnWriteDataStorage(t *testing.T) {
+	t.Run("init", func(t *testing.T) {
+		dataStorage := NewCopyOnWriteDataStorage()
+		assert.False(t, dataStorage.IsInitialized())
+		assert.Equal(t, int64(0), dataStorage.GetVersion())
+		require.NoError(t, dataStorage.Init(nil, int64(1)))
+		assert.False(t, dataStorage.IsInitialized())
+
+		items := map[string]Item{item1.GetId(): item1, item3.GetId(): item3}
+		require.NoError(t, dataStorage.Init(map[Category]map[string]Item{data.Datatests: items}, int64(2)))
+		assert.True(t, dataStorage.IsInitialized())
+		assert.Equal(t, int64(2), dataStorage.GetVersion())
+		item, _ := dataStorage.Get(data.Datatests, item1.GetId())
+		assert.Equal(t, item1, item)
+		item, _ = dataStorage.Get(data.Datatests, item3.GetId())
+		assert.Nil(t, item)
+		allItems, _ := dataStorage.GetAll(data.Datatests)
+		assert.Equal(t, 1, len(allItems))
+
+		// the data are copied
+		items[item2.GetId()] = item2
+		item, _ = dataStorage.Get(data.Datatests, item2.GetId())
+		assert.Nil(t, item)
+		// an older version is ignored
+		require.NoError(t, dataStorage.Init(map[Category]map[string]Item{data.Datatests: {item2.GetId(): item2}}, int64(2)))
+		item, _ = dataStorage.Get(data.Datatests, item2.GetId())
+		assert.Nil(t, item)
+	})
+
+	t.Run("upsert", func(t *testing.T) {
+		dataStorage := NewCopyOnWriteDataStorage()
+		ok, err := dataStorage.Upsert(data.Datatests, item1.GetId(), item1, int64(1))
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.True(t, dataStorage.IsInitialized())
+		ok, _ = dataStorage.Upsert(data.Datatests, item2.GetId(), item2, int64(2))
+		assert.True(t, ok)
+		ok, _ = dataStorage.Upsert(data.Datatests, item2.GetId(), item2, int64(3))
+		assert.False(t, ok)
+		ok, _ = dataStorage.Upsert(data.Datatests, item2.GetId(), item2, int64(0))
+		assert.False(t, ok)
+		ok, _ = dataStorage.Upsert(nil, item1.GetId(), item1, int64(4))
+		assert.False(t, ok)
+		assert.Equal(t, int64(2), dataStorage.GetVersion())
+		allItems, _ := dataStorage.GetAll(data.Datatests)
+		assert.Equal(t, 2, len(allItems))
+
+		archived := item1.ToArchivedItem()
+		ok, _ = dataStorage.Upsert(data.Datatests, item1.GetId(), archived, int64(4))
+		assert.False(t, ok, "the archived item has the same timestamp")
+		newItem := data.NewTestItem(true)
+		ok, _ = dataStorage.Upsert(data.Datatests, item1.GetId(), newItem, int64(5))
+		assert.True(t, ok)
+		item, _ := dataStorage.Get(data.Datatests, item1.GetId())
+		assert.Nil(t, item)
+	})
+
+	t.Run("consistent view", func(t *testing.T) {
+		dataStorage := NewCopyOnWriteDataStorage()
+		require.NoError(t, dataStorage.Init(map[Category]map[string]Item{data.Datatests: {item1.GetId(): item1}}, int64(1)))
+		view := dataStorage.View()
+		_, _ = dataStorage.Upsert(data.Datatests, item2.GetId(), item2, int64(2))
+		assert.Equal(t, int64(1), view.GetVersion())
+		item, _ := view.Get(data.Datatests, item2.GetId())
+		assert.Nil(t, item)
+		allItems, _ := view.GetAll(data.Datatests)
+		assert.Equal(t, 1, len(allItems))
+		item, _ = dataStorage.Get(data.Datatests, item2.GetId())
+		assert.Equal(t, item2, item)
+	})
+}
+
+const benchmarkItemNums = 1000
+
+func prepareBenchmarkStorage(b *testing.B, storage DataStorage) []string {
+	keys := make([]string, benchmarkItemNums)
+	items := make(map[string]Item, benchmarkItemNums)
+	for i := range keys {
+		item := data.NewTestItem(false)
+		keys[i] = item.GetId()
+		items[item.GetId()] = item
+	}
+	require.NoError(b, storage.Init(map[Category]map[string]Item{data.Datatests: items}, time.Now().UnixNano()))
+	return keys
+}
+
+// benchmarkGet reads the items by all the goroutines, with a concurrent writer if the update interval is positive
+func benchmarkGet(b *testing.B, storage DataStorage, updateInterval time.Duration) {
+	keys := prepareBenchmarkStorage(b, storage)
+	var wg sync.WaitGroup
+	var done int32
+	if updateInterval > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; atomic.LoadInt32(&done) == 0; i++ {
+				item := data.NewTestItem(false)
+				_, _ = storage.Upsert(data.Datatests, keys[i%len(keys)], item, item.GetTimestamp())
+				time.Sleep(updateInterval)
+			}
+		}()
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			_, _ = storage.Get(data.Datatests, keys[i%len(keys)])
+			i++
+		}
+	})
+	b.StopTimer()
+	atomic.StoreInt32(&done, 1)
+	wg.Wait()
+}
+
+func BenchmarkGet(b *testing.B) {
+	for _, updateInterval := range []time.Duration{0, 100 * time.Microsecond} {
+		b.Run(fmt.Sprintf("InMemory/update=%v", updateInterval), func(b *testing.B) {
+			benchmarkGet(b, NewInMemoryDataStorage(), updateInterval)
+		})
+		b.Run(fmt.Sprintf("CopyOnWrite/update=%v", updateInterval), func(b *testing.B) {
+			benchmarkGet(b, NewCopyOnWriteDataStorage(), updateInterval)
+		})
+	}
+}
+
+func BenchmarkUpsert(b *testing.B) {
+	for name, storage := range map[string]DataStorage{"InMemory": NewInMemoryDataStorage(), "CopyOnWrite": NewCopyOnWriteDataStorage()} {
+		b.Run(name, func(b *testing.B) {
+			keys := prepareBenchmarkStorage(b, storage)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				item := data.NewTestItem(false)
+				_, _ = storage.Upsert(data.Datatests, keys[i%len(keys)], item, item.GetTimestamp())
+			}
+		})
+	}
+}
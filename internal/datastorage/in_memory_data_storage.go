@@ -36,7 +36,7 @@ func (i *InMemoryDataStorage) Init(allData map[Category]map[string]Item, version
 }
 
 func (i *InMemoryDataStorage) Upsert(category Category, key string, item Item, version int64) (bool, error) {
-	if i.version >= version || item == nil || category == nil || key == "" {
+	if version <= 0 || item == nil || category == nil || key == "" {
 		return false, nil
 	}
 	i.lock.Lock()
@@ -56,13 +56,50 @@ func (i *InMemoryDataStorage) Upsert(category Category, key string, item Item, v
 	} else {
 		i.allData[category] = map[string]Item{key: item}
 	}
-	i.version = version
+	// the items are versioned by their timestamps, the version of storage is the latest one
+	if version > i.version {
+		i.version = version
+	}
 	if !i.initialized {
 		i.initialized = true
 	}
 	return true, nil
 }
 
+func (i *InMemoryDataStorage) UpsertBatch(items map[Category]map[string]Item, version int64) (bool, error) {
+	if version <= 0 {
+		return false, nil
+	}
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	var updated bool
+	for cat, catItems := range items {
+		for key, item := range catItems {
+			if item == nil || cat == nil || key == "" {
+				continue
+			}
+			if oldItem, ok := i.allData[cat][key]; ok && oldItem.GetTimestamp() >= item.GetTimestamp() {
+				continue
+			}
+			if i.allData == nil {
+				i.allData = make(map[Category]map[string]Item, len(items))
+			}
+			if _, ok := i.allData[cat]; !ok {
+				i.allData[cat] = make(map[string]Item, len(catItems))
+			}
+			i.allData[cat][key] = item
+			updated = true
+		}
+	}
+	if updated {
+		if version > i.version {
+			i.version = version
+		}
+		i.initialized = true
+	}
+	return updated, nil
+}
+
 func (i *InMemoryDataStorage) Get(category Category, key string) (Item, error) {
 	i.lock.RLock()
 	defer i.lock.RUnlock()
@@ -94,6 +131,21 @@ func (i *InMemoryDataStorage) GetAll(category Category) (map[string]Item, error)
 	return res, nil
 }
 
+// AllData returns a copy of all the items, including the archived ones, and the version of the storage
+func (i *InMemoryDataStorage) AllData() (map[Category]map[string]Item, int64) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	res := make(map[Category]map[string]Item, len(i.allData))
+	for cat, items := range i.allData {
+		_items := make(map[string]Item, len(items))
+		for key, value := range items {
+			_items[key] = value
+		}
+		res[cat] = _items
+	}
+	return res, i.version
+}
+
 func (i *InMemoryDataStorage) IsInitialized() bool {
 	i.lock.RLock()
 	defer i.lock.RUnlock()
@@ -83,6 +83,23 @@ func TestUpsert(t *testing.T) {
 		item, _ = dataStorage.Get(data.Datatests, item1.GetId())
 		assert.Equal(t, newItem, item)
 	})
+	t.Run("reordered upsert", func(t *testing.T) {
+		dataStorage := NewInMemoryDataStorage()
+		older, newer := data.NewTestItem(false), data.NewTestItem(false)
+		ok, _ := dataStorage.Upsert(data.Datatests, newer.GetId(), newer, newer.GetTimestamp())
+		assert.True(t, ok)
+		// the delayed item of another key is applied, the version is kept
+		ok, _ = dataStorage.Upsert(data.Datatests, older.GetId(), older, older.GetTimestamp())
+		assert.True(t, ok)
+		assert.Equal(t, newer.GetTimestamp(), dataStorage.GetVersion())
+		item, _ := dataStorage.Get(data.Datatests, older.GetId())
+		assert.Equal(t, older, item)
+		// the delayed item of the same key is ignored
+		ok, _ = dataStorage.Upsert(data.Datatests, newer.GetId(), older, older.GetTimestamp())
+		assert.False(t, ok)
+		item, _ = dataStorage.Get(data.Datatests, newer.GetId())
+		assert.Equal(t, newer, item)
+	})
 	t.Run("invalid upsert", func(t *testing.T) {
 		dataStorage := NewInMemoryDataStorage()
 		ok, err := dataStorage.Upsert(nil, item1.GetId(), item1, int64(1))
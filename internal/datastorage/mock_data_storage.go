@@ -48,6 +48,13 @@ func (m *MockDataStorage) Upsert(category interfaces.Category, key string, item
 	return m.fakeErr == nil, m.fakeErr
 }
 
+func (m *MockDataStorage) UpsertBatch(items map[interfaces.Category]map[string]interfaces.Item, version int64) (bool, error) {
+	_, _ = UpsertBatch(m.realDataStorage, items, version)
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.fakeErr == nil, m.fakeErr
+}
+
 func (m *MockDataStorage) Get(category interfaces.Category, key string) (interfaces.Item, error) {
 	item, err := m.realDataStorage.Get(category, key)
 	m.lock.Lock()
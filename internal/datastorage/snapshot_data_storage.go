@@ -0,0 +1,259 @@
+package datastorage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/types/data"
+	"github.com/featbit/featbit-go-sdk/internal/util/log"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+)
+
+// the categories saved in the snapshot
+var snapshotCategories = []Category{data.Features, data.Segments}
+
+type snapshot struct {
+	// the fingerprint of the env secret, a snapshot of another environment is never loaded
+	Env     string                                `json:"env"`
+	Version int64                                 `json:"version"`
+	Data    map[string]map[string]json.RawMessage `json:"data"`
+}
+
+// SnapshotDataStorage is a DataStorage decorator that saves the data to a local snapshot file after each update,
+// and loads the last snapshot at startup, so that the flags could be evaluated before the DataSynchronizer is ready.
+//
+// The data loaded from the snapshot are stale until the DataSynchronizer delivers fresh data.
+// The snapshot is written in background by a go routine, the file is replaced atomically.
+// The snapshot holds a fingerprint of the env secret, it's not loaded by the SDK of another environment.
+type SnapshotDataStorage struct {
+	storage   DataStorage
+	path      string
+	env       string
+	stale     int32
+	dirtyCh   chan struct{}
+	closeOnce sync.Once
+	closeCh   chan struct{}
+	doneCh    chan struct{}
+}
+
+// NewSnapshotDataStorage creates a SnapshotDataStorage and loads the snapshot into the given storage if it exists
+// and if it's saved by the same environment
+func NewSnapshotDataStorage(storage DataStorage, path string, envSecret string) *SnapshotDataStorage {
+	s := &SnapshotDataStorage{
+		storage: storage,
+		path:    path,
+		env:     envFingerprint(envSecret),
+		dirtyCh: make(chan struct{}, 1),
+		closeCh: make(chan struct{}),
+		doneCh:  make(chan struct{}),
+	}
+	s.load()
+	go s.writeRoutine()
+	return s
+}
+
+// envFingerprint returns a hash of the env secret, the env secret itself is never written in the snapshot
+func envFingerprint(envSecret string) string {
+	sum := sha256.Sum256([]byte(envSecret))
+	return hex.EncodeToString(sum[:8])
+}
+
+func (s *SnapshotDataStorage) load() {
+	content, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		log.LogDebug("no snapshot file: %s", s.path)
+		return
+	} else if err != nil {
+		log.LogWarn("FB GO SDK: failed to read the snapshot file: %v", err)
+		return
+	}
+	allData, version, env, err := decodeSnapshot(content)
+	if err != nil {
+		log.LogWarn("FB GO SDK: invalid snapshot file %s: %v", s.path, err)
+		return
+	}
+	if env != s.env {
+		log.LogWarn("FB GO SDK: the snapshot file %s is saved by another environment, it's not loaded", s.path)
+		return
+	}
+	if s.storage.IsInitialized() {
+		// the storage is persistent and already has the data
+		return
+	}
+	if err = s.storage.Init(allData, version); err != nil {
+		log.LogWarn("FB GO SDK: failed to load the snapshot: %v", err)
+		return
+	}
+	if s.storage.IsInitialized() {
+		log.LogInfo("FB GO SDK: the data are loaded from the snapshot %s, version = %d", s.path, version)
+		atomic.StoreInt32(&s.stale, 1)
+	}
+}
+
+func decodeSnapshot(content []byte) (map[Category]map[string]Item, int64, string, error) {
+	var snap snapshot
+	if err := json.Unmarshal(content, &snap); err != nil {
+		return nil, 0, "", err
+	}
+	allData := make(map[Category]map[string]Item, len(snapshotCategories))
+	for _, cat := range snapshotCategories {
+		items := make(map[string]Item, len(snap.Data[cat.GetName()]))
+		for key, value := range snap.Data[cat.GetName()] {
+			item, err := data.DecodeItem(cat, value)
+			if err != nil {
+				return nil, 0, "", fmt.Errorf("%s %s: %v", cat.GetName(), key, err)
+			}
+			items[key] = item
+		}
+		allData[cat] = items
+	}
+	return allData, snap.Version, snap.Env, nil
+}
+
+func (s *SnapshotDataStorage) writeRoutine() {
+	defer close(s.doneCh)
+	for {
+		select {
+		case <-s.dirtyCh:
+			s.write()
+		case <-s.closeCh:
+			select {
+			case <-s.dirtyCh:
+				s.write()
+			default:
+			}
+			return
+		}
+	}
+}
+
+func (s *SnapshotDataStorage) markDirty() {
+	select {
+	case s.dirtyCh <- struct{}{}:
+	default:
+	}
+}
+
+func (s *SnapshotDataStorage) write() {
+	snap := snapshot{Env: s.env, Version: s.storage.GetVersion(), Data: make(map[string]map[string]json.RawMessage, len(snapshotCategories))}
+	for _, cat := range snapshotCategories {
+		items, err := s.storage.GetAll(cat)
+		if err != nil {
+			log.LogWarn("FB GO SDK: failed to read the data for the snapshot: %v", err)
+			return
+		}
+		values := make(map[string]json.RawMessage, len(items))
+		for key, item := range items {
+			bytes, err := data.EncodeItem(item)
+			if err != nil {
+				log.LogWarn("FB GO SDK: failed to encode the data for the snapshot: %v", err)
+				return
+			}
+			values[key] = bytes
+		}
+		snap.Data[cat.GetName()] = values
+	}
+	content, err := json.Marshal(snap)
+	if err == nil {
+		err = writeFileAtomically(s.path, content)
+	}
+	if err != nil {
+		log.LogWarn("FB GO SDK: failed to write the snapshot: %v", err)
+		return
+	}
+	log.LogDebug("snapshot is saved, version = %d", snap.Version)
+}
+
+// writeFileAtomically writes a temporary file in the same directory and renames it,
+// the readers never see a partially written file
+func writeFileAtomically(path string, content []byte) error {
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := ioutil.TempFile(dir, name+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(content); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Close writes the pending changes to the snapshot and closes the underlying storage
+func (s *SnapshotDataStorage) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.closeCh)
+		<-s.doneCh
+	})
+	return s.storage.Close()
+}
+
+func (s *SnapshotDataStorage) Init(allData map[Category]map[string]Item, version int64) error {
+	if err := s.storage.Init(allData, version); err != nil {
+		return err
+	}
+	if s.storage.GetVersion() == version {
+		atomic.StoreInt32(&s.stale, 0)
+		s.markDirty()
+	}
+	return nil
+}
+
+func (s *SnapshotDataStorage) Upsert(category Category, key string, item Item, version int64) (bool, error) {
+	ok, err := s.storage.Upsert(category, key, item, version)
+	if ok {
+		s.markDirty()
+	}
+	return ok, err
+}
+
+func (s *SnapshotDataStorage) UpsertBatch(items map[Category]map[string]Item, version int64) (bool, error) {
+	ok, err := UpsertBatch(s.storage, items, version)
+	if ok {
+		s.markDirty()
+	}
+	return ok, err
+}
+
+func (s *SnapshotDataStorage) Get(category Category, key string) (Item, error) {
+	return s.storage.Get(category, key)
+}
+
+func (s *SnapshotDataStorage) GetAll(category Category) (map[string]Item, error) {
+	return s.storage.GetAll(category)
+}
+
+func (s *SnapshotDataStorage) IsInitialized() bool {
+	return s.storage.IsInitialized()
+}
+
+func (s *SnapshotDataStorage) GetVersion() int64 {
+	return s.storage.GetVersion()
+}
+
+func (s *SnapshotDataStorage) unwrap() DataStorage {
+	return s.storage
+}
+
+func (s *SnapshotDataStorage) IsStale() bool {
+	return atomic.LoadInt32(&s.stale) == 1
+}
+
+func (s *SnapshotDataStorage) MarkFresh() {
+	atomic.StoreInt32(&s.stale, 0)
+}
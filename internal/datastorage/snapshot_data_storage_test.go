@@ -0,0 +1,84 @@
+package datastorage
+
+import (
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/types/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSnapshotDataStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fb-snapshot")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "snapshot.json")
+
+	t.Run("no snapshot", func(t *testing.T) {
+		storage := NewSnapshotDataStorage(NewInMemoryDataStorage(), path, "env-secret")
+		assert.False(t, storage.IsInitialized())
+		assert.False(t, storage.IsStale())
+		require.NoError(t, storage.Close())
+		_, err := os.Stat(path)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("save the updates", func(t *testing.T) {
+		storage := NewSnapshotDataStorage(NewInMemoryDataStorage(), path, "env-secret")
+		allData := map[Category]map[string]Item{
+			data.Features: {"ff1": newFlag(t, "ff1", "a", 10, false)},
+			data.Segments: {"seg1": newSegment(t, "seg1", 20)},
+		}
+		require.NoError(t, storage.Init(allData, 20))
+		ok, err := storage.Upsert(data.Features, "ff2", newFlag(t, "ff2", "b", 30, false), 30)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.False(t, storage.IsStale())
+		// the pending changes are written when closing
+		require.NoError(t, storage.Close())
+		files, _ := ioutil.ReadDir(dir)
+		assert.Equal(t, 1, len(files))
+	})
+
+	t.Run("load the snapshot", func(t *testing.T) {
+		storage := NewSnapshotDataStorage(NewInMemoryDataStorage(), path, "env-secret")
+		defer storage.Close()
+		assert.True(t, storage.IsInitialized())
+		assert.True(t, storage.IsStale())
+		assert.Equal(t, int64(30), storage.GetVersion())
+		item, _ := storage.Get(data.Features, "ff2")
+		assert.Equal(t, "b", item.(*data.FeatureFlag).GetFlagValue("v1"))
+		item, _ = storage.Get(data.Segments, "seg1")
+		assert.Equal(t, data.SegmentIncludeUser, item.(*data.Segment).MatchUser("u1"))
+		all, _ := storage.GetAll(data.Features)
+		assert.Equal(t, 2, len(all))
+
+		// a patch doesn't mean that all the data are up-to-date
+		ok, _ := storage.Upsert(data.Features, "ff1", newFlag(t, "ff1", "c", 40, false), 40)
+		assert.True(t, ok)
+		assert.True(t, storage.IsStale())
+		storage.MarkFresh()
+		assert.False(t, storage.IsStale())
+	})
+
+	t.Run("snapshot of another env", func(t *testing.T) {
+		storage := NewSnapshotDataStorage(NewInMemoryDataStorage(), path, "other-env-secret")
+		assert.False(t, storage.IsInitialized())
+		assert.False(t, storage.IsStale())
+		require.NoError(t, storage.Close())
+		content, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		assert.NotContains(t, string(content), "env-secret")
+	})
+
+	t.Run("invalid snapshot", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(path, []byte("{invalid"), 0644))
+		storage := NewSnapshotDataStorage(NewInMemoryDataStorage(), path, "env-secret")
+		defer storage.Close()
+		assert.False(t, storage.IsInitialized())
+		assert.False(t, storage.IsStale())
+	})
+}
@@ -0,0 +1,319 @@
+package datastorage
+
+import (
+	"database/sql"
+	"fmt"
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/types/data"
+	"github.com/featbit/featbit-go-sdk/internal/util/log"
+	"sync"
+	"time"
+)
+
+// the categories persisted in the database
+var sqlCategories = []Category{data.Features, data.Segments}
+
+// SQLDataStorage is a DataStorage that persists the data in a relational database through database/sql,
+// the queries are compatible with Postgres and SQLite.
+//
+// The items of all the categories are saved in a single table, the version of the storage is saved in a table of one row.
+// Init replaces all the items in a transaction, Upsert updates the item and the version in a transaction,
+// the version rules are applied by the conditional updates, so that the storage could be shared by the SDK instances:
+// an item is only replaced by a newer one, and the version of storage is the latest version applied.
+type SQLDataStorage struct {
+	db           *sql.DB
+	itemsTable   string
+	versionTable string
+	lock         sync.RWMutex
+	initialized  bool
+	// the last version read from the database, returned if the database can't be reached
+	version int64
+	// the interval at which the availability of the database is probed
+	probeInterval time.Duration
+}
+
+// NewSQLDataStorage creates a SQLDataStorage and the tables if they don't exist, the tables are named by the prefix.
+// The availability of the database is probed at the given interval, the default interval of the SDK is used if it's 0.
+func NewSQLDataStorage(db *sql.DB, tablePrefix string, probeInterval time.Duration) (*SQLDataStorage, error) {
+	s := &SQLDataStorage{db: db, itemsTable: tablePrefix + "items", versionTable: tablePrefix + "version", probeInterval: probeInterval}
+	statements := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	category VARCHAR(64) NOT NULL,
+	item_key VARCHAR(255) NOT NULL,
+	timestamp BIGINT NOT NULL,
+	archived INTEGER NOT NULL,
+	payload TEXT NOT NULL,
+	PRIMARY KEY (category, item_key)
+)`, s.itemsTable),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (id INTEGER PRIMARY KEY, version BIGINT NOT NULL)`, s.versionTable),
+		fmt.Sprintf(`INSERT INTO %s (id, version) VALUES (1, 0) ON CONFLICT (id) DO NOTHING`, s.versionTable),
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func isSQLCategory(category Category) bool {
+	for _, cat := range sqlCategories {
+		if cat == category {
+			return true
+		}
+	}
+	return false
+}
+
+// Close doesn't close the database, which is owned by the caller
+func (s *SQLDataStorage) Close() error {
+	return nil
+}
+
+// updateVersion sets the version in the transaction if it's newer than the current one, it also locks the version row
+// until the end of transaction if it's updated
+func (s *SQLDataStorage) updateVersion(tx *sql.Tx, version int64) (bool, error) {
+	res, err := tx.Exec(fmt.Sprintf(`UPDATE %s SET version = $1 WHERE id = 1 AND version < $2`, s.versionTable), version, version)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (s *SQLDataStorage) Init(allData map[Category]map[string]Item, version int64) (err error) {
+	if len(allData) == 0 {
+		return nil
+	}
+	for cat := range allData {
+		if !isSQLCategory(cat) {
+			return fmt.Errorf("unsupported category: %s", cat.GetName())
+		}
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	ok, err := s.updateVersion(tx, version)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return tx.Rollback()
+	}
+	if _, err = tx.Exec(fmt.Sprintf(`DELETE FROM %s`, s.itemsTable)); err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(fmt.Sprintf(`INSERT INTO %s (category, item_key, timestamp, archived, payload) VALUES ($1, $2, $3, $4, $5)`, s.itemsTable))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for cat, items := range allData {
+		for key, item := range items {
+			var payload []byte
+			if payload, err = data.EncodeItem(item); err != nil {
+				return err
+			}
+			if _, err = stmt.Exec(cat.GetName(), key, item.GetTimestamp(), boolToInt(item.IsArchived()), string(payload)); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLDataStorage) Upsert(category Category, key string, item Item, version int64) (_ bool, err error) {
+	if version <= 0 || item == nil || category == nil || key == "" {
+		return false, nil
+	}
+	if !isSQLCategory(category) {
+		return false, fmt.Errorf("unsupported category: %s", category.GetName())
+	}
+	payload, err := data.EncodeItem(item)
+	if err != nil {
+		return false, err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	n, err := s.upsertItem(tx, category, key, item, payload)
+	if err != nil {
+		return false, err
+	}
+	if n == 0 {
+		return false, tx.Rollback()
+	}
+	// the version of storage is the latest version applied
+	if _, err = s.updateVersion(tx, version); err != nil {
+		return false, err
+	}
+	if err = tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *SQLDataStorage) upsertItemQuery() string {
+	// the item is only replaced by a newer one
+	return fmt.Sprintf(`INSERT INTO %[1]s (category, item_key, timestamp, archived, payload) VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (category, item_key) DO UPDATE SET timestamp = excluded.timestamp, archived = excluded.archived, payload = excluded.payload
+WHERE %[1]s.timestamp < excluded.timestamp`, s.itemsTable)
+}
+
+// upsertItem returns the number of rows updated
+func (s *SQLDataStorage) upsertItem(tx *sql.Tx, category Category, key string, item Item, payload []byte) (int64, error) {
+	res, err := tx.Exec(s.upsertItemQuery(), category.GetName(), key, item.GetTimestamp(), boolToInt(item.IsArchived()), string(payload))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// UpsertBatch applies the items in a transaction
+func (s *SQLDataStorage) UpsertBatch(items map[Category]map[string]Item, version int64) (_ bool, err error) {
+	if version <= 0 {
+		return false, nil
+	}
+	payloads := make(map[Category]map[string][]byte, len(items))
+	for cat, catItems := range items {
+		if !isSQLCategory(cat) {
+			return false, fmt.Errorf("unsupported category: %s", cat.GetName())
+		}
+		payloads[cat] = make(map[string][]byte, len(catItems))
+		for key, item := range catItems {
+			if item == nil || key == "" {
+				continue
+			}
+			if payloads[cat][key], err = data.EncodeItem(item); err != nil {
+				return false, err
+			}
+		}
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	var updated int64
+	for cat, catPayloads := range payloads {
+		for key, payload := range catPayloads {
+			var n int64
+			if n, err = s.upsertItem(tx, cat, key, items[cat][key], payload); err != nil {
+				return false, err
+			}
+			updated += n
+		}
+	}
+	if updated == 0 {
+		return false, tx.Rollback()
+	}
+	if _, err = s.updateVersion(tx, version); err != nil {
+		return false, err
+	}
+	if err = tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *SQLDataStorage) Get(category Category, key string) (Item, error) {
+	var payload string
+	var archived int
+	err := s.db.QueryRow(fmt.Sprintf(`SELECT payload, archived FROM %s WHERE category = $1 AND item_key = $2`, s.itemsTable), category.GetName(), key).Scan(&payload, &archived)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if archived != 0 {
+		return nil, nil
+	}
+	return data.DecodeItem(category, []byte(payload))
+}
+
+func (s *SQLDataStorage) GetAll(category Category) (map[string]Item, error) {
+	rows, err := s.db.Query(fmt.Sprintf(`SELECT item_key, payload FROM %s WHERE category = $1 AND archived = 0`, s.itemsTable), category.GetName())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make(map[string]Item)
+	for rows.Next() {
+		var key, payload string
+		if err = rows.Scan(&key, &payload); err != nil {
+			return nil, err
+		}
+		item, err := data.DecodeItem(category, []byte(payload))
+		if err != nil {
+			return nil, err
+		}
+		res[key] = item
+	}
+	return res, rows.Err()
+}
+
+// IsInitialized returns true if the storage is initialized by any of the SDK instances sharing the same tables
+func (s *SQLDataStorage) IsInitialized() bool {
+	s.lock.RLock()
+	initialized := s.initialized
+	s.lock.RUnlock()
+	if initialized {
+		return true
+	}
+	if s.GetVersion() > 0 {
+		// the data in database are never removed by the SDK
+		s.lock.Lock()
+		s.initialized = true
+		s.lock.Unlock()
+		return true
+	}
+	return false
+}
+
+// GetVersion returns the version in database, or the last known version if the database can't be reached
+func (s *SQLDataStorage) GetVersion() int64 {
+	var version int64
+	if err := s.db.QueryRow(fmt.Sprintf(`SELECT version FROM %s WHERE id = 1`, s.versionTable)).Scan(&version); err != nil {
+		log.LogError("FB GO SDK: database error, the last known version is kept: %v", err)
+		s.lock.RLock()
+		defer s.lock.RUnlock()
+		return s.version
+	}
+	s.lock.Lock()
+	s.version = version
+	s.lock.Unlock()
+	return version
+}
+
+// ProbeInterval returns the interval at which the availability of the database is probed
+func (s *SQLDataStorage) ProbeInterval() time.Duration {
+	return s.probeInterval
+}
+
+func (s *SQLDataStorage) CheckAvailability() error {
+	var version int64
+	return s.db.QueryRow(fmt.Sprintf(`SELECT version FROM %s WHERE id = 1`, s.versionTable)).Scan(&version)
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,135 @@
+package datastorage
+
+import (
+	"database/sql"
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/types/data"
+	_ "github.com/glebarez/go-sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestSQLStorage(t *testing.T, db *sql.DB, prefix string) *SQLDataStorage {
+	storage, err := NewSQLDataStorage(db, prefix, 0)
+	require.NoError(t, err)
+	return storage
+}
+
+func TestSQLDataStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fb-sql")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	db, err := sql.Open("sqlite", filepath.Join(dir, "featbit.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	t.Run("init and get", func(t *testing.T) {
+		storage := newTestSQLStorage(t, db, "init_")
+		defer storage.Close()
+		assert.False(t, storage.IsInitialized())
+		assert.Equal(t, int64(0), storage.GetVersion())
+
+		allData := map[Category]map[string]Item{
+			data.Features: {"ff1": newFlag(t, "ff1", "a", 10, false), "ff2": newFlag(t, "ff2", "b", 20, true).ToArchivedItem()},
+			data.Segments: {"seg1": newSegment(t, "seg1", 30)},
+		}
+		require.NoError(t, storage.Init(allData, 30))
+		assert.True(t, storage.IsInitialized())
+		assert.Equal(t, int64(30), storage.GetVersion())
+
+		item, err := storage.Get(data.Features, "ff1")
+		require.NoError(t, err)
+		assert.Equal(t, "a", item.(*data.FeatureFlag).GetFlagValue("v1"))
+		assert.Equal(t, int64(10), item.GetTimestamp())
+		item, err = storage.Get(data.Features, "ff2")
+		require.NoError(t, err)
+		assert.Nil(t, item)
+		item, err = storage.Get(data.Features, "unknown")
+		require.NoError(t, err)
+		assert.Nil(t, item)
+		item, err = storage.Get(data.Segments, "seg1")
+		require.NoError(t, err)
+		assert.Equal(t, data.SegmentIncludeUser, item.(*data.Segment).MatchUser("u1"))
+		all, err := storage.GetAll(data.Features)
+		require.NoError(t, err)
+		assert.Equal(t, 1, len(all))
+
+		// an older version is ignored
+		require.NoError(t, storage.Init(map[Category]map[string]Item{data.Features: {}}, 20))
+		all, _ = storage.GetAll(data.Features)
+		assert.Equal(t, 1, len(all))
+		// a newer version replaces all the data
+		require.NoError(t, storage.Init(map[Category]map[string]Item{data.Features: {"ff3": newFlag(t, "ff3", "c", 40, false)}}, 40))
+		all, _ = storage.GetAll(data.Features)
+		assert.Equal(t, 1, len(all))
+		assert.NotNil(t, all["ff3"])
+		all, _ = storage.GetAll(data.Segments)
+		assert.Equal(t, 0, len(all))
+
+		err = storage.Init(map[Category]map[string]Item{data.Datatests: {"test": data.NewTestItem(false)}}, 50)
+		assert.Error(t, err)
+		assert.Equal(t, int64(40), storage.GetVersion())
+	})
+
+	t.Run("upsert", func(t *testing.T) {
+		storage := newTestSQLStorage(t, db, "upsert_")
+		defer storage.Close()
+
+		ok, err := storage.Upsert(data.Features, "ff1", newFlag(t, "ff1", "a", 10, false), 10)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.True(t, storage.IsInitialized())
+		// a delayed item is applied, the version of storage is not changed
+		ok, err = storage.Upsert(data.Features, "ff0", newFlag(t, "ff0", "z", 5, false), 5)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, int64(10), storage.GetVersion())
+		// the item is not newer, the version is not changed
+		ok, err = storage.Upsert(data.Features, "ff1", newFlag(t, "ff1", "b", 10, false), 20)
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.Equal(t, int64(10), storage.GetVersion())
+		ok, _ = storage.Upsert(data.Features, "ff1", newFlag(t, "ff1", "b", 20, false), 20)
+		assert.True(t, ok)
+		assert.Equal(t, int64(20), storage.GetVersion())
+		item, _ := storage.Get(data.Features, "ff1")
+		assert.Equal(t, "b", item.(*data.FeatureFlag).GetFlagValue("v1"))
+
+		ok, _ = storage.Upsert(data.Features, "ff1", newFlag(t, "ff1", "b", 30, true).ToArchivedItem(), 30)
+		assert.True(t, ok)
+		item, _ = storage.Get(data.Features, "ff1")
+		assert.Nil(t, item)
+		all, _ := storage.GetAll(data.Features)
+		assert.Equal(t, 1, len(all))
+		assert.NotNil(t, all["ff0"])
+		// the placeholder is kept
+		ok, _ = storage.Upsert(data.Features, "ff1", newFlag(t, "ff1", "c", 25, false), 40)
+		assert.False(t, ok)
+	})
+
+	t.Run("last known version if the database fails", func(t *testing.T) {
+		storage := newTestSQLStorage(t, db, "lost_")
+		defer storage.Close()
+		require.NoError(t, storage.Init(map[Category]map[string]Item{data.Features: {"ff1": newFlag(t, "ff1", "a", 10, false)}}, 10))
+		assert.Equal(t, int64(10), storage.GetVersion())
+		_, err := db.Exec(`DROP TABLE lost_version`)
+		require.NoError(t, err)
+		assert.Equal(t, int64(10), storage.GetVersion())
+	})
+
+	t.Run("shared by instances", func(t *testing.T) {
+		storage1 := newTestSQLStorage(t, db, "shared_")
+		storage2 := newTestSQLStorage(t, db, "shared_")
+		other := newTestSQLStorage(t, db, "other_")
+		_, _ = storage1.Upsert(data.Features, "ff1", newFlag(t, "ff1", "a", 10, false), 10)
+		assert.True(t, storage2.IsInitialized())
+		assert.Equal(t, int64(10), storage2.GetVersion())
+		item, _ := storage2.Get(data.Features, "ff1")
+		assert.NotNil(t, item)
+		assert.False(t, other.IsInitialized())
+	})
+}
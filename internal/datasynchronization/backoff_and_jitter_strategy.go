@@ -2,6 +2,7 @@ package datasynchronization
 
 import (
 	realRand "crypto/rand"
+	. "github.com/featbit/featbit-go-sdk/interfaces"
 	"github.com/featbit/featbit-go-sdk/internal/util/log"
 	"math"
 	"math/big"
@@ -9,6 +10,15 @@ import (
 	"time"
 )
 
+const (
+	DefaultMaxRetryDelay = 60 * time.Second
+	DefaultResetInterval = 60 * time.Second
+	DefaultJitterRatio   = 0.5
+)
+
+// BackoffAndJitterStrategy is the default RetryPolicy: the delay is doubled after each failure up to the max delay,
+// a part of the delay is randomized by the jitter ratio. The delay is reset to the first one if the last good run
+// is older than the reset interval.
 type BackoffAndJitterStrategy struct {
 	firstRetryDelay time.Duration
 	maxRetryDelay   time.Duration
@@ -18,16 +28,19 @@ type BackoffAndJitterStrategy struct {
 	lastGoodRun     time.Time
 }
 
-var defaultStrategy = &BackoffAndJitterStrategy{
-	maxRetryDelay: 60 * 1000 * time.Millisecond,
-	resetInterval: 60 * 1000 * time.Millisecond,
-	jitterRatio:   0.5,
-	retryCount:    0,
+// NewBackoffAndJitterStrategy creates a BackoffAndJitterStrategy, the jitter ratio is between 0 and 1
+func NewBackoffAndJitterStrategy(firstRetryDelay time.Duration, maxRetryDelay time.Duration, resetInterval time.Duration, jitterRatio float64) *BackoffAndJitterStrategy {
+	return &BackoffAndJitterStrategy{
+		firstRetryDelay: firstRetryDelay,
+		maxRetryDelay:   maxRetryDelay,
+		resetInterval:   resetInterval,
+		jitterRatio:     math.Max(0, math.Min(1, jitterRatio)),
+	}
 }
 
+// NewWithFirstRetryDelay creates a BackoffAndJitterStrategy with the default max delay, reset interval and jitter ratio
 func NewWithFirstRetryDelay(firstRetryDelay time.Duration) *BackoffAndJitterStrategy {
-	defaultStrategy.firstRetryDelay = firstRetryDelay
-	return defaultStrategy
+	return NewBackoffAndJitterStrategy(firstRetryDelay, DefaultMaxRetryDelay, DefaultResetInterval, DefaultJitterRatio)
 }
 
 func (s *BackoffAndJitterStrategy) SetGoodRunAtNow() {
@@ -55,9 +68,18 @@ func (b *BackoffAndJitterStrategy) NextDelay() time.Duration {
 	}
 	backOff := b.countBackoffTime()
 	jitterTime := b.countJitterTime(backOff)
-	delay := (jitterTime + backOff/2) * 1000
+	// without jitter, the delay is the backoff time; the jitter randomizes a part of it
+	delay := (backOff*(1-b.jitterRatio) + jitterTime) * 1000
 	b.retryCount += 1
 	millis := time.Duration(int64(math.Floor(delay))) * time.Millisecond
 	log.LogInfo("backoff: %v, jitter: %v, next delay: %v", backOff, jitterTime, millis.Milliseconds())
 	return millis
 }
+
+func (b *BackoffAndJitterStrategy) OnSuccess() {
+	b.SetGoodRunAtNow()
+}
+
+func (b *BackoffAndJitterStrategy) OnFailure(_ ConnectionFailure) (time.Duration, bool) {
+	return b.NextDelay(), true
+}
@@ -1,7 +1,10 @@
 package datasynchronization
 
 import (
+	"fmt"
+	. "github.com/featbit/featbit-go-sdk/interfaces"
 	"github.com/stretchr/testify/assert"
+	"net/http"
 	"testing"
 	"time"
 )
@@ -24,3 +27,55 @@ func TestNextDelay(t *testing.T) {
 	delay = strategy.NextDelay()
 	assert.True(t, delay < 60*time.Second)
 }
+
+func TestBackoffAndJitterStrategy(t *testing.T) {
+	t.Run("no jitter", func(t *testing.T) {
+		strategy := NewBackoffAndJitterStrategy(time.Second, 5*time.Second, time.Minute, 0)
+		strategy.OnSuccess()
+		for _, expected := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+			delay, retry := strategy.OnFailure(ConnectionFailure{})
+			assert.True(t, retry)
+			assert.Equal(t, expected, delay)
+		}
+	})
+	t.Run("state is not shared", func(t *testing.T) {
+		strategy1 := NewWithFirstRetryDelay(time.Second)
+		strategy1.SetGoodRunAtNow()
+		strategy2 := NewWithFirstRetryDelay(10 * time.Second)
+		strategy2.SetGoodRunAtNow()
+		for i := 0; i < 3; i++ {
+			strategy1.NextDelay()
+		}
+		assert.Equal(t, time.Second, strategy1.firstRetryDelay)
+		delay := strategy2.NextDelay()
+		assert.True(t, delay >= 5*time.Second && delay < 10*time.Second)
+	})
+	t.Run("reset after a long good run", func(t *testing.T) {
+		strategy := NewBackoffAndJitterStrategy(time.Second, time.Minute, 10*time.Millisecond, 0)
+		strategy.SetGoodRunAtNow()
+		strategy.NextDelay()
+		strategy.NextDelay()
+		time.Sleep(20 * time.Millisecond)
+		assert.Equal(t, time.Second, strategy.NextDelay())
+	})
+}
+
+func TestCircuitBreakerRetryPolicy(t *testing.T) {
+	authFailure := ConnectionFailure{Err: fmt.Errorf("bad handshake"), StatusCode: http.StatusUnauthorized}
+	networkFailure := ConnectionFailure{Err: fmt.Errorf("connection refused")}
+	policy := NewCircuitBreakerRetryPolicy(NewBackoffAndJitterStrategy(time.Second, time.Minute, time.Minute, 0), 3)
+	policy.OnSuccess()
+	_, retry := policy.OnFailure(authFailure)
+	assert.True(t, retry)
+	_, retry = policy.OnFailure(authFailure)
+	assert.True(t, retry)
+	// the auth failures must be consecutive
+	_, retry = policy.OnFailure(networkFailure)
+	assert.True(t, retry)
+	_, retry = policy.OnFailure(authFailure)
+	assert.True(t, retry)
+	_, retry = policy.OnFailure(authFailure)
+	assert.True(t, retry)
+	_, retry = policy.OnFailure(authFailure)
+	assert.False(t, retry)
+}
@@ -0,0 +1,38 @@
+package datasynchronization
+
+import (
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/util/log"
+	"time"
+)
+
+// CircuitBreakerRetryPolicy is a RetryPolicy that stops retrying after a number of consecutive auth failures,
+// a rejected env secret is not going to be accepted by retrying. The delays are given by the wrapped RetryPolicy.
+type CircuitBreakerRetryPolicy struct {
+	policy          RetryPolicy
+	maxAuthFailures int
+	authFailures    int
+}
+
+// NewCircuitBreakerRetryPolicy creates a CircuitBreakerRetryPolicy wrapping the given policy
+func NewCircuitBreakerRetryPolicy(policy RetryPolicy, maxAuthFailures int) *CircuitBreakerRetryPolicy {
+	return &CircuitBreakerRetryPolicy{policy: policy, maxAuthFailures: maxAuthFailures}
+}
+
+func (c *CircuitBreakerRetryPolicy) OnSuccess() {
+	c.authFailures = 0
+	c.policy.OnSuccess()
+}
+
+func (c *CircuitBreakerRetryPolicy) OnFailure(failure ConnectionFailure) (time.Duration, bool) {
+	if failure.IsAuthFailure() {
+		c.authFailures++
+		if c.authFailures >= c.maxAuthFailures {
+			log.LogError("FB GO SDK: the env secret is rejected %d times in a row, stop retrying", c.authFailures)
+			return 0, false
+		}
+	} else {
+		c.authFailures = 0
+	}
+	return c.policy.OnFailure(failure)
+}
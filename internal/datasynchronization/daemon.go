@@ -0,0 +1,106 @@
+package datasynchronization
+
+import (
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/util/log"
+	"sync"
+	"time"
+)
+
+// storageChecker is implemented by the DataUpdater that checks the availability of a persistent storage
+type storageChecker interface {
+	CheckStorageAvailability() error
+}
+
+// Daemon is a DataSynchronizer that doesn't connect to the feature flag center: the data are read from a persistent
+// DataStorage populated by another process, such as a relay.
+//
+// The daemon is initialized once the storage has got the data, it periodically checks the availability of the storage
+// and reports it as the status.
+type Daemon struct {
+	dataUpdater   DataUpdater
+	checkInterval time.Duration
+	// start actions should call only one time
+	startOnce sync.Once
+	// ready actions should call only one time
+	readyOnce sync.Once
+	// notify that the storage has got the data
+	readyCh chan struct{}
+	// close actions should call only one time
+	closeOnce   sync.Once
+	closeCh     chan struct{}
+	lock        sync.RWMutex
+	initialized bool
+}
+
+func NewDaemon(dataUpdater DataUpdater, checkInterval time.Duration) *Daemon {
+	return &Daemon{
+		dataUpdater:   dataUpdater,
+		checkInterval: checkInterval,
+		readyCh:       make(chan struct{}),
+		closeCh:       make(chan struct{}),
+	}
+}
+
+func (d *Daemon) Close() error {
+	d.closeOnce.Do(func() {
+		log.LogInfo("FB GO SDK: daemon is stopping")
+		close(d.closeCh)
+	})
+	return nil
+}
+
+func (d *Daemon) IsInitialized() bool {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	return d.initialized
+}
+
+func (d *Daemon) Start() <-chan struct{} {
+	d.startOnce.Do(func() {
+		log.LogDebug("Daemon Starting...")
+		updateActiveSource(d.dataUpdater, StorageSource)
+		d.check()
+		go d.checkRoutine()
+	})
+	return d.readyCh
+}
+
+func (d *Daemon) checkRoutine() {
+	ticker := time.NewTicker(d.checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			d.check()
+		case <-d.closeCh:
+			d.dataUpdater.UpdateStatus(NormalOFFState())
+			d.readyOnce.Do(func() {
+				close(d.readyCh)
+			})
+			log.LogDebug("daemon go routine is over")
+			return
+		}
+	}
+}
+
+func (d *Daemon) check() {
+	if checker, ok := d.dataUpdater.(storageChecker); ok {
+		if err := checker.CheckStorageAvailability(); err != nil {
+			log.LogError("FB GO SDK: data storage is unavailable: %v", err)
+			d.dataUpdater.UpdateStatus(INTERRUPTEDState(DataStorageUnavailable, err.Error()))
+			return
+		}
+	}
+	if !d.dataUpdater.StorageInitialized() {
+		log.LogDebug("data storage is not yet populated")
+		return
+	}
+	d.readyOnce.Do(func() {
+		d.lock.Lock()
+		d.initialized = true
+		d.lock.Unlock()
+		close(d.readyCh)
+	})
+	d.dataUpdater.UpdateStatus(OKState())
+}
@@ -0,0 +1,82 @@
+package datasynchronization
+
+import (
+	"fmt"
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/datastorage"
+	"github.com/featbit/featbit-go-sdk/internal/dataupdating"
+	"github.com/featbit/featbit-go-sdk/internal/types/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakePersistentStorage is an in-memory storage of which the availability could be changed
+type fakePersistentStorage struct {
+	*datastorage.InMemoryDataStorage
+	unavailable int32
+}
+
+func (f *fakePersistentStorage) CheckAvailability() error {
+	if atomic.LoadInt32(&f.unavailable) == 1 {
+		return fmt.Errorf("connection refused")
+	}
+	return nil
+}
+
+func TestDaemon(t *testing.T) {
+	t.Run("initialized when the storage is populated", func(t *testing.T) {
+		storage := &fakePersistentStorage{InMemoryDataStorage: datastorage.NewInMemoryDataStorage()}
+		dataUpdater := dataupdating.NewDataUpdaterImpl(storage)
+		statusProvider := dataupdating.NewDataUpdateStatusProviderImpl(dataUpdater)
+		daemon := NewDaemon(dataUpdater, 10*time.Millisecond)
+		defer daemon.Close()
+
+		ready := daemon.Start()
+		assert.False(t, daemon.IsInitialized())
+		assert.Equal(t, StorageSource, statusProvider.GetActiveSource())
+		assert.Equal(t, INITIALIZING, statusProvider.GetCurrentState().StateType)
+
+		item := data.NewTestItem(false)
+		require.NoError(t, storage.Init(map[Category]map[string]Item{data.Datatests: {item.GetId(): item}}, item.GetTimestamp()))
+		select {
+		case <-ready:
+		case <-time.After(time.Second):
+			t.Fatal("daemon is not ready")
+		}
+		assert.True(t, daemon.IsInitialized())
+		assert.True(t, dataUpdater.StorageInitialized())
+		assert.Equal(t, OK, statusProvider.GetCurrentState().StateType)
+	})
+
+	t.Run("storage is unavailable", func(t *testing.T) {
+		storage := &fakePersistentStorage{InMemoryDataStorage: datastorage.NewInMemoryDataStorage()}
+		item := data.NewTestItem(false)
+		require.NoError(t, storage.Init(map[Category]map[string]Item{data.Datatests: {item.GetId(): item}}, item.GetTimestamp()))
+		dataUpdater := dataupdating.NewDataUpdaterImpl(storage)
+		statusProvider := dataupdating.NewDataUpdateStatusProviderImpl(dataUpdater)
+		daemon := NewDaemon(dataUpdater, 10*time.Millisecond)
+
+		<-daemon.Start()
+		assert.True(t, daemon.IsInitialized())
+		assert.Equal(t, OK, statusProvider.GetCurrentState().StateType)
+
+		atomic.StoreInt32(&storage.unavailable, 1)
+		assert.Eventually(t, func() bool {
+			state := statusProvider.GetCurrentState()
+			return state.StateType == INTERRUPTED && state.ErrorTrack.ErrorType == DataStorageUnavailable
+		}, time.Second, 10*time.Millisecond)
+
+		atomic.StoreInt32(&storage.unavailable, 0)
+		assert.Eventually(t, func() bool {
+			return statusProvider.GetCurrentState().StateType == OK
+		}, time.Second, 10*time.Millisecond)
+
+		_ = daemon.Close()
+		assert.Eventually(t, func() bool {
+			return statusProvider.GetCurrentState().StateType == OFF
+		}, time.Second, 10*time.Millisecond)
+	})
+}
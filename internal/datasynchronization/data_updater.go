@@ -0,0 +1,49 @@
+package datasynchronization
+
+import (
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"sort"
+)
+
+type patchItem struct {
+	category Category
+	key      string
+	item     Item
+}
+
+// upsertBatch applies the items of a patch in a single operation if the DataUpdater implements BatchDataUpdater,
+// otherwise the items are upserted one by one in the order of their timestamps.
+func upsertBatch(dataUpdater DataUpdater, items map[Category]map[string]Item, version int64) bool {
+	if batchUpdater, ok := dataUpdater.(BatchDataUpdater); ok {
+		return batchUpdater.UpsertBatch(items, version)
+	}
+	var patch []patchItem
+	for cat, catItems := range items {
+		for key, item := range catItems {
+			if item != nil {
+				patch = append(patch, patchItem{category: cat, key: key, item: item})
+			}
+		}
+	}
+	sort.Slice(patch, func(i, j int) bool {
+		return patch[i].item.GetTimestamp() < patch[j].item.GetTimestamp()
+	})
+	success := true
+	for _, p := range patch {
+		success = dataUpdater.Upsert(p.category, p.key, p.item, p.item.GetTimestamp()) && success
+	}
+	return success
+}
+
+// resyncRequested returns true if the DataUpdater implements ResyncDataUpdater and requests the full data again
+func resyncRequested(dataUpdater DataUpdater) bool {
+	resyncUpdater, ok := dataUpdater.(ResyncDataUpdater)
+	return ok && resyncUpdater.ResyncRequested()
+}
+
+// updateActiveSource reports the source of the data if the DataUpdater implements ActiveSourceUpdater
+func updateActiveSource(dataUpdater DataUpdater, source string) {
+	if sourceUpdater, ok := dataUpdater.(ActiveSourceUpdater); ok {
+		sourceUpdater.UpdateActiveSource(source)
+	}
+}
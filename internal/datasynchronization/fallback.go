@@ -0,0 +1,372 @@
+package datasynchronization
+
+import (
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/util/log"
+	"sync"
+	"time"
+)
+
+const (
+	minFallbackCheckInterval = 10 * time.Millisecond
+	maxFallbackCheckInterval = time.Second
+)
+
+// SynchronizerCreator creates a DataSynchronizer that pushes the data into the given DataUpdater
+type SynchronizerCreator func(dataUpdater DataUpdater) (DataSynchronizer, error)
+
+// sourceUpdater is the DataUpdater given to a source of the Fallback, the data and the status are only forwarded
+// to the real DataUpdater if the source is active. The data of an inactive source, such as a probe, are kept aside
+// and pushed once the source is activated, so that the probe never competes with the active source.
+type sourceUpdater struct {
+	DataUpdater
+	lock sync.Mutex
+	// forward the data and the status to the real DataUpdater
+	active bool
+	name   string
+	state  State
+	// the data received while inactive, since the last full data
+	pending []func(DataUpdater) bool
+	// the time the source left the OK state, or its creation time if it has never been OK
+	unhealthySince time.Time
+}
+
+func newSourceUpdater(dataUpdater DataUpdater, active bool) *sourceUpdater {
+	return &sourceUpdater{DataUpdater: dataUpdater, active: active, unhealthySince: time.Now()}
+}
+
+// write pushes the data into the real DataUpdater if the source is active, otherwise they are kept until activate,
+// the pending data are discarded by the full data
+func (s *sourceUpdater) write(update func(DataUpdater) bool, full bool) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.active {
+		return update(s.DataUpdater)
+	}
+	if full {
+		s.pending = nil
+	}
+	s.pending = append(s.pending, update)
+	return true
+}
+
+func (s *sourceUpdater) Init(allData map[Category]map[string]Item, version int64) bool {
+	return s.write(func(dataUpdater DataUpdater) bool {
+		return dataUpdater.Init(allData, version)
+	}, true)
+}
+
+func (s *sourceUpdater) Upsert(category Category, key string, item Item, version int64) bool {
+	return s.write(func(dataUpdater DataUpdater) bool {
+		return dataUpdater.Upsert(category, key, item, version)
+	}, false)
+}
+
+func (s *sourceUpdater) UpdateStatus(state State) {
+	s.lock.Lock()
+	if state.StateType != OK && s.state.StateType == OK {
+		s.unhealthySince = time.Now()
+	}
+	s.state = state
+	active := s.active
+	s.lock.Unlock()
+	if active {
+		s.DataUpdater.UpdateStatus(state)
+	}
+}
+
+func (s *sourceUpdater) UpdateActiveSource(source string) {
+	s.lock.Lock()
+	s.name = source
+	active := s.active
+	s.lock.Unlock()
+	if active {
+		updateActiveSource(s.DataUpdater, source)
+	}
+}
+
+func (s *sourceUpdater) UpsertBatch(items map[Category]map[string]Item, version int64) bool {
+	return s.write(func(dataUpdater DataUpdater) bool {
+		return upsertBatch(dataUpdater, items, version)
+	}, false)
+}
+
+func (s *sourceUpdater) ResyncRequested() bool {
+	return resyncRequested(s.DataUpdater)
+}
+
+// activate pushes the pending data, and forwards the last status and the name of the source to the real DataUpdater
+func (s *sourceUpdater) activate() {
+	s.lock.Lock()
+	s.active = true
+	for _, update := range s.pending {
+		update(s.DataUpdater)
+	}
+	s.pending = nil
+	state := s.state
+	name := s.name
+	s.lock.Unlock()
+	if name != "" {
+		updateActiveSource(s.DataUpdater, name)
+	}
+	s.DataUpdater.UpdateStatus(state)
+}
+
+func (s *sourceUpdater) deactivate() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.active = false
+}
+
+// unhealthyFor returns how long the source has left the OK state, or since its creation if never OK
+func (s *sourceUpdater) unhealthyFor(now time.Time) time.Duration {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.state.StateType == OK {
+		return 0
+	}
+	return now.Sub(s.unhealthySince)
+}
+
+func (s *sourceUpdater) isOK() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.state.StateType == OK
+}
+
+func (s *sourceUpdater) isErrorOFF() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.state.StateType == OFF && s.state.ErrorTrack.ErrorType != ""
+}
+
+type source struct {
+	synchronizer DataSynchronizer
+	updater      *sourceUpdater
+	// closed once the synchronizer is ready or has failed
+	ready <-chan struct{}
+	// closed once the source is stopped
+	closed chan struct{}
+}
+
+func (s *source) close() {
+	s.updater.deactivate()
+	_ = s.synchronizer.Close()
+	close(s.closed)
+}
+
+// Fallback is a DataSynchronizer that feeds the data from a primary source, normally the streaming, and switches to
+// a secondary source, normally the polling, if the primary one is not healthy for longer than a threshold.
+// Once the secondary source is active, the primary source is periodically probed and takes over again once it's healthy.
+type Fallback struct {
+	dataUpdater     DataUpdater
+	createPrimary   SynchronizerCreator
+	createSecondary SynchronizerCreator
+	threshold       time.Duration
+	probeInterval   time.Duration
+	checkInterval   time.Duration
+	startOnce       sync.Once
+	readyOnce       sync.Once
+	readyCh         chan struct{}
+	closeOnce       sync.Once
+	closeCh         chan struct{}
+	doneCh          chan struct{}
+	lock            sync.RWMutex
+	initialized     bool
+	started         bool
+	// only used by the orchestration go routine once started
+	primary   *source
+	secondary *source
+	probe     *source
+	nextProbe time.Time
+}
+
+func NewFallback(dataUpdater DataUpdater, createPrimary SynchronizerCreator, createSecondary SynchronizerCreator, threshold time.Duration, probeInterval time.Duration) *Fallback {
+	checkInterval := threshold / 4
+	if checkInterval < minFallbackCheckInterval {
+		checkInterval = minFallbackCheckInterval
+	} else if checkInterval > maxFallbackCheckInterval {
+		checkInterval = maxFallbackCheckInterval
+	}
+	return &Fallback{
+		dataUpdater:     dataUpdater,
+		createPrimary:   createPrimary,
+		createSecondary: createSecondary,
+		threshold:       threshold,
+		probeInterval:   probeInterval,
+		checkInterval:   checkInterval,
+		readyCh:         make(chan struct{}),
+		closeCh:         make(chan struct{}),
+		doneCh:          make(chan struct{}),
+	}
+}
+
+func (f *Fallback) Close() error {
+	f.closeOnce.Do(func() {
+		log.LogInfo("FB GO SDK: fallback data synchronizer is stopping")
+		// Start and Close are exclusive, the sources are never started once closed
+		f.lock.Lock()
+		close(f.closeCh)
+		started := f.started
+		f.lock.Unlock()
+		if started {
+			<-f.doneCh
+		} else {
+			f.markReady(false)
+		}
+	})
+	return nil
+}
+
+func (f *Fallback) IsInitialized() bool {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return f.initialized
+}
+
+func (f *Fallback) Start() <-chan struct{} {
+	f.startOnce.Do(func() {
+		log.LogDebug("Fallback data synchronizer Starting...")
+		if err := f.start(); err != nil {
+			f.dataUpdater.UpdateStatus(ErrorOFFState(UnknownError, err.Error()))
+			f.markReady(false)
+		}
+	})
+	return f.readyCh
+}
+
+// start starts the first healthy source and the orchestration go routine, unless the Fallback is closed
+func (f *Fallback) start() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if isClosed(f.closeCh) {
+		return nil
+	}
+	var err error
+	if f.primary, err = f.startSource(f.createPrimary, true); err != nil {
+		log.LogError("FB GO SDK: failed to create the primary data source: %v", err)
+		if f.secondary, err = f.startSource(f.createSecondary, true); err != nil {
+			log.LogError("FB GO SDK: failed to create the secondary data source: %v", err)
+			return err
+		}
+	}
+	f.started = true
+	go f.run()
+	return nil
+}
+
+func (f *Fallback) startSource(create SynchronizerCreator, active bool) (*source, error) {
+	updater := newSourceUpdater(f.dataUpdater, active)
+	synchronizer, err := create(updater)
+	if err != nil {
+		return nil, err
+	}
+	s := &source{synchronizer: synchronizer, updater: updater, ready: synchronizer.Start(), closed: make(chan struct{})}
+	// the SDK is ready once any of the sources is initialized
+	go func() {
+		select {
+		case <-s.ready:
+			if synchronizer.IsInitialized() {
+				f.markReady(true)
+			}
+		case <-s.closed:
+		}
+	}()
+	return s, nil
+}
+
+func (f *Fallback) markReady(initialized bool) {
+	f.readyOnce.Do(func() {
+		f.lock.Lock()
+		f.initialized = initialized
+		f.lock.Unlock()
+		close(f.readyCh)
+	})
+}
+
+func (f *Fallback) run() {
+	defer close(f.doneCh)
+	ticker := time.NewTicker(f.checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			f.check(now)
+		case <-f.closeCh:
+			for _, s := range []*source{f.primary, f.secondary, f.probe} {
+				if s != nil {
+					s.close()
+				}
+			}
+			f.markReady(false)
+			return
+		}
+	}
+}
+
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func (f *Fallback) check(now time.Time) {
+	if f.primary != nil {
+		// the primary source has given up or has not been healthy for too long
+		failed := isClosed(f.primary.ready) && !f.primary.synchronizer.IsInitialized()
+		if failed || f.primary.updater.isErrorOFF() || f.primary.updater.unhealthyFor(now) > f.threshold {
+			log.LogWarn("FB GO SDK: the primary data source is not healthy, fallback to the secondary one")
+			f.fallback(now)
+		}
+		return
+	}
+	if f.secondary != nil && isClosed(f.secondary.ready) && !f.secondary.synchronizer.IsInitialized() {
+		// both sources failed
+		f.markReady(false)
+	}
+	if f.probe == nil {
+		if !now.Before(f.nextProbe) {
+			log.LogDebug("probing the primary data source")
+			probe, err := f.startSource(f.createPrimary, false)
+			if err != nil {
+				log.LogError("FB GO SDK: failed to create the primary data source: %v", err)
+				f.nextProbe = now.Add(f.probeInterval)
+				return
+			}
+			f.probe = probe
+		}
+		return
+	}
+	if f.probe.updater.isOK() {
+		log.LogInfo("FB GO SDK: the primary data source is healthy again, stop the secondary one")
+		if f.secondary != nil {
+			f.secondary.close()
+		}
+		f.probe.updater.activate()
+		f.primary, f.secondary, f.probe = f.probe, nil, nil
+		return
+	}
+	failed := isClosed(f.probe.ready) && !f.probe.synchronizer.IsInitialized()
+	if failed || f.probe.updater.isErrorOFF() || f.probe.updater.unhealthyFor(now) > f.threshold {
+		log.LogDebug("the primary data source is still not healthy")
+		f.probe.close()
+		f.probe = nil
+		f.nextProbe = now.Add(f.probeInterval)
+	}
+}
+
+func (f *Fallback) fallback(now time.Time) {
+	f.primary.close()
+	var err error
+	f.primary = nil
+	f.secondary, err = f.startSource(f.createSecondary, true)
+	f.nextProbe = now.Add(f.probeInterval)
+	if err != nil {
+		// keep probing the primary source
+		log.LogError("FB GO SDK: failed to create the secondary data source: %v", err)
+		f.dataUpdater.UpdateStatus(INTERRUPTEDState(UnknownError, err.Error()))
+	}
+}
@@ -0,0 +1,177 @@
+package datasynchronization
+
+import (
+	"fmt"
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/datastorage"
+	"github.com/featbit/featbit-go-sdk/internal/dataupdating"
+	"github.com/featbit/featbit-go-sdk/internal/types/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeSource struct {
+	name        string
+	healthy     bool
+	dataUpdater DataUpdater
+	readyCh     chan struct{}
+	initialized int32
+	closed      int32
+}
+
+func (f *fakeSource) Close() error {
+	atomic.StoreInt32(&f.closed, 1)
+	return nil
+}
+
+func (f *fakeSource) IsInitialized() bool {
+	return atomic.LoadInt32(&f.initialized) == 1
+}
+
+func (f *fakeSource) Start() <-chan struct{} {
+	updateActiveSource(f.dataUpdater, f.name)
+	if f.healthy {
+		atomic.StoreInt32(&f.initialized, 1)
+		f.dataUpdater.UpdateStatus(OKState())
+		close(f.readyCh)
+	} else {
+		f.dataUpdater.UpdateStatus(INTERRUPTEDState(NetworkError, "fake error"))
+	}
+	return f.readyCh
+}
+
+func (f *fakeSource) isClosed() bool {
+	return atomic.LoadInt32(&f.closed) == 1
+}
+
+type fakeSources struct {
+	lock      sync.Mutex
+	name      string
+	healthy   []bool
+	instances []*fakeSource
+}
+
+func (f *fakeSources) create(dataUpdater DataUpdater) (DataSynchronizer, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	n := len(f.instances)
+	if n >= len(f.healthy) {
+		return nil, fmt.Errorf("no more source")
+	}
+	s := &fakeSource{name: f.name, healthy: f.healthy[n], dataUpdater: dataUpdater, readyCh: make(chan struct{})}
+	f.instances = append(f.instances, s)
+	return s, nil
+}
+
+func (f *fakeSources) get(i int) *fakeSource {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if i >= len(f.instances) {
+		return nil
+	}
+	return f.instances[i]
+}
+
+func TestFallback(t *testing.T) {
+	t.Run("fallback and back to primary", func(t *testing.T) {
+		dataUpdater := dataupdating.NewDataUpdaterImpl(datastorage.NewInMemoryDataStorage())
+		status := dataupdating.NewDataUpdateStatusProviderImpl(dataUpdater)
+		// the first probe fails, the second one succeeds
+		primary := &fakeSources{name: StreamingSource, healthy: []bool{false, false, true}}
+		secondary := &fakeSources{name: PollingSource, healthy: []bool{true}}
+		fallback := NewFallback(dataUpdater, primary.create, secondary.create, 50*time.Millisecond, 50*time.Millisecond)
+		defer fallback.Close()
+
+		select {
+		case <-fallback.Start():
+		case <-time.After(time.Second):
+			t.Fatal("fallback is not ready")
+		}
+		assert.True(t, fallback.IsInitialized())
+		assert.Equal(t, PollingSource, status.GetActiveSource())
+		assert.Equal(t, OK, status.GetCurrentState().StateType)
+		assert.True(t, primary.get(0).isClosed())
+
+		assert.Eventually(t, func() bool { return status.GetActiveSource() == StreamingSource }, 2*time.Second, 10*time.Millisecond)
+		assert.Equal(t, OK, status.GetCurrentState().StateType)
+		assert.True(t, primary.get(1).isClosed())
+		assert.False(t, primary.get(2).isClosed())
+		assert.True(t, secondary.get(0).isClosed())
+
+		require.NoError(t, fallback.Close())
+		assert.True(t, primary.get(2).isClosed())
+	})
+	t.Run("healthy primary", func(t *testing.T) {
+		dataUpdater := dataupdating.NewDataUpdaterImpl(datastorage.NewInMemoryDataStorage())
+		status := dataupdating.NewDataUpdateStatusProviderImpl(dataUpdater)
+		primary := &fakeSources{name: StreamingSource, healthy: []bool{true}}
+		secondary := &fakeSources{name: PollingSource, healthy: []bool{true}}
+		fallback := NewFallback(dataUpdater, primary.create, secondary.create, 20*time.Millisecond, 20*time.Millisecond)
+		<-fallback.Start()
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(t, StreamingSource, status.GetActiveSource())
+		assert.Nil(t, secondary.get(0))
+		require.NoError(t, fallback.Close())
+		assert.True(t, primary.get(0).isClosed())
+	})
+	t.Run("no fallback before the threshold after a long healthy run", func(t *testing.T) {
+		dataUpdater := dataupdating.NewDataUpdaterImpl(datastorage.NewInMemoryDataStorage())
+		status := dataupdating.NewDataUpdateStatusProviderImpl(dataUpdater)
+		primary := &fakeSources{name: StreamingSource, healthy: []bool{true}}
+		secondary := &fakeSources{name: PollingSource, healthy: []bool{true}}
+		fallback := NewFallback(dataUpdater, primary.create, secondary.create, 200*time.Millisecond, time.Minute)
+		defer fallback.Close()
+		<-fallback.Start()
+		// healthy but quiet for longer than the threshold
+		time.Sleep(300 * time.Millisecond)
+		primary.get(0).dataUpdater.UpdateStatus(INTERRUPTEDState(NetworkError, "fake error"))
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(t, StreamingSource, status.GetActiveSource())
+		assert.Nil(t, secondary.get(0))
+		assert.Eventually(t, func() bool { return status.GetActiveSource() == PollingSource }, 2*time.Second, 10*time.Millisecond)
+	})
+	t.Run("both sources fail", func(t *testing.T) {
+		dataUpdater := dataupdating.NewDataUpdaterImpl(datastorage.NewInMemoryDataStorage())
+		status := dataupdating.NewDataUpdateStatusProviderImpl(dataUpdater)
+		primary := &fakeSources{name: StreamingSource}
+		secondary := &fakeSources{name: PollingSource}
+		fallback := NewFallback(dataUpdater, primary.create, secondary.create, 20*time.Millisecond, 20*time.Millisecond)
+		<-fallback.Start()
+		assert.False(t, fallback.IsInitialized())
+		assert.Equal(t, OFF, status.GetCurrentState().StateType)
+		require.NoError(t, fallback.Close())
+	})
+	t.Run("closed before started", func(t *testing.T) {
+		dataUpdater := dataupdating.NewDataUpdaterImpl(datastorage.NewInMemoryDataStorage())
+		primary := &fakeSources{name: StreamingSource, healthy: []bool{true}}
+		secondary := &fakeSources{name: PollingSource, healthy: []bool{true}}
+		fallback := NewFallback(dataUpdater, primary.create, secondary.create, 20*time.Millisecond, 20*time.Millisecond)
+		require.NoError(t, fallback.Close())
+		select {
+		case <-fallback.Start():
+		case <-time.After(time.Second):
+			t.Fatal("fallback is not ready")
+		}
+		assert.False(t, fallback.IsInitialized())
+		assert.Nil(t, primary.get(0))
+	})
+}
+
+func TestSourceUpdater(t *testing.T) {
+	dataUpdater := dataupdating.NewDataUpdaterImpl(datastorage.NewInMemoryDataStorage())
+	probe := newSourceUpdater(dataUpdater, false)
+	// the data of an inactive source are kept aside
+	assert.True(t, probe.Init(map[Category]map[string]Item{data.Features: {}}, 10))
+	assert.False(t, dataUpdater.StorageInitialized())
+	probe.activate()
+	assert.True(t, dataUpdater.StorageInitialized())
+	assert.Equal(t, int64(10), dataUpdater.GetVersion())
+	// the data of a closed source are discarded
+	probe.deactivate()
+	assert.True(t, probe.Init(map[Category]map[string]Item{data.Features: {}}, 20))
+	assert.Equal(t, int64(10), dataUpdater.GetVersion())
+}
@@ -0,0 +1,199 @@
+package datasynchronization
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/types/data"
+	"github.com/featbit/featbit-go-sdk/internal/util/log"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// FileDataSynchronizer loads the flags and segments from one or more json files in the FeatBit format, the format
+// accepted by FBClient.InitializeFromExternalJson, and watches the files for changes by polling their mtime and hash.
+//
+// The flags and segments of all the files are merged, if an item is defined in several files, the last file wins.
+// If any of the files can't be read or parsed, the status is set to INTERRUPTED and the last good data are kept.
+type FileDataSynchronizer struct {
+	dataUpdater  DataUpdater
+	paths        []string
+	pollInterval time.Duration
+	// start actions should call only one time
+	startOnce sync.Once
+	// notify that the first loading is done
+	readyCh chan struct{}
+	// close actions should call only one time
+	closeOnce sync.Once
+	// notify that the watching should quit
+	closeCh     chan struct{}
+	lock        sync.RWMutex
+	initialized bool
+	// only used by the watching go routine once started
+	stamps []fileStamp
+	hash   []byte
+}
+
+func NewFileDataSynchronizer(dataUpdater DataUpdater, paths []string, pollInterval time.Duration) *FileDataSynchronizer {
+	return &FileDataSynchronizer{
+		dataUpdater:  dataUpdater,
+		paths:        paths,
+		pollInterval: pollInterval,
+		readyCh:      make(chan struct{}),
+		closeCh:      make(chan struct{}),
+	}
+}
+
+func (f *FileDataSynchronizer) Close() error {
+	f.closeOnce.Do(func() {
+		log.LogInfo("FB GO SDK: file data synchronizer is stopping")
+		close(f.closeCh)
+	})
+	return nil
+}
+
+func (f *FileDataSynchronizer) IsInitialized() bool {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return f.initialized
+}
+
+func (f *FileDataSynchronizer) Start() <-chan struct{} {
+	f.startOnce.Do(func() {
+		log.LogDebug("File data synchronizer Starting...")
+		updateActiveSource(f.dataUpdater, FileSource)
+		// the files are local, no need to wait for them: the SDK is ready after the first loading, even if it failed
+		f.reload()
+		close(f.readyCh)
+		go f.watchRoutine()
+	})
+	return f.readyCh
+}
+
+func (f *FileDataSynchronizer) watchRoutine() {
+	log.LogDebug("file watching go routine is starting")
+	ticker := time.NewTicker(f.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			f.reload()
+		case <-f.closeCh:
+			f.dataUpdater.UpdateStatus(NormalOFFState())
+			log.LogDebug("file watching go routine is over")
+			return
+		}
+	}
+}
+
+// reload loads the files if any of them has changed since the last loading
+func (f *FileDataSynchronizer) reload() {
+	stamps := make([]fileStamp, len(f.paths))
+	for i, path := range f.paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			f.onError(DataInvalidError, err)
+			return
+		}
+		stamps[i] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+	}
+	if f.stampsUnchanged(stamps) {
+		return
+	}
+	contents := make([][]byte, len(f.paths))
+	hash := sha256.New()
+	for i, path := range f.paths {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			f.onError(DataInvalidError, err)
+			return
+		}
+		contents[i] = content
+		_, _ = hash.Write(content)
+	}
+	sum := hash.Sum(nil)
+	// the files are touched but not changed, or restored to the last good data
+	if bytes.Equal(sum, f.hash) {
+		f.stamps = stamps
+		f.dataUpdater.UpdateStatus(OKState())
+		return
+	}
+	allData, version, err := mergeFiles(f.paths, contents)
+	if err != nil {
+		// don't parse again the same invalid files
+		f.stamps = stamps
+		f.onError(DataInvalidError, err)
+		return
+	}
+	// the content of a file could be changed without changing the timestamps of the items,
+	// the version must be increased to replace the data in storage
+	if current := f.dataUpdater.GetVersion(); version <= current {
+		version = current + 1
+	}
+	if !f.dataUpdater.Init(allData, version) {
+		// the data updater has handled the storage error
+		return
+	}
+	f.stamps, f.hash = stamps, sum
+	log.LogDebug("loading data files is well done")
+	f.lock.Lock()
+	f.initialized = true
+	f.lock.Unlock()
+	f.dataUpdater.UpdateStatus(OKState())
+}
+
+func (f *FileDataSynchronizer) stampsUnchanged(stamps []fileStamp) bool {
+	if len(f.stamps) != len(stamps) {
+		return false
+	}
+	for i, stamp := range stamps {
+		if !stamp.modTime.Equal(f.stamps[i].modTime) || stamp.size != f.stamps[i].size {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *FileDataSynchronizer) onError(errorType string, err error) {
+	log.LogError("FB GO SDK: failed to load data files: %v", err)
+	f.dataUpdater.UpdateStatus(INTERRUPTEDState(errorType, err.Error()))
+}
+
+// mergeFiles parses the files and merges their flags and segments, returns the merged data and its version
+func mergeFiles(paths []string, contents [][]byte) (map[Category]map[string]Item, int64, error) {
+	merged := map[Category]map[string]Item{
+		data.Features: make(map[string]Item),
+		data.Segments: make(map[string]Item),
+	}
+	var version int64
+	for i, content := range contents {
+		var all data.All
+		if err := json.Unmarshal(content, &all); err != nil {
+			return nil, 0, fmt.Errorf("%s: %v", paths[i], err)
+		}
+		if !all.IsProcessData() {
+			return nil, 0, fmt.Errorf("%s: %s", paths[i], jsonParsingErrorMsg)
+		}
+		for cat, items := range all.Data.ToStorageType() {
+			for key, item := range items {
+				if _, ok := merged[cat][key]; ok {
+					log.LogWarn("FB GO SDK: %s %s is overridden by the file %s", cat.GetName(), key, paths[i])
+				}
+				merged[cat][key] = item
+			}
+		}
+		if timestamp := all.Data.GetTimestamp(); timestamp > version {
+			version = timestamp
+		}
+	}
+	return merged, version, nil
+}
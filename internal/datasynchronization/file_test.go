@@ -0,0 +1,95 @@
+package datasynchronization
+
+import (
+	"fmt"
+	"github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/datastorage"
+	"github.com/featbit/featbit-go-sdk/internal/dataupdating"
+	"github.com/featbit/featbit-go-sdk/internal/types/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const fileFlagFormat = `{"messageType":"data-sync","data":{"eventType":"full","featureFlags":[{"id":"%[1]s","key":"%[1]s","name":"%[1]s","isEnabled":false,"disabledVariationId":"v1","variations":[{"id":"v1","value":"%[2]s"}],"updatedAt":"2023-01-01T00:00:00Z"}],"segments":[]}}`
+
+func flagValue(t *testing.T, storage interfaces.DataStorage, key string) string {
+	item, err := storage.Get(data.Features, key)
+	require.NoError(t, err)
+	if item == nil {
+		return ""
+	}
+	return item.(*data.FeatureFlag).GetFlagValue("v1")
+}
+
+func TestFileDataSynchronizer(t *testing.T) {
+	jsonBytes := loadTestData(t)
+
+	dir, err := ioutil.TempDir("", "fb-file-sync")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file1 := filepath.Join(dir, "flags.json")
+	file2 := filepath.Join(dir, "overrides.json")
+	require.NoError(t, ioutil.WriteFile(file1, jsonBytes, 0644))
+	require.NoError(t, ioutil.WriteFile(file2, []byte(fmt.Sprintf(fileFlagFormat, "ff-file", "a")), 0644))
+
+	storage := datastorage.NewInMemoryDataStorage()
+	dataUpdater := dataupdating.NewDataUpdaterImpl(storage)
+	status := dataupdating.NewDataUpdateStatusProviderImpl(dataUpdater)
+	synchronizer := NewFileDataSynchronizer(dataUpdater, []string{file1, file2}, 10*time.Millisecond)
+	defer synchronizer.Close()
+
+	<-synchronizer.Start()
+	require.True(t, synchronizer.IsInitialized())
+	assert.Equal(t, interfaces.OK, status.GetCurrentState().StateType)
+	assert.Equal(t, interfaces.FileSource, status.GetActiveSource())
+	// the flags of both files are merged
+	flag, _ := storage.Get(data.Features, "ff-test-bool")
+	assert.NotNil(t, flag)
+	assert.Equal(t, "a", flagValue(t, storage, "ff-file"))
+
+	t.Run("reload a changed file", func(t *testing.T) {
+		// the timestamp of the flag is not changed
+		require.NoError(t, ioutil.WriteFile(file2, []byte(fmt.Sprintf(fileFlagFormat, "ff-file", "bb")), 0644))
+		assert.Eventually(t, func() bool {
+			return flagValue(t, storage, "ff-file") == "bb"
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("keep the last good data if invalid", func(t *testing.T) {
+		require.NoError(t, ioutil.WriteFile(file2, []byte("{invalid json"), 0644))
+		assert.Eventually(t, func() bool {
+			return status.GetCurrentState().StateType == interfaces.INTERRUPTED
+		}, time.Second, 10*time.Millisecond)
+		assert.Equal(t, interfaces.DataInvalidError, status.GetCurrentState().ErrorTrack.ErrorType)
+		assert.Equal(t, "bb", flagValue(t, storage, "ff-file"))
+		flag, _ := storage.Get(data.Features, "ff-test-bool")
+		assert.NotNil(t, flag)
+
+		require.NoError(t, ioutil.WriteFile(file2, []byte(fmt.Sprintf(fileFlagFormat, "ff-file2", "c")), 0644))
+		assert.Eventually(t, func() bool {
+			return status.GetCurrentState().StateType == interfaces.OK
+		}, time.Second, 10*time.Millisecond)
+		assert.Equal(t, "c", flagValue(t, storage, "ff-file2"))
+		// the flag removed from the file is removed from the storage
+		assert.Equal(t, "", flagValue(t, storage, "ff-file"))
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		dataUpdater := dataupdating.NewDataUpdaterImpl(datastorage.NewInMemoryDataStorage())
+		status := dataupdating.NewDataUpdateStatusProviderImpl(dataUpdater)
+		missing := filepath.Join(dir, "missing.json")
+		synchronizer := NewFileDataSynchronizer(dataUpdater, []string{missing}, 10*time.Millisecond)
+		defer synchronizer.Close()
+		<-synchronizer.Start()
+		assert.False(t, synchronizer.IsInitialized())
+
+		require.NoError(t, ioutil.WriteFile(missing, jsonBytes, 0644))
+		assert.Eventually(t, synchronizer.IsInitialized, time.Second, 10*time.Millisecond)
+		assert.Equal(t, interfaces.OK, status.GetCurrentState().StateType)
+	})
+}
@@ -0,0 +1,170 @@
+package datasynchronization
+
+import (
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/types/data"
+	"strings"
+	"sync"
+)
+
+// filteredUpdater is a DataUpdater that only pushes a subset of the feature flags into the real DataUpdater,
+// with the segments they reference, so that the storage doesn't hold the flags that are never evaluated.
+//
+// A flag is kept if its key is given, starts with one of the prefixes or if it has one of the tags.
+// The segments are kept aside in memory, a segment is written into the storage once it's referenced by a kept flag.
+type filteredUpdater struct {
+	DataUpdater
+	prefixes []string
+	keys     map[string]struct{}
+	tags     map[string]struct{}
+	lock     sync.Mutex
+	// the kept flags and the segments they reference
+	flags map[string][]string
+	// all the segments received
+	segments map[string]Item
+}
+
+// NewFilteredUpdater creates a DataUpdater that keeps the flags matching any of the key prefixes, keys or tags
+func NewFilteredUpdater(dataUpdater DataUpdater, keyPrefixes []string, keys []string, tags []string) DataUpdater {
+	f := &filteredUpdater{
+		DataUpdater: dataUpdater,
+		prefixes:    keyPrefixes,
+		keys:        make(map[string]struct{}, len(keys)),
+		tags:        make(map[string]struct{}, len(tags)),
+		flags:       make(map[string][]string),
+		segments:    make(map[string]Item),
+	}
+	for _, key := range keys {
+		f.keys[key] = struct{}{}
+	}
+	for _, tag := range tags {
+		f.tags[tag] = struct{}{}
+	}
+	return f
+}
+
+func (f *filteredUpdater) matches(item Item) bool {
+	flag, ok := item.(*data.FeatureFlag)
+	if !ok || flag.IsArchived() {
+		return false
+	}
+	if _, ok := f.keys[flag.Key]; ok {
+		return true
+	}
+	for _, prefix := range f.prefixes {
+		if strings.HasPrefix(flag.Key, prefix) {
+			return true
+		}
+	}
+	for _, tag := range flag.Tags {
+		if _, ok := f.tags[tag]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *filteredUpdater) Init(allData map[Category]map[string]Item, version int64) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.flags = make(map[string][]string)
+	f.segments = make(map[string]Item, len(allData[data.Segments]))
+	filtered := make(map[Category]map[string]Item, len(allData))
+	for cat, items := range allData {
+		if cat != data.Features && cat != data.Segments {
+			filtered[cat] = items
+		}
+	}
+	flags := make(map[string]Item)
+	for key, item := range allData[data.Features] {
+		if f.matches(item) {
+			flags[key] = item
+			f.flags[key] = item.(*data.FeatureFlag).ReferencedSegments()
+		}
+	}
+	for key, item := range allData[data.Segments] {
+		f.segments[key] = item
+	}
+	segments := make(map[string]Item)
+	for _, refs := range f.flags {
+		for _, id := range refs {
+			if segment, ok := f.segments[id]; ok {
+				segments[id] = segment
+			}
+		}
+	}
+	filtered[data.Features] = flags
+	filtered[data.Segments] = segments
+	return f.DataUpdater.Init(filtered, version)
+}
+
+// filterPatch returns the items of a patch to write into the storage:
+// the kept flags, the flags that are no longer kept as archived, and the segments referenced by the kept flags
+func (f *filteredUpdater) filterPatch(items map[Category]map[string]Item) map[Category]map[string]Item {
+	filtered := make(map[Category]map[string]Item, len(items))
+	put := func(cat Category, key string, item Item) {
+		if _, ok := filtered[cat]; !ok {
+			filtered[cat] = make(map[string]Item)
+		}
+		filtered[cat][key] = item
+	}
+	for cat, catItems := range items {
+		if cat != data.Features && cat != data.Segments {
+			filtered[cat] = catItems
+		}
+	}
+	for key, item := range items[data.Segments] {
+		f.segments[key] = item
+	}
+	for key, item := range items[data.Features] {
+		if f.matches(item) {
+			refs := item.(*data.FeatureFlag).ReferencedSegments()
+			f.flags[key] = refs
+			put(data.Features, key, item)
+			// the segments newly referenced are written with the flag
+			for _, id := range refs {
+				if segment, ok := f.segments[id]; ok {
+					put(data.Segments, id, segment)
+				}
+			}
+		} else if _, ok := f.flags[key]; ok {
+			delete(f.flags, key)
+			put(data.Features, key, item.ToArchivedItem())
+		}
+	}
+	referenced := make(map[string]struct{})
+	for _, refs := range f.flags {
+		for _, id := range refs {
+			referenced[id] = struct{}{}
+		}
+	}
+	for key, item := range items[data.Segments] {
+		if _, ok := referenced[key]; ok {
+			put(data.Segments, key, item)
+		}
+	}
+	return filtered
+}
+
+func (f *filteredUpdater) Upsert(category Category, key string, item Item, version int64) bool {
+	return f.UpsertBatch(map[Category]map[string]Item{category: {key: item}}, version)
+}
+
+func (f *filteredUpdater) UpsertBatch(items map[Category]map[string]Item, version int64) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	filtered := f.filterPatch(items)
+	if len(filtered) == 0 {
+		// nothing to keep
+		return true
+	}
+	return upsertBatch(f.DataUpdater, filtered, version)
+}
+
+func (f *filteredUpdater) ResyncRequested() bool {
+	return resyncRequested(f.DataUpdater)
+}
+
+func (f *filteredUpdater) UpdateActiveSource(source string) {
+	updateActiveSource(f.DataUpdater, source)
+}
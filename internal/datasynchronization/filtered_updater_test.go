@@ -0,0 +1,89 @@
+package datasynchronization
+
+import (
+	"encoding/json"
+	"github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/datastorage"
+	"github.com/featbit/featbit-go-sdk/internal/dataupdating"
+	"github.com/featbit/featbit-go-sdk/internal/types/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+const testSegmentId = "a0832b1c-fe73-479f-9a30-af8f003c34bf"
+
+// changeFlag returns a newer version of the flag with the given tags
+func changeFlag(t *testing.T, flag interfaces.Item, tags ...string) *data.FeatureFlag {
+	js, _ := json.Marshal(flag)
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(js, &fields))
+	fields["tags"] = tags
+	fields["updatedAt"] = time.Unix(0, (flag.GetTimestamp()+1000)*int64(time.Millisecond)).Format(time.RFC3339Nano)
+	js, _ = json.Marshal(fields)
+	var newFlag data.FeatureFlag
+	require.NoError(t, json.Unmarshal(js, &newFlag))
+	return &newFlag
+}
+
+func TestFilteredUpdater(t *testing.T) {
+	var all data.All
+	require.NoError(t, json.Unmarshal(loadTestData(t), &all))
+	allData := all.Data.ToStorageType()
+
+	t.Run("init", func(t *testing.T) {
+		storage := datastorage.NewInMemoryDataStorage()
+		dataUpdater := NewFilteredUpdater(dataupdating.NewDataUpdaterImpl(storage), []string{"ff-test-n"}, []string{"ff-test-seg"}, nil)
+		require.True(t, dataUpdater.Init(allData, all.Data.GetTimestamp()))
+		assert.Equal(t, all.Data.GetTimestamp(), storage.GetVersion())
+		flags, _ := storage.GetAll(data.Features)
+		assert.Equal(t, 2, len(flags))
+		assert.Contains(t, flags, "ff-test-seg")
+		assert.Contains(t, flags, "ff-test-number")
+		segments, _ := storage.GetAll(data.Segments)
+		assert.Equal(t, 1, len(segments))
+		assert.Contains(t, segments, testSegmentId)
+	})
+
+	t.Run("no flag kept", func(t *testing.T) {
+		storage := datastorage.NewInMemoryDataStorage()
+		dataUpdater := NewFilteredUpdater(dataupdating.NewDataUpdaterImpl(storage), nil, []string{"unknown"}, nil)
+		require.True(t, dataUpdater.Init(allData, all.Data.GetTimestamp()))
+		assert.True(t, storage.IsInitialized())
+		flags, _ := storage.GetAll(data.Features)
+		assert.Empty(t, flags)
+		segments, _ := storage.GetAll(data.Segments)
+		assert.Empty(t, segments)
+	})
+
+	t.Run("patches by tags", func(t *testing.T) {
+		storage := datastorage.NewInMemoryDataStorage()
+		dataUpdater := NewFilteredUpdater(dataupdating.NewDataUpdaterImpl(storage), nil, nil, []string{"payment"})
+		require.True(t, dataUpdater.Init(allData, all.Data.GetTimestamp()))
+		flags, _ := storage.GetAll(data.Features)
+		assert.Empty(t, flags)
+
+		// a flag tagged later is kept with the segment it references
+		tagged := changeFlag(t, allData[data.Features]["ff-test-seg"], "payment")
+		require.True(t, dataUpdater.Upsert(data.Features, tagged.GetId(), tagged, tagged.GetTimestamp()))
+		item, _ := storage.Get(data.Features, "ff-test-seg")
+		assert.Equal(t, tagged, item)
+		item, _ = storage.Get(data.Segments, testSegmentId)
+		assert.NotNil(t, item)
+
+		// the other flags are ignored
+		other := changeFlag(t, allData[data.Features]["ff-test-bool"], "other")
+		require.True(t, dataUpdater.Upsert(data.Features, other.GetId(), other, other.GetTimestamp()))
+		item, _ = storage.Get(data.Features, "ff-test-bool")
+		assert.Nil(t, item)
+
+		// a flag no longer tagged is removed
+		untagged := changeFlag(t, tagged)
+		require.True(t, upsertBatch(dataUpdater, map[interfaces.Category]map[string]interfaces.Item{data.Features: {untagged.GetId(): untagged}}, untagged.GetTimestamp()))
+		item, _ = storage.Get(data.Features, "ff-test-seg")
+		assert.Nil(t, item)
+		flags, _ = storage.GetAll(data.Features)
+		assert.Empty(t, flags)
+	})
+}
@@ -0,0 +1,193 @@
+package datasynchronization
+
+import (
+	"encoding/json"
+	"fmt"
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/types/data"
+	"github.com/featbit/featbit-go-sdk/internal/util/log"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const pollingTimestampParam = "timestamp"
+
+type Polling struct {
+	context      Context
+	pollingUri   string
+	dataUpdater  DataUpdater
+	client       *http.Client
+	pollInterval time.Duration
+	strategy     *BackoffAndJitterStrategy
+	// start actions should call only one time
+	startOnce sync.Once
+	// ready actions should call only one time
+	readyOnce sync.Once
+	// notify that sdk client data sync is ready
+	readyCh chan struct{}
+	// close actions should call only one time
+	closeOnce sync.Once
+	// notify that polling should quit
+	closeCh     chan struct{}
+	lock        sync.RWMutex
+	initialized bool
+	// the ETag of the last response
+	etag string
+}
+
+func NewPolling(context Context, dataUpdater DataUpdater, client *http.Client, pollInterval time.Duration, firstRetryDelay time.Duration) *Polling {
+	var pollingUri string
+	if pollingConfig, ok := context.(PollingConfig); ok {
+		pollingUri = pollingConfig.GetPollingUri()
+	}
+	return &Polling{
+		context:      context,
+		pollingUri:   pollingUri,
+		dataUpdater:  dataUpdater,
+		client:       client,
+		pollInterval: pollInterval,
+		strategy:     NewWithFirstRetryDelay(firstRetryDelay),
+		readyCh:      make(chan struct{}),
+		closeCh:      make(chan struct{}),
+	}
+}
+
+func (p *Polling) Close() error {
+	p.closeOnce.Do(func() {
+		log.LogInfo("FB GO SDK: polling is stopping")
+		close(p.closeCh)
+	})
+	return nil
+}
+
+func (p *Polling) IsInitialized() bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.initialized
+}
+
+func (p *Polling) Start() <-chan struct{} {
+	p.startOnce.Do(func() {
+		log.LogDebug("Polling Starting...")
+		updateActiveSource(p.dataUpdater, PollingSource)
+		p.strategy.SetGoodRunAtNow()
+		go p.pollRoutine()
+	})
+	return p.readyCh
+}
+
+func (p *Polling) noMoreRetry() {
+	p.readyOnce.Do(func() {
+		close(p.readyCh)
+	})
+}
+
+func (p *Polling) onReady() {
+	p.readyOnce.Do(func() {
+		p.lock.Lock()
+		p.initialized = true
+		p.lock.Unlock()
+		close(p.readyCh)
+	})
+	p.dataUpdater.UpdateStatus(OKState())
+}
+
+func (p *Polling) pollRoutine() {
+	log.LogDebug("polling go routine is starting")
+	for {
+		delay := p.pollInterval
+		if ok, retry := p.poll(); !retry {
+			p.noMoreRetry()
+			log.LogDebug("polling go routine is over, no more retry")
+			return
+		} else if ok {
+			p.strategy.SetGoodRunAtNow()
+		} else {
+			delay = p.strategy.NextDelay()
+		}
+		select {
+		case <-time.After(delay):
+		case <-p.closeCh:
+			p.dataUpdater.UpdateStatus(NormalOFFState())
+			p.noMoreRetry()
+			log.LogDebug("polling go routine is over")
+			return
+		}
+	}
+}
+
+// poll fetches the data changed since the version of the storage, returns true if the data is up-to-date,
+// and false as the second value if the error can't be recovered by retrying
+func (p *Polling) poll() (bool, bool) {
+	version := syncVersion(p.dataUpdater)
+	if resyncRequested(p.dataUpdater) {
+		// the server could answer that nothing has changed
+		p.etag = ""
+	}
+	uri := fmt.Sprintf("%s?%s=%s", p.pollingUri, pollingTimestampParam, url.QueryEscape(strconv.FormatInt(version, 10)))
+	req, err := http.NewRequest("GET", uri, nil)
+	if err != nil {
+		log.LogError("FB GO SDK: invalid polling url: %s", uri)
+		p.dataUpdater.UpdateStatus(ErrorOFFState(NetworkError, err.Error()))
+		return false, false
+	}
+	req.Header = p.context.GetNetwork().GetHeaders(nil)
+	// the ETag identifies the state of the data on the server side, it could be sent even if the version has changed
+	if p.etag != "" {
+		req.Header.Set("If-None-Match", p.etag)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		if urlErr, ok := err.(*url.Error); ok {
+			if _, ok := urlErr.Err.(*net.DNSError); ok {
+				log.LogError("FB GO SDK: Host unknown: %s", err.Error())
+				p.dataUpdater.UpdateStatus(ErrorOFFState(NetworkError, err.Error()))
+				return false, false
+			}
+		}
+		log.LogError("FB GO SDK: polling network error: %s, try to poll again...", err.Error())
+		p.dataUpdater.UpdateStatus(INTERRUPTEDState(NetworkError, err.Error()))
+		return false, true
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		log.LogTrace("polling data not modified")
+		if p.dataUpdater.StorageInitialized() {
+			p.onReady()
+		}
+		return true, true
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		log.LogError("FB GO SDK: polling request is rejected, http code = %d", resp.StatusCode)
+		p.dataUpdater.UpdateStatus(ErrorOFFState(RequestInvalidError, http.StatusText(resp.StatusCode)))
+		return false, false
+	case resp.StatusCode != http.StatusOK:
+		log.LogError("FB GO SDK: polling error, http code = %d, try to poll again...", resp.StatusCode)
+		p.dataUpdater.UpdateStatus(INTERRUPTEDState(NetworkError, http.StatusText(resp.StatusCode)))
+		return false, true
+	case err != nil:
+		log.LogError("FB GO SDK: polling error: %s, try to poll again...", err.Error())
+		p.dataUpdater.UpdateStatus(INTERRUPTEDState(NetworkError, err.Error()))
+		return false, true
+	}
+	var all data.All
+	if err = json.Unmarshal(body, &all); err != nil || !all.IsProcessData() {
+		log.LogError("FB GO SDK: polling data is invalid, try to poll again...")
+		p.dataUpdater.UpdateStatus(INTERRUPTEDState(DataInvalidError, jsonParsingErrorMsg))
+		return false, true
+	}
+	if !applyData(p.dataUpdater, &all) {
+		// the data updater has handled the storage error
+		return false, true
+	}
+	p.etag = resp.Header.Get("ETag")
+	log.LogDebug("processing polling data is well done")
+	p.onReady()
+	return true, true
+}
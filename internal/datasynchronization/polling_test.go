@@ -0,0 +1,175 @@
+package datasynchronization
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/featbit/featbit-go-sdk/fixtures"
+	"github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal"
+	"github.com/featbit/featbit-go-sdk/internal/datastorage"
+	"github.com/featbit/featbit-go-sdk/internal/dataupdating"
+	fbnetwork "github.com/featbit/featbit-go-sdk/internal/network"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+var fakeEnvSecret = base64.URLEncoding.EncodeToString([]byte("http://fake"))
+
+type networkFactory struct{}
+
+func (networkFactory) CreateNetwork(config interfaces.BasicConfig) (interfaces.Network, error) {
+	headers := make(http.Header)
+	headers.Set("Authorization", config.GetEnvSecret())
+	return fbnetwork.NetworkConfigImpl{DefaultHeaders: headers}, nil
+}
+
+func loadTestData(t *testing.T) []byte {
+	// fixtures are loaded from the root directory
+	wd, _ := os.Getwd()
+	require.NoError(t, os.Chdir("../.."))
+	defer os.Chdir(wd)
+	jsonBytes, err := fixtures.LoadFBClientTestData()
+	require.NoError(t, err)
+	return jsonBytes
+}
+
+func newTestPolling(t *testing.T, handler http.HandlerFunc) (*Polling, *dataupdating.DataUpdaterImpl, func()) {
+	server := httptest.NewServer(handler)
+	ctx, err := internal.FromConfig(fakeEnvSecret, "ws://fake-url", server.URL, networkFactory{})
+	require.NoError(t, err)
+	dataUpdater := dataupdating.NewDataUpdaterImpl(datastorage.NewInMemoryDataStorage())
+	polling := NewPolling(ctx, dataUpdater, server.Client(), 10*time.Millisecond, 10*time.Millisecond)
+	return polling, dataUpdater, func() {
+		_ = polling.Close()
+		server.Close()
+	}
+}
+
+func TestPolling(t *testing.T) {
+	jsonBytes := loadTestData(t)
+
+	t.Run("poll and not modified", func(t *testing.T) {
+		var lock sync.Mutex
+		var timestamps, etags []string
+		polling, dataUpdater, stop := newTestPolling(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/api/public/sdk/server/latest-all", r.URL.Path)
+			assert.Equal(t, fakeEnvSecret, r.Header.Get("Authorization"))
+			lock.Lock()
+			timestamps = append(timestamps, r.URL.Query().Get("timestamp"))
+			etags = append(etags, r.Header.Get("If-None-Match"))
+			lock.Unlock()
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			_, _ = w.Write(jsonBytes)
+		})
+		defer stop()
+		<-polling.Start()
+		assert.True(t, polling.IsInitialized())
+		assert.True(t, dataUpdater.StorageInitialized())
+		status := dataupdating.NewDataUpdateStatusProviderImpl(dataUpdater)
+		assert.Equal(t, interfaces.OK, status.GetCurrentState().StateType)
+		assert.Eventually(t, func() bool {
+			lock.Lock()
+			defer lock.Unlock()
+			return len(timestamps) >= 3
+		}, time.Second, 10*time.Millisecond)
+		lock.Lock()
+		defer lock.Unlock()
+		assert.Equal(t, "0", timestamps[0])
+		assert.Equal(t, "", etags[0])
+		version := dataUpdater.GetVersion()
+		assert.True(t, version > 0)
+		for i := 1; i < len(timestamps); i++ {
+			assert.Equal(t, strconv.FormatInt(version, 10), timestamps[i])
+			assert.Equal(t, `"v1"`, etags[i])
+		}
+	})
+	t.Run("request rejected", func(t *testing.T) {
+		polling, dataUpdater, stop := newTestPolling(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+		defer stop()
+		<-polling.Start()
+		assert.False(t, polling.IsInitialized())
+		status := dataupdating.NewDataUpdateStatusProviderImpl(dataUpdater)
+		assert.Equal(t, interfaces.OFF, status.GetCurrentState().StateType)
+		assert.Equal(t, interfaces.RequestInvalidError, status.GetCurrentState().ErrorTrack.ErrorType)
+	})
+	t.Run("retry after errors", func(t *testing.T) {
+		var lock sync.Mutex
+		calls := 0
+		polling, dataUpdater, stop := newTestPolling(t, func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			calls++
+			n := calls
+			lock.Unlock()
+			switch n {
+			case 1:
+				w.WriteHeader(http.StatusServiceUnavailable)
+			case 2:
+				_, _ = w.Write([]byte("invalid json"))
+			default:
+				_, _ = w.Write(jsonBytes)
+			}
+		})
+		defer stop()
+		status := dataupdating.NewDataUpdateStatusProviderImpl(dataUpdater)
+		polling.Start()
+		assert.True(t, status.WaitForOKState(5*time.Second), status.GetCurrentState().String())
+		assert.True(t, polling.IsInitialized())
+		assert.Equal(t, interfaces.DataInvalidError, status.GetCurrentState().ErrorTrack.ErrorType)
+	})
+	t.Run("resync after reordered patch", func(t *testing.T) {
+		// a patch of a new flag older than the full data
+		var all map[string]interface{}
+		require.NoError(t, json.Unmarshal(jsonBytes, &all))
+		allData := all["data"].(map[string]interface{})
+		flag := allData["featureFlags"].([]interface{})[0].(map[string]interface{})
+		flag["id"], flag["key"], flag["updatedAt"] = "ff-delayed", "ff-delayed", "2000-01-01T00:00:00Z"
+		allData["eventType"], allData["featureFlags"], allData["segments"] = "patch", []interface{}{flag}, []interface{}{}
+		patchBytes, err := json.Marshal(all)
+		require.NoError(t, err)
+
+		var lock sync.Mutex
+		var timestamps, etags []string
+		polling, dataUpdater, stop := newTestPolling(t, func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			timestamps = append(timestamps, r.URL.Query().Get("timestamp"))
+			etags = append(etags, r.Header.Get("If-None-Match"))
+			n := len(timestamps)
+			lock.Unlock()
+			w.Header().Set("ETag", `"v1"`)
+			if n == 2 {
+				_, _ = w.Write(patchBytes)
+			} else {
+				_, _ = w.Write(jsonBytes)
+			}
+		})
+		defer stop()
+		<-polling.Start()
+		assert.Eventually(t, func() bool {
+			lock.Lock()
+			defer lock.Unlock()
+			return len(timestamps) >= 3
+		}, time.Second, 10*time.Millisecond)
+		assert.Eventually(t, func() bool {
+			return !dataUpdater.ResyncRequested()
+		}, time.Second, 10*time.Millisecond)
+		lock.Lock()
+		defer lock.Unlock()
+		// the full data are requested again after the reordered patch
+		assert.NotEqual(t, "0", timestamps[1])
+		assert.Equal(t, "0", timestamps[2])
+		assert.Equal(t, "", etags[2])
+	})
+}
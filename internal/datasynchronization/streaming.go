@@ -20,7 +20,8 @@ const (
 	authParams                        = "?token=%s&type=server"
 	jsonParsingErrorMsg               = "fb json format is invalid"
 	r2pChFullErrStr                   = "too many sync message in queue, skip the message and restart"
-	pingInterval                      = 10 * time.Second
+	DefaultPingInterval               = 10 * time.Second
+	DefaultPongTimeout                = 10 * time.Second
 	closeTimeOut                      = 10 * time.Second
 	invalidRequestClose               = 4003
 	invalidRequestCloseReason         = "invalid request"
@@ -82,11 +83,21 @@ func newSyncMessage(bytes []byte, err error) *syncMessage {
 	return &syncMessage{data: &all, ok: true}
 }
 
+// wsConnection is a websocket connection and the channel from its read routine to its data process routine,
+// the routines of a lost connection never touch the next one
+type wsConnection struct {
+	conn    *websocket.Conn
+	r2pChan chan *syncMessage
+}
+
 type Streaming struct {
 	maxRetryTimes int64
 	context       Context
 	dataUpdater   DataUpdater
-	strategy      *BackoffAndJitterStrategy
+	retryPolicy   RetryPolicy
+	pingInterval  time.Duration
+	// the connection is considered as lost if no message, a pong at least, is received in the ping interval plus this timeout
+	pongTimeout time.Duration
 	// start actions should call only one time
 	startOnce sync.Once
 	// ready actions should call only one time
@@ -106,16 +117,20 @@ type Streaming struct {
 	wsConnected      bool
 	conn             *websocket.Conn
 	connRetryCounter int64
-	pingScheduler    *time.Ticker
-	r2pChan          chan *syncMessage
 }
 
-func NewStreaming(context Context, dataUpdater DataUpdater, firstRetryDelay time.Duration, maxRetryTimes int) *Streaming {
+// NewStreaming creates a Streaming, the retry policy should not be shared with other data synchronizers.
+//
+// A ping is sent every ping interval, the connection is closed and reconnected if the server doesn't answer
+// in the pong timeout.
+func NewStreaming(context Context, dataUpdater DataUpdater, retryPolicy RetryPolicy, maxRetryTimes int, pingInterval time.Duration, pongTimeout time.Duration) *Streaming {
 	return &Streaming{
 		context:       context,
 		dataUpdater:   dataUpdater,
 		maxRetryTimes: int64(maxRetryTimes),
-		strategy:      NewWithFirstRetryDelay(firstRetryDelay),
+		retryPolicy:   retryPolicy,
+		pingInterval:  pingInterval,
+		pongTimeout:   pongTimeout,
 		readyCh:       make(chan struct{}),
 		closeCh:       make(chan struct{}),
 	}
@@ -124,7 +139,9 @@ func NewStreaming(context Context, dataUpdater DataUpdater, firstRetryDelay time
 func (s *Streaming) Close() error {
 	s.closeOnce.Do(func() {
 		log.LogInfo("FB GO SDK: streaming is stopping")
+		s.lock.Lock()
 		s.streamClosed = true
+		s.lock.Unlock()
 		close(s.closeCh)
 	})
 	return nil
@@ -139,30 +156,32 @@ func (s *Streaming) IsInitialized() bool {
 func (s *Streaming) Start() <-chan struct{} {
 	s.startOnce.Do(func() {
 		log.LogDebug("Streaming Starting...")
-		atomic.AddInt64(&s.connRetryCounter, 0)
-		s.strategy.SetGoodRunAtNow()
+		updateActiveSource(s.dataUpdater, StreamingSource)
+		atomic.StoreInt64(&s.connRetryCounter, 0)
+		s.retryPolicy.OnSuccess()
 		go s.connectRoutine()
 	})
 	return s.readyCh
 }
 
-func (s *Streaming) sendMessageToServer(messageType int, msg []byte) error {
-	if !s.isWsConnected() {
-		return nil
-	}
+// sendMessageToServer writes a message in the given connection, nothing is sent if it's no longer the current one
+func (s *Streaming) sendMessageToServer(c *wsConnection, messageType int, msg []byte) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	return s.conn.WriteMessage(messageType, msg)
+	if !s.wsConnected || s.conn != c.conn {
+		return nil
+	}
+	return c.conn.WriteMessage(messageType, msg)
 }
 
-func (s *Streaming) sendCloseMessageToServer(closeCode int, closeText string) error {
-	return s.sendMessageToServer(websocket.CloseMessage, websocket.FormatCloseMessage(closeCode, closeText))
+func (s *Streaming) sendCloseMessageToServer(c *wsConnection, closeCode int, closeText string) error {
+	return s.sendMessageToServer(c, websocket.CloseMessage, websocket.FormatCloseMessage(closeCode, closeText))
 }
 
-func (s *Streaming) isWsConnected() bool {
+func (s *Streaming) isClosed() bool {
 	s.lock.RLock()
 	defer s.lock.RUnlock()
-	return s.conn != nil && s.wsConnected
+	return s.streamClosed
 }
 
 func (s *Streaming) noMoreReconnect() {
@@ -174,25 +193,21 @@ func (s *Streaming) noMoreReconnect() {
 	s.lock.Unlock()
 }
 
-func (s *Streaming) clean() {
-	if !s.isWsConnected() {
-		return
-	}
+// clean releases the resources of a connection, it's called only once by the read routine, the only sender of r2pChan
+func (s *Streaming) clean(c *wsConnection) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	s.wsConnected = false
-	_ = s.conn.Close()
-	if s.r2pChan != nil {
-		close(s.r2pChan)
+	if s.conn == c.conn {
+		s.wsConnected = false
 	}
+	_ = c.conn.Close()
+	close(c.r2pChan)
 }
 
-func (s *Streaming) reconnect() {
+func (s *Streaming) reconnect(c *wsConnection) {
 	// release resources
-	s.clean()
-	s.lock.RLock()
-	defer s.lock.RUnlock()
-	if s.streamClosed {
+	s.clean(c)
+	if s.isClosed() {
 		log.LogDebug("force to quit, no more reconnect")
 		return
 	}
@@ -216,60 +231,65 @@ func (s *Streaming) onClose(code int, _ string) error {
 	return nil
 }
 
-func (s *Streaming) onPing() error {
-	return s.sendMessageToServer(websocket.TextMessage, []byte(data.DefaultPingMessage))
+func (s *Streaming) onPing(c *wsConnection) error {
+	return s.sendMessageToServer(c, websocket.TextMessage, []byte(data.DefaultPingMessage))
 }
 
-func (s *Streaming) onOpen() error {
-	if !s.isWsConnected() {
-		return nil
-	}
+func (s *Streaming) onOpen(c *wsConnection) error {
 	log.LogDebug("Ask Data Updating")
 	createJson := func(version int64) []byte {
 		return []byte(fmt.Sprintf(data.DefaultSyncMessage, version))
 	}
-	var bytes []byte
-	if s.dataUpdater.StorageInitialized() {
-		bytes = createJson(s.dataUpdater.GetVersion())
-	} else {
-		bytes = createJson(0)
-	}
-	return s.sendMessageToServer(websocket.TextMessage, bytes)
+	return s.sendMessageToServer(c, websocket.TextMessage, createJson(syncVersion(s.dataUpdater)))
 }
 
-func (s *Streaming) onDataProcess(allData *data.All) bool {
+func (s *Streaming) onDataProcess(c *wsConnection, allData *data.All) bool {
 	log.LogDebug("Streaming WebSocket is processing data")
-	newData := allData.Data.ToStorageType()
-	var success bool = true
-	switch allData.Data.EventType {
-	case data.FullOp:
-		success = s.dataUpdater.Init(newData, allData.Data.GetTimestamp())
-	case data.PatchOp:
-	LOOP:
-		for cat, items := range newData {
-			for key, item := range items {
-				if !s.dataUpdater.Upsert(cat, key, item, item.GetTimestamp()) {
-					success = false
-					break LOOP
-				}
-			}
-		}
-	}
+	success := applyData(s.dataUpdater, allData)
 	if success {
 		s.readyOnce.Do(func() {
+			s.lock.Lock()
 			s.initialized = true
+			s.lock.Unlock()
 			close(s.readyCh)
 		})
 		log.LogDebug("processing data is well done")
 		s.dataUpdater.UpdateStatus(OKState())
+		if resyncRequested(s.dataUpdater) {
+			// ask the full data again in the same connection
+			if err := s.onOpen(c); err != nil {
+				log.LogWarn("FB GO SDK: failed to request the full data: %v", err)
+			}
+		}
+	}
+	return success
+}
+
+// syncVersion returns the version since which the data are requested, 0 to request the full data
+func syncVersion(dataUpdater DataUpdater) int64 {
+	if !dataUpdater.StorageInitialized() || resyncRequested(dataUpdater) {
+		return 0
+	}
+	return dataUpdater.GetVersion()
+}
 
+// applyData pushes the full or patch data into the storage, returns false if the data updater fails
+func applyData(dataUpdater DataUpdater, allData *data.All) bool {
+	newData := allData.Data.ToStorageType()
+	var success bool = true
+	switch allData.Data.EventType {
+	case data.FullOp:
+		success = dataUpdater.Init(newData, allData.Data.GetTimestamp())
+	case data.PatchOp:
+		// the items of a patch are applied together, a flag is never visible without the segments it references
+		success = upsertBatch(dataUpdater, newData, allData.Data.GetTimestamp())
 	}
 	return success
 }
 
 func (s *Streaming) connectRoutine() {
 	log.LogDebug("connection go routine is starting")
-	for s.connRetryCounter <= s.maxRetryTimes && !s.streamClosed {
+	for !s.isClosed() {
 		network := s.context.GetNetwork()
 		dialer := network.GetWebsocketClient().(*websocket.Dialer)
 		streamingUri := s.context.GetStreamingUri()
@@ -278,8 +298,10 @@ func (s *Streaming) connectRoutine() {
 		url := fmt.Sprintf(urlFormat, token)
 		conn, resp, err := dialer.Dial(url, network.GetHeaders(nil))
 		if err != nil {
+			failure := ConnectionFailure{Err: err}
 			if resp != nil {
 				log.LogDebug("Err in connecting ws server, http code = %v", resp.StatusCode)
+				failure.StatusCode = resp.StatusCode
 			}
 			if err.Error() == invalidUrl {
 				log.LogError("FB GO SDK: invalid url: %s", streamingUri)
@@ -293,39 +315,71 @@ func (s *Streaming) connectRoutine() {
 				s.noMoreReconnect()
 				return
 			}
+			delayToReconnect, retry := s.retryPolicy.OnFailure(failure)
+			if !retry {
+				log.LogError("FB GO SDK: Streaming Websocket stops retrying after the error: %s", err.Error())
+				errorType := NetworkError
+				if failure.IsAuthFailure() {
+					errorType = RequestInvalidError
+				}
+				s.dataUpdater.UpdateStatus(ErrorOFFState(errorType, err.Error()))
+				s.noMoreReconnect()
+				return
+			}
+			if failures := atomic.AddInt64(&s.connRetryCounter, 1); failures > s.maxRetryTimes {
+				log.LogError("FB GO SDK: Streaming Websocket stops retrying after %d failed connections: %s", failures, err.Error())
+				s.dataUpdater.UpdateStatus(ErrorOFFState(NetworkError, err.Error()))
+				s.noMoreReconnect()
+				return
+			}
 			s.dataUpdater.UpdateStatus(INTERRUPTEDState(NetworkError, err.Error()))
-			delayToReconnect := s.strategy.NextDelay()
 			log.LogError("FB GO SDK: Streaming Websocket network error  : %s, try to reconnect...", err.Error())
-			time.Sleep(delayToReconnect)
+			select {
+			case <-time.After(delayToReconnect):
+			case <-s.closeCh:
+				log.LogDebug("force to quit, no more reconnect")
+				return
+			}
 			continue
 		}
 		log.LogDebug("ws conn is done")
-		s.strategy.SetGoodRunAtNow()
+		atomic.StoreInt64(&s.connRetryCounter, 0)
+		s.retryPolicy.OnSuccess()
+		c := &wsConnection{conn: conn, r2pChan: make(chan *syncMessage, R2PChCap)}
+		conn.SetCloseHandler(s.onClose)
+		s.extendReadDeadline(c)
 		s.lock.Lock()
 		s.wsConnected = true
 		s.conn = conn
-		s.conn.SetCloseHandler(s.onClose)
-		s.r2pChan = make(chan *syncMessage, R2PChCap)
 		s.lock.Unlock()
-		_ = s.onOpen()
-		go s.readRoutine()
-		go s.dataProcessRoutine()
+		_ = s.onOpen(c)
+		go s.readRoutine(c)
+		go s.dataProcessRoutine(c)
 		log.LogDebug("connection is completed, go routine is over")
 		return
 	}
 }
 
-func (s *Streaming) readRoutine() (isConnect bool) {
-	if !s.isWsConnected() {
-		return
-	}
+// extendReadDeadline gives the server the ping interval plus the pong timeout to send the next message,
+// a half-open connection is detected by the read timeout
+func (s *Streaming) extendReadDeadline(c *wsConnection) {
+	_ = c.conn.SetReadDeadline(time.Now().Add(s.pingInterval + s.pongTimeout))
+}
+
+func (s *Streaming) readRoutine(c *wsConnection) {
 	defer func() {
 		log.LogDebug("read go routine is over, reconnect or exit")
-		s.reconnect()
+		s.reconnect(c)
 	}()
 	log.LogDebug("read go routine is starting")
 	for {
-		_, jsonBytes, err := s.conn.ReadMessage()
+		_, jsonBytes, err := c.conn.ReadMessage()
+		if err == nil {
+			s.extendReadDeadline(c)
+		} else if e, ok := err.(net.Error); ok && e.Timeout() {
+			log.LogWarn("FB GO SDK: Streaming WebSocket receives no pong in %v, the connection is lost", s.pongTimeout)
+			err = fmt.Errorf("no pong received in %v", s.pongTimeout)
+		}
 		msg := newSyncMessage(jsonBytes, err)
 		// ignore pong message
 		if msg == nil {
@@ -349,7 +403,7 @@ func (s *Streaming) readRoutine() (isConnect bool) {
 		}
 		// the return msg is ok, other close code, or other err, notify data process routine
 		select {
-		case s.r2pChan <- msg:
+		case c.r2pChan <- msg:
 		default:
 			log.LogDebug(r2pChFullErrStr)
 			s.dataUpdater.UpdateStatus(INTERRUPTEDState(UnknownError, r2pChFullErrStr))
@@ -362,17 +416,14 @@ func (s *Streaming) readRoutine() (isConnect bool) {
 	}
 }
 
-func (s *Streaming) dataProcessRoutine() {
-	if !s.wsConnected {
-		return
-	}
+func (s *Streaming) dataProcessRoutine(c *wsConnection) {
 	log.LogDebug("data process go routine is starting")
 	// start ping scheduler, stop it at quiting the routine
 	log.LogDebug("ping ticker is starting")
-	s.pingScheduler = time.NewTicker(pingInterval)
+	pingScheduler := time.NewTicker(s.pingInterval)
 	defer func() {
 		log.LogDebug("ping ticker is over")
-		s.pingScheduler.Stop()
+		pingScheduler.Stop()
 	}()
 
 	// to listen data to process,
@@ -380,17 +431,17 @@ func (s *Streaming) dataProcessRoutine() {
 	// and close signal to quit from the streaming
 	for {
 		select {
-		case t := <-s.pingScheduler.C:
+		case t := <-pingScheduler.C:
 			log.LogTrace("ping in %s", t)
-			_ = s.onPing()
-		case syncMsg, ok := <-s.r2pChan:
+			_ = s.onPing(c)
+		case syncMsg, ok := <-c.r2pChan:
 			if !ok {
 				log.LogWarn("quit the routine by error or close, maybe reconnect later")
 				return
 			}
-			if syncMsg.ok && !s.onDataProcess(syncMsg.data) {
+			if syncMsg.ok && !s.onDataProcess(c, syncMsg.data) {
 				// data sync failed, should to reconnect to server
-				_ = s.sendCloseMessageToServer(websocket.CloseGoingAway, CloseAndThenReconnByDatasyncError)
+				_ = s.sendCloseMessageToServer(c, websocket.CloseGoingAway, CloseAndThenReconnByDatasyncError)
 			} else if syncMsg.isOtherErr {
 				// handle reconnect-able error
 				log.LogWarn("FB GO SDK: Streaming WbSocket will reconnect because of %v", syncMsg.err.Error())
@@ -400,14 +451,14 @@ func (s *Streaming) dataProcessRoutine() {
 			log.LogDebug("force to close streaming because of SDK quit")
 			// Cleanly close the connection by sending a close message and then
 			// waiting (with timeout) for the server to close the connection.
-			err := s.sendCloseMessageToServer(websocket.CloseNormalClosure, "")
+			err := s.sendCloseMessageToServer(c, websocket.CloseNormalClosure, "")
 			if err != nil {
 				log.LogError("FB GO SDK: unknown error in closing streaming, %v", err.Error())
 				s.dataUpdater.UpdateStatus(ErrorOFFState(UnknownError, err.Error()))
 				return
 			}
 			select {
-			case <-s.r2pChan:
+			case <-c.r2pChan:
 				log.LogDebug("data process go routine is over")
 			case <-time.After(closeTimeOut):
 				log.LogWarn("time out in closing streaming, force to quit")
@@ -0,0 +1,163 @@
+package datasynchronization
+
+import (
+	"encoding/json"
+	"github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal"
+	"github.com/featbit/featbit-go-sdk/internal/datastorage"
+	"github.com/featbit/featbit-go-sdk/internal/dataupdating"
+	"github.com/featbit/featbit-go-sdk/internal/types/data"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestStreaming(t *testing.T, handler http.Handler, retryPolicy interfaces.RetryPolicy, pingInterval time.Duration, pongTimeout time.Duration) (*Streaming, *dataupdating.DataUpdaterImpl, func()) {
+	server := httptest.NewServer(handler)
+	streamingUrl := "ws" + strings.TrimPrefix(server.URL, "http")
+	ctx, err := internal.FromConfig(fakeEnvSecret, streamingUrl, server.URL, networkFactory{})
+	require.NoError(t, err)
+	dataUpdater := dataupdating.NewDataUpdaterImpl(datastorage.NewInMemoryDataStorage())
+	streaming := NewStreaming(ctx, dataUpdater, retryPolicy, math.MaxInt32, pingInterval, pongTimeout)
+	return streaming, dataUpdater, func() {
+		_ = streaming.Close()
+		server.Close()
+	}
+}
+
+func TestStreamingRetryPolicy(t *testing.T) {
+	t.Run("stop retrying after auth failures", func(t *testing.T) {
+		var attempts int32
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&attempts, 1)
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+		retryPolicy := NewCircuitBreakerRetryPolicy(NewBackoffAndJitterStrategy(10*time.Millisecond, 10*time.Millisecond, time.Minute, 0), 3)
+		streaming, dataUpdater, closeFunc := newTestStreaming(t, handler, retryPolicy, DefaultPingInterval, DefaultPongTimeout)
+		defer closeFunc()
+		select {
+		case <-streaming.Start():
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "streaming should stop retrying")
+		}
+		assert.False(t, streaming.IsInitialized())
+		assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+		status := dataupdating.NewDataUpdateStatusProviderImpl(dataUpdater)
+		assert.Equal(t, interfaces.OFF, status.GetCurrentState().StateType)
+		assert.Equal(t, interfaces.RequestInvalidError, status.GetCurrentState().ErrorTrack.ErrorType)
+	})
+	t.Run("keep retrying other failures", func(t *testing.T) {
+		var attempts int32
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&attempts, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})
+		retryPolicy := NewCircuitBreakerRetryPolicy(NewBackoffAndJitterStrategy(10*time.Millisecond, 10*time.Millisecond, time.Minute, 0), 3)
+		streaming, dataUpdater, closeFunc := newTestStreaming(t, handler, retryPolicy, DefaultPingInterval, DefaultPongTimeout)
+		defer closeFunc()
+		streaming.Start()
+		assert.Eventually(t, func() bool { return atomic.LoadInt32(&attempts) > 5 }, 5*time.Second, 10*time.Millisecond)
+		status := dataupdating.NewDataUpdateStatusProviderImpl(dataUpdater)
+		assert.NotEqual(t, interfaces.OFF, status.GetCurrentState().StateType)
+		assert.Equal(t, interfaces.NetworkError, status.GetCurrentState().ErrorTrack.ErrorType)
+	})
+	t.Run("stop retrying after the max retry times", func(t *testing.T) {
+		var attempts int32
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&attempts, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})
+		retryPolicy := NewCircuitBreakerRetryPolicy(NewBackoffAndJitterStrategy(10*time.Millisecond, 10*time.Millisecond, time.Minute, 0), 3)
+		streaming, dataUpdater, closeFunc := newTestStreaming(t, handler, retryPolicy, DefaultPingInterval, DefaultPongTimeout)
+		defer closeFunc()
+		streaming.maxRetryTimes = 2
+		select {
+		case <-streaming.Start():
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "streaming should stop retrying")
+		}
+		assert.False(t, streaming.IsInitialized())
+		assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+		status := dataupdating.NewDataUpdateStatusProviderImpl(dataUpdater)
+		assert.Equal(t, interfaces.OFF, status.GetCurrentState().StateType)
+		assert.Equal(t, interfaces.NetworkError, status.GetCurrentState().ErrorTrack.ErrorType)
+	})
+}
+
+// testWebsocketServer answers the data-sync requests with the data and the pings with a pong, unless it's silent
+type testWebsocketServer struct {
+	data        []byte
+	connections int32
+	silent      int32
+}
+
+func (ws *testWebsocketServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	atomic.AddInt32(&ws.connections, 1)
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if atomic.LoadInt32(&ws.silent) == 1 {
+			continue
+		}
+		var m data.Message
+		_ = json.Unmarshal(msg, &m)
+		switch m.MessageType {
+		case "data-sync":
+			_ = conn.WriteMessage(websocket.TextMessage, ws.data)
+		case "ping":
+			_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"messageType":"pong","data":{}}`))
+		}
+	}
+}
+
+func (ws *testWebsocketServer) getConnections() int32 {
+	return atomic.LoadInt32(&ws.connections)
+}
+
+func TestStreamingPongTimeout(t *testing.T) {
+	jsonBytes := loadTestData(t)
+
+	t.Run("pongs keep the connection", func(t *testing.T) {
+		ws := &testWebsocketServer{data: jsonBytes}
+		retryPolicy := NewBackoffAndJitterStrategy(10*time.Millisecond, 10*time.Millisecond, time.Minute, 0)
+		streaming, dataUpdater, closeFunc := newTestStreaming(t, ws, retryPolicy, 20*time.Millisecond, 50*time.Millisecond)
+		defer closeFunc()
+		status := dataupdating.NewDataUpdateStatusProviderImpl(dataUpdater)
+		streaming.Start()
+		require.True(t, status.WaitForOKState(5*time.Second), status.GetCurrentState().String())
+		time.Sleep(300 * time.Millisecond)
+		assert.Equal(t, interfaces.OK, status.GetCurrentState().StateType)
+		assert.Equal(t, int32(1), ws.getConnections())
+	})
+	t.Run("reconnect if no pong", func(t *testing.T) {
+		ws := &testWebsocketServer{data: jsonBytes}
+		retryPolicy := NewBackoffAndJitterStrategy(10*time.Millisecond, 10*time.Millisecond, time.Minute, 0)
+		streaming, dataUpdater, closeFunc := newTestStreaming(t, ws, retryPolicy, 20*time.Millisecond, 50*time.Millisecond)
+		defer closeFunc()
+		status := dataupdating.NewDataUpdateStatusProviderImpl(dataUpdater)
+		streaming.Start()
+		require.True(t, status.WaitForOKState(5*time.Second), status.GetCurrentState().String())
+		// the server stops answering, such as a half-open connection
+		atomic.StoreInt32(&ws.silent, 1)
+		assert.Eventually(t, func() bool { return ws.getConnections() >= 2 }, 5*time.Second, 10*time.Millisecond)
+		assert.Eventually(t, func() bool {
+			state := status.GetCurrentState()
+			return state.StateType == interfaces.INTERRUPTED && state.ErrorTrack.ErrorType == interfaces.WebsocketError
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
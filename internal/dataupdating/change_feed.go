@@ -0,0 +1,203 @@
+package dataupdating
+
+import (
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/types/data"
+	"sync"
+	"sync/atomic"
+)
+
+const DefaultChangeBufferSize = 100
+
+type changeSubscription struct {
+	feed      *changeFeed
+	events    chan ChangeEvent
+	overflow  chan struct{}
+	dropped   uint64
+	closeOnce sync.Once
+}
+
+func (c *changeSubscription) Events() <-chan ChangeEvent {
+	return c.events
+}
+
+func (c *changeSubscription) Overflow() <-chan struct{} {
+	return c.overflow
+}
+
+func (c *changeSubscription) Dropped() uint64 {
+	return atomic.LoadUint64(&c.dropped)
+}
+
+func (c *changeSubscription) Cancel() {
+	c.feed.remove(c)
+}
+
+// publish never blocks, the event is dropped if the buffer is full
+func (c *changeSubscription) publish(event ChangeEvent) {
+	select {
+	case c.events <- event:
+	default:
+		atomic.AddUint64(&c.dropped, 1)
+		select {
+		case c.overflow <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (c *changeSubscription) close() {
+	c.closeOnce.Do(func() {
+		close(c.events)
+		close(c.overflow)
+	})
+}
+
+// changeFeed publishes the changes of the storage to the subscriptions
+type changeFeed struct {
+	lock          sync.RWMutex
+	subscriptions []*changeSubscription
+}
+
+func (f *changeFeed) subscribe(bufferSize int) *changeSubscription {
+	if bufferSize <= 0 {
+		bufferSize = DefaultChangeBufferSize
+	}
+	s := &changeSubscription{
+		feed:     f,
+		events:   make(chan ChangeEvent, bufferSize),
+		overflow: make(chan struct{}, 1),
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.subscriptions = append(f.subscriptions, s)
+	return s
+}
+
+func (f *changeFeed) remove(s *changeSubscription) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for i, sub := range f.subscriptions {
+		if sub == s {
+			f.subscriptions = append(f.subscriptions[:i], f.subscriptions[i+1:]...)
+			s.close()
+			break
+		}
+	}
+}
+
+func (f *changeFeed) hasSubscriptions() bool {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return len(f.subscriptions) > 0
+}
+
+func (f *changeFeed) publish(events []ChangeEvent) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	for _, event := range events {
+		for _, s := range f.subscriptions {
+			s.publish(event)
+		}
+	}
+}
+
+func (f *changeFeed) close() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for _, s := range f.subscriptions {
+		s.close()
+	}
+	f.subscriptions = nil
+}
+
+// changeScope is the items read before and after a write to find the changes: all the items of a category if the keys are nil
+type changeScope map[Category][]string
+
+// fullScope returns the scope of Init: the categories of the data and the built-in ones
+func fullScope(allData map[Category]map[string]Item) changeScope {
+	scope := make(changeScope, len(allData)+len(data.AllCats))
+	for _, cat := range data.AllCats {
+		scope[cat] = nil
+	}
+	for cat := range allData {
+		scope[cat] = nil
+	}
+	return scope
+}
+
+// patchScope returns the scope of the upserted items
+func patchScope(items map[Category]map[string]Item) changeScope {
+	scope := make(changeScope, len(items))
+	for cat, catItems := range items {
+		keys := make([]string, 0, len(catItems))
+		for key := range catItems {
+			keys = append(keys, key)
+		}
+		scope[cat] = keys
+	}
+	return scope
+}
+
+func readScope(storage DataStorage, scope changeScope) map[Category]map[string]Item {
+	res := make(map[Category]map[string]Item, len(scope))
+	for cat, keys := range scope {
+		if keys == nil {
+			items, _ := storage.GetAll(cat)
+			res[cat] = items
+			continue
+		}
+		items := make(map[string]Item, len(keys))
+		for _, key := range keys {
+			if item, _ := storage.Get(cat, key); item != nil {
+				items[key] = item
+			}
+		}
+		res[cat] = items
+	}
+	return res
+}
+
+// diff returns the changes between two reads of the same scope
+func diff(before, after map[Category]map[string]Item, version int64) []ChangeEvent {
+	var events []ChangeEvent
+	for cat, newItems := range after {
+		oldItems := before[cat]
+		for key, newItem := range newItems {
+			oldItem, ok := oldItems[key]
+			if !ok || oldItem.GetTimestamp() != newItem.GetTimestamp() {
+				events = append(events, ChangeEvent{Category: cat, Key: key, OldItem: oldItem, NewItem: newItem, Version: version})
+			}
+		}
+		for key, oldItem := range oldItems {
+			if _, ok := newItems[key]; !ok {
+				events = append(events, ChangeEvent{Category: cat, Key: key, OldItem: oldItem, Archived: true, Version: version})
+			}
+		}
+	}
+	return events
+}
+
+// trackChanges runs a write of the storage, the changes in the scope are recorded in the history if it's enabled,
+// and published if there are any subscriptions
+func (d *DataUpdaterImpl) trackChanges(scope changeScope, version int64, write func() error) error {
+	if d.history == nil && !d.feed.hasSubscriptions() {
+		return write()
+	}
+	before := readScope(d.storage, scope)
+	if err := write(); err != nil {
+		return err
+	}
+	events := diff(before, readScope(d.storage, scope), version)
+	if d.history != nil {
+		d.history.record(events)
+	}
+	d.feed.publish(events)
+	return nil
+}
+
+// SubscribeChanges subscribes to the changes of the storage made by the DataSynchronizer,
+// the events are buffered with the given size, DefaultChangeBufferSize if it's not positive.
+func (d *DataUpdaterImpl) SubscribeChanges(bufferSize int) ChangeSubscription {
+	return d.feed.subscribe(bufferSize)
+}
@@ -0,0 +1,99 @@
+package dataupdating
+
+import (
+	"github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/datastorage"
+	"github.com/featbit/featbit-go-sdk/internal/types/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func receiveEvents(sub interfaces.ChangeSubscription) map[string]interfaces.ChangeEvent {
+	events := make(map[string]interfaces.ChangeEvent)
+	for {
+		select {
+		case event := <-sub.Events():
+			events[event.Key] = event
+		default:
+			return events
+		}
+	}
+}
+
+func TestSubscribeChanges(t *testing.T) {
+	t.Run("init and upsert", func(t *testing.T) {
+		dataUpdater := NewDataUpdaterImpl(datastorage.NewInMemoryDataStorage())
+		sub := dataUpdater.SubscribeChanges(0)
+		defer sub.Cancel()
+
+		kept, changed, removed := data.NewTestItem(false), data.NewTestItem(false), data.NewTestItem(false)
+		items := map[string]interfaces.Item{kept.GetId(): kept, changed.GetId(): changed, removed.GetId(): removed}
+		require.True(t, dataUpdater.Init(map[interfaces.Category]map[string]interfaces.Item{data.Datatests: items}, removed.GetTimestamp()))
+		events := receiveEvents(sub)
+		assert.Equal(t, 3, len(events))
+		assert.Nil(t, events[kept.GetId()].OldItem)
+		assert.Equal(t, kept, events[kept.GetId()].NewItem)
+		assert.Equal(t, data.Datatests, events[kept.GetId()].Category)
+		assert.Equal(t, removed.GetTimestamp(), events[kept.GetId()].Version)
+
+		newItem := data.NewTestItem(false)
+		items = map[string]interfaces.Item{kept.GetId(): kept, changed.GetId(): newItem}
+		require.True(t, dataUpdater.Init(map[interfaces.Category]map[string]interfaces.Item{data.Datatests: items}, newItem.GetTimestamp()))
+		events = receiveEvents(sub)
+		assert.Equal(t, 2, len(events), "the unchanged item has no event")
+		assert.Equal(t, changed, events[changed.GetId()].OldItem)
+		assert.Equal(t, newItem, events[changed.GetId()].NewItem)
+		assert.False(t, events[changed.GetId()].Archived)
+		assert.Equal(t, removed, events[removed.GetId()].OldItem)
+		assert.Nil(t, events[removed.GetId()].NewItem)
+		assert.True(t, events[removed.GetId()].Archived)
+
+		archived := data.NewTestItem(true)
+		require.True(t, dataUpdater.Upsert(data.Datatests, kept.GetId(), archived, archived.GetTimestamp()))
+		events = receiveEvents(sub)
+		assert.Equal(t, 1, len(events))
+		assert.Equal(t, kept, events[kept.GetId()].OldItem)
+		assert.True(t, events[kept.GetId()].Archived)
+		assert.Equal(t, archived.GetTimestamp(), events[kept.GetId()].Version)
+
+		// an ignored upsert has no event
+		dataUpdater.Upsert(data.Datatests, changed.GetId(), changed, changed.GetTimestamp())
+		assert.Empty(t, receiveEvents(sub))
+		assert.Equal(t, uint64(0), sub.Dropped())
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		dataUpdater := NewDataUpdaterImpl(datastorage.NewInMemoryDataStorage())
+		sub := dataUpdater.SubscribeChanges(1)
+		defer sub.Cancel()
+		for i := 0; i < 3; i++ {
+			item := data.NewTestItem(false)
+			require.True(t, dataUpdater.Upsert(data.Datatests, item.GetId(), item, item.GetTimestamp()))
+		}
+		assert.Equal(t, uint64(2), sub.Dropped())
+		select {
+		case <-sub.Overflow():
+		default:
+			assert.Fail(t, "overflow is not signalled")
+		}
+		select {
+		case <-sub.Overflow():
+			assert.Fail(t, "overflow signals are not coalesced")
+		default:
+		}
+		assert.Equal(t, 1, len(receiveEvents(sub)))
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		dataUpdater := NewDataUpdaterImpl(datastorage.NewInMemoryDataStorage())
+		sub := dataUpdater.SubscribeChanges(0)
+		sub.Cancel()
+		sub.Cancel()
+		_, ok := <-sub.Events()
+		assert.False(t, ok)
+		assert.False(t, dataUpdater.feed.hasSubscriptions())
+		item := data.NewTestItem(false)
+		assert.True(t, dataUpdater.Upsert(data.Datatests, item.GetId(), item, item.GetTimestamp()))
+	})
+}
@@ -0,0 +1,32 @@
+package dataupdating
+
+import (
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+)
+
+type DataStorageStatusProviderImpl struct {
+	dataUpdaterImpl *DataUpdaterImpl
+}
+
+func (d DataStorageStatusProviderImpl) GetStatus() DataStorageStatus {
+	if d.dataUpdaterImpl.monitor == nil {
+		return DataStorageStatus{Available: true, Since: d.dataUpdaterImpl.createdAt}
+	}
+	return d.dataUpdaterImpl.monitor.getStatus()
+}
+
+func (d DataStorageStatusProviderImpl) IsStatusMonitoringEnabled() bool {
+	return d.dataUpdaterImpl.monitor != nil
+}
+
+func (d DataStorageStatusProviderImpl) AddStatusListener() (<-chan DataStorageStatus, func()) {
+	if d.dataUpdaterImpl.monitor == nil {
+		// the status never changes
+		return make(chan DataStorageStatus), func() {}
+	}
+	return d.dataUpdaterImpl.monitor.addListener()
+}
+
+func NewDataStorageStatusProviderImpl(dataUpdaterImpl *DataUpdaterImpl) DataStorageStatusProviderImpl {
+	return DataStorageStatusProviderImpl{dataUpdaterImpl: dataUpdaterImpl}
+}
@@ -0,0 +1,153 @@
+package dataupdating
+
+import (
+	"fmt"
+	"github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/datastorage"
+	"github.com/featbit/featbit-go-sdk/internal/types/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakePersistentStorage is an in-memory storage that could be made unavailable
+type fakePersistentStorage struct {
+	*datastorage.InMemoryDataStorage
+	unavailable int32
+}
+
+func (f *fakePersistentStorage) err() error {
+	if atomic.LoadInt32(&f.unavailable) == 1 {
+		return fmt.Errorf("connection refused")
+	}
+	return nil
+}
+
+func (f *fakePersistentStorage) setAvailable(available bool) {
+	if available {
+		atomic.StoreInt32(&f.unavailable, 0)
+	} else {
+		atomic.StoreInt32(&f.unavailable, 1)
+	}
+}
+
+func (f *fakePersistentStorage) Init(allData map[interfaces.Category]map[string]interfaces.Item, version int64) error {
+	if err := f.err(); err != nil {
+		return err
+	}
+	return f.InMemoryDataStorage.Init(allData, version)
+}
+
+func (f *fakePersistentStorage) Upsert(category interfaces.Category, key string, item interfaces.Item, version int64) (bool, error) {
+	if err := f.err(); err != nil {
+		return false, err
+	}
+	return f.InMemoryDataStorage.Upsert(category, key, item, version)
+}
+
+func (f *fakePersistentStorage) CheckAvailability() error {
+	return f.err()
+}
+
+// probedPersistentStorage is a fakePersistentStorage that sets the interval of its probes
+type probedPersistentStorage struct {
+	*fakePersistentStorage
+	probeInterval time.Duration
+}
+
+func (p *probedPersistentStorage) ProbeInterval() time.Duration {
+	return p.probeInterval
+}
+
+func waitForStatus(t *testing.T, ch <-chan interfaces.DataStorageStatus, available bool) interfaces.DataStorageStatus {
+	for {
+		select {
+		case status := <-ch:
+			if status.Available == available {
+				return status
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("storage status is not changed to available = %v", available)
+		}
+	}
+}
+
+func TestDataStorageStatusProvider(t *testing.T) {
+	t.Run("not persistent storage", func(t *testing.T) {
+		dataUpdater := NewDataUpdaterImpl(datastorage.NewInMemoryDataStorage())
+		statusProvider := NewDataStorageStatusProviderImpl(dataUpdater)
+		defer dataUpdater.close()
+		assert.False(t, statusProvider.IsStatusMonitoringEnabled())
+		assert.True(t, statusProvider.GetStatus().Available)
+	})
+
+	t.Run("outage and recovery", func(t *testing.T) {
+		storage := &fakePersistentStorage{InMemoryDataStorage: datastorage.NewInMemoryDataStorage()}
+		dataUpdater := newDataUpdaterImpl(storage, 10*time.Millisecond)
+		statusProvider := NewDataStorageStatusProviderImpl(dataUpdater)
+		defer dataUpdater.close()
+		assert.True(t, statusProvider.IsStatusMonitoringEnabled())
+		listener, remove := statusProvider.AddStatusListener()
+		defer remove()
+
+		item1 := data.NewTestItem(false)
+		require.True(t, dataUpdater.Init(map[interfaces.Category]map[string]interfaces.Item{data.Datatests: {item1.GetId(): item1}}, item1.GetTimestamp()))
+		assert.True(t, statusProvider.GetStatus().Available)
+
+		// the update is missed by the storage during the outage
+		storage.setAvailable(false)
+		item2 := data.NewTestItem(false)
+		assert.False(t, dataUpdater.Upsert(data.Datatests, item2.GetId(), item2, item2.GetTimestamp()))
+		status := waitForStatus(t, listener, false)
+		assert.True(t, status.NeedsRefresh)
+		assert.Equal(t, "connection refused", status.LastError)
+		item, _ := storage.Get(data.Datatests, item2.GetId())
+		assert.Nil(t, item)
+
+		// the latest data are written again once the storage is back
+		storage.setAvailable(true)
+		status = waitForStatus(t, listener, true)
+		assert.False(t, status.NeedsRefresh)
+		item, _ = storage.Get(data.Datatests, item2.GetId())
+		assert.Equal(t, item2, item)
+		item, _ = storage.Get(data.Datatests, item1.GetId())
+		assert.Equal(t, item1, item)
+		assert.Equal(t, item2.GetTimestamp(), storage.GetVersion())
+	})
+
+	t.Run("unavailable detected by probing", func(t *testing.T) {
+		storage := &fakePersistentStorage{InMemoryDataStorage: datastorage.NewInMemoryDataStorage()}
+		dataUpdater := newDataUpdaterImpl(storage, 10*time.Millisecond)
+		statusProvider := NewDataStorageStatusProviderImpl(dataUpdater)
+		listener, _ := statusProvider.AddStatusListener()
+
+		storage.setAvailable(false)
+		waitForStatus(t, listener, false)
+		assert.False(t, statusProvider.GetStatus().Available)
+		assert.Error(t, dataUpdater.CheckStorageAvailability())
+		storage.setAvailable(true)
+		waitForStatus(t, listener, true)
+		assert.NoError(t, dataUpdater.CheckStorageAvailability())
+
+		dataUpdater.close()
+		_, ok := <-listener
+		assert.False(t, ok)
+	})
+
+	t.Run("probe interval set by the storage", func(t *testing.T) {
+		storage := &probedPersistentStorage{
+			fakePersistentStorage: &fakePersistentStorage{InMemoryDataStorage: datastorage.NewInMemoryDataStorage()},
+			probeInterval:         5 * time.Millisecond,
+		}
+		dataUpdater := NewDataUpdaterImpl(storage)
+		defer dataUpdater.close()
+		assert.Equal(t, 5*time.Millisecond, dataUpdater.monitor.interval)
+
+		storage.probeInterval = 0
+		defaultUpdater := NewDataUpdaterImpl(storage)
+		defer defaultUpdater.close()
+		assert.Equal(t, defaultStorageProbeInterval, defaultUpdater.monitor.interval)
+	})
+}
@@ -21,6 +21,14 @@ func (d DataUpdateStatusProviderImpl) WaitForOKState(timeout time.Duration) bool
 	return d.dataUpdaterImpl.waitFor(OK, timeout)
 }
 
+func (d DataUpdateStatusProviderImpl) GetActiveSource() string {
+	return d.dataUpdaterImpl.getActiveSource()
+}
+
+func (d DataUpdateStatusProviderImpl) IsStale() bool {
+	return d.dataUpdaterImpl.isStale()
+}
+
 func (d DataUpdateStatusProviderImpl) Close() error {
 	d.dataUpdaterImpl.close()
 	return nil
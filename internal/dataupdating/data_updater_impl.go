@@ -2,6 +2,8 @@ package dataupdating
 
 import (
 	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/datastorage"
+	"github.com/featbit/featbit-go-sdk/internal/types/data"
 	"github.com/featbit/featbit-go-sdk/internal/util/log"
 	"sync"
 	"time"
@@ -12,43 +14,285 @@ const defaultListenerNums = 10
 type DataUpdaterImpl struct {
 	storage      DataStorage
 	currentState State
+	activeSource string
 	lock         sync.Mutex
 	listeners    []chan State
+	dataLock     sync.Mutex
+	subscribers  []chan struct{}
+	createdAt    time.Time
+	// only for a persistent storage: the latest data received by the DataSynchronizer are kept in memory,
+	// and written into the storage again once it recovers from an outage
+	monitor *storageMonitor
+	latest  *datastorage.InMemoryDataStorage
+	// serializes the writes of the storage
+	storageLock sync.Mutex
+	feed        changeFeed
+	history     *History
+	// the latest version received by the DataSynchronizer, and whether the full data are requested again
+	syncLock        sync.Mutex
+	highWaterMark   int64
+	resyncRequested bool
 }
 
 func NewDataUpdaterImpl(storage DataStorage) *DataUpdaterImpl {
-	return &DataUpdaterImpl{storage: storage,
+	return newDataUpdaterImpl(storage, defaultStorageProbeInterval)
+}
+
+func newDataUpdaterImpl(storage DataStorage, probeInterval time.Duration) *DataUpdaterImpl {
+	d := &DataUpdaterImpl{storage: storage,
 		currentState: INITIALIZINGState(),
+		createdAt:    time.Now(),
+	}
+	if persistentStorage, ok := datastorage.Persistent(storage); ok {
+		if probed, ok := persistentStorage.(probedStorage); ok && probed.ProbeInterval() > 0 {
+			probeInterval = probed.ProbeInterval()
+		}
+		d.latest = datastorage.NewInMemoryDataStorage()
+		d.monitor = newStorageMonitor(persistentStorage, probeInterval, d.refreshStorage)
 	}
+	return d
 }
 
 func (d *DataUpdaterImpl) handleErrorFromStorage(errorType string, err error) {
-	log.LogError("FB GO SDK: Data Storage error: {}, DataSynchronizer will attempt to receive the data", err.Error())
+	log.LogError("FB GO SDK: Data Storage error: %v, DataSynchronizer will attempt to receive the data", err)
+	if d.monitor != nil {
+		d.monitor.setUnavailable(err)
+	}
 	d.UpdateStatus(INTERRUPTEDState(errorType, err.Error()))
 }
 
+// refreshStorage writes the latest data received by the DataSynchronizer into the persistent storage,
+// the storage keeps its data if they are newer, such as the data written by another SDK instance.
+func (d *DataUpdaterImpl) refreshStorage() error {
+	d.storageLock.Lock()
+	defer d.storageLock.Unlock()
+	allData, version := d.latest.AllData()
+	if len(allData) == 0 {
+		return nil
+	}
+	if err := d.trackChanges(fullScope(allData), version, func() error {
+		return d.storage.Init(allData, version)
+	}); err != nil {
+		return err
+	}
+	d.notifyDataUpdated()
+	return nil
+}
+
 func (d *DataUpdaterImpl) Init(allDate map[Category]map[string]Item, version int64) bool {
-	if err := d.storage.Init(allDate, version); err != nil {
+	// the full data of the same version don't replace the storage, they are merged to fill the gaps of a resync
+	d.storageLock.Lock()
+	defer d.storageLock.Unlock()
+	merge := d.ResyncRequested() && version <= d.storage.GetVersion()
+	if merge {
+		var err error
+		if allDate, err = d.archiveAbsentItems(allDate, version); err != nil {
+			d.handleErrorFromStorage(DataStorageInitError, err)
+			return false
+		}
+	}
+	if d.latest != nil {
+		if merge {
+			_, _ = d.latest.UpsertBatch(allDate, version)
+		} else {
+			_ = d.latest.Init(allDate, version)
+		}
+	}
+	scope := fullScope(allDate)
+	if merge {
+		scope = patchScope(allDate)
+	}
+	err := d.trackChanges(scope, version, func() error {
+		if merge {
+			_, err := datastorage.UpsertBatch(d.storage, allDate, version)
+			return err
+		}
+		return d.storage.Init(allDate, version)
+	})
+	if err != nil {
 		d.handleErrorFromStorage(DataStorageInitError, err)
 		return false
 	}
+	d.syncLock.Lock()
+	if d.resyncRequested {
+		log.LogInfo("FB GO SDK: the full data are resynchronized, version = %d", version)
+	}
+	d.resyncRequested = false
+	if version > d.highWaterMark {
+		d.highWaterMark = version
+	}
+	d.syncLock.Unlock()
+	d.notifyDataUpdated()
 	return true
 }
 
+// archiveAbsentItems adds to the full data of a resync an archived item for each item of the storage absent from them,
+// the items deleted during the gap are removed, but not the items newer than the full data
+func (d *DataUpdaterImpl) archiveAbsentItems(allData map[Category]map[string]Item, version int64) (map[Category]map[string]Item, error) {
+	stored, _, err := datastorage.AllData(d.storage)
+	if err != nil {
+		return nil, err
+	}
+	merged := make(map[Category]map[string]Item, len(allData))
+	for cat, items := range allData {
+		merged[cat] = make(map[string]Item, len(items))
+		for key, item := range items {
+			merged[cat][key] = item
+		}
+	}
+	for cat, items := range stored {
+		for key, item := range items {
+			if item.IsArchived() || item.GetTimestamp() >= version {
+				continue
+			}
+			if _, ok := merged[cat][key]; ok {
+				continue
+			}
+			if merged[cat] == nil {
+				merged[cat] = make(map[string]Item)
+			}
+			merged[cat][key] = data.NewArchivedItem(key, version)
+		}
+	}
+	return merged, nil
+}
+
+// checkPatch requests a resync if the patch is older than the latest version received, the patches are reordered and
+// some updates could be missed; or if the storage is behind the latest version, the storage has lost some updates.
+// The high-water mark is raised to the version of the patch.
+func (d *DataUpdaterImpl) checkPatch(oldest int64, version int64) {
+	storageVersion := d.storage.GetVersion()
+	d.syncLock.Lock()
+	defer d.syncLock.Unlock()
+	if !d.resyncRequested && d.highWaterMark > 0 {
+		if oldest < d.highWaterMark {
+			log.LogWarn("FB GO SDK: the patch is older than the latest version %d < %d, the full data are requested", oldest, d.highWaterMark)
+			d.resyncRequested = true
+		} else if storageVersion < d.highWaterMark {
+			log.LogWarn("FB GO SDK: the data storage is behind the latest version %d < %d, the full data are requested", storageVersion, d.highWaterMark)
+			d.resyncRequested = true
+		}
+	}
+	if version > d.highWaterMark {
+		d.highWaterMark = version
+	}
+}
+
+func (d *DataUpdaterImpl) ResyncRequested() bool {
+	d.syncLock.Lock()
+	defer d.syncLock.Unlock()
+	return d.resyncRequested
+}
+
 func (d *DataUpdaterImpl) Upsert(category Category, key string, item Item, version int64) bool {
+	if item != nil {
+		d.checkPatch(item.GetTimestamp(), version)
+	}
+	d.storageLock.Lock()
+	defer d.storageLock.Unlock()
+	if d.latest != nil {
+		_, _ = d.latest.Upsert(category, key, item, version)
+	}
 	var ret bool
-	var err error
-	if ret, err = d.storage.Upsert(category, key, item, version); err != nil {
+	scope := changeScope{}
+	if category != nil {
+		scope[category] = []string{key}
+	}
+	err := d.trackChanges(scope, version, func() (err error) {
+		ret, err = d.storage.Upsert(category, key, item, version)
+		return err
+	})
+	if err != nil {
 		d.handleErrorFromStorage(DataStorageUpdateError, err)
 		return false
 	}
+	if ret {
+		d.notifyDataUpdated()
+	}
 	return ret
 }
 
+func (d *DataUpdaterImpl) UpsertBatch(items map[Category]map[string]Item, version int64) bool {
+	oldest := version
+	for _, catItems := range items {
+		for _, item := range catItems {
+			if item != nil && item.GetTimestamp() < oldest {
+				oldest = item.GetTimestamp()
+			}
+		}
+	}
+	d.checkPatch(oldest, version)
+	d.storageLock.Lock()
+	defer d.storageLock.Unlock()
+	if d.latest != nil {
+		_, _ = d.latest.UpsertBatch(items, version)
+	}
+	var ret bool
+	err := d.trackChanges(patchScope(items), version, func() (err error) {
+		ret, err = datastorage.UpsertBatch(d.storage, items, version)
+		return err
+	})
+	if err != nil {
+		d.handleErrorFromStorage(DataStorageUpdateError, err)
+		return false
+	}
+	if ret {
+		d.notifyDataUpdated()
+	}
+	return ret
+}
+
+// SubscribeDataUpdate returns a channel that receives a signal each time the data in storage are changed,
+// and a function to cancel the subscription.
+//
+// The signals are coalesced: the channel is buffered with a size of 1, a subscriber that is busy only receives one
+// signal for all the changes that happened in the meantime.
+func (d *DataUpdaterImpl) SubscribeDataUpdate() (<-chan struct{}, func()) {
+	d.dataLock.Lock()
+	defer d.dataLock.Unlock()
+	subscriber := make(chan struct{}, 1)
+	d.subscribers = append(d.subscribers, subscriber)
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			d.dataLock.Lock()
+			defer d.dataLock.Unlock()
+			for i, ch := range d.subscribers {
+				if ch == subscriber {
+					d.subscribers = append(d.subscribers[:i], d.subscribers[i+1:]...)
+					close(ch)
+					break
+				}
+			}
+		})
+	}
+	return subscriber, cancel
+}
+
+func (d *DataUpdaterImpl) notifyDataUpdated() {
+	d.dataLock.Lock()
+	defer d.dataLock.Unlock()
+	for _, ch := range d.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
 func (d *DataUpdaterImpl) StorageInitialized() bool {
 	return d.storage.IsInitialized()
 }
 
+// CheckStorageAvailability returns an error if the persistent storage can't be reached and updates the storage status,
+// it always returns nil if the storage is not persistent
+func (d *DataUpdaterImpl) CheckStorageAvailability() error {
+	if d.monitor != nil {
+		return d.monitor.probe()
+	}
+	return nil
+}
+
 func (d *DataUpdaterImpl) GetVersion() int64 {
 	return d.storage.GetVersion()
 }
@@ -57,6 +301,12 @@ func (d *DataUpdaterImpl) UpdateStatus(state State) {
 	if state.StateType == "" {
 		return
 	}
+	if state.StateType == OK {
+		// the DataSynchronizer is up-to-date
+		if storage, ok := d.storage.(StaleDataStorage); ok {
+			storage.MarkFresh()
+		}
+	}
 	d.lock.Lock()
 	lastState := d.currentState
 	lastStateSince := d.currentState.StateSince
@@ -76,6 +326,7 @@ func (d *DataUpdaterImpl) UpdateStatus(state State) {
 		StateSince: lastStateSince,
 		ErrorTrack: lastError,
 	}
+	currentState := d.currentState
 
 	var chs []chan State
 	if len(d.listeners) > 0 {
@@ -85,11 +336,35 @@ func (d *DataUpdaterImpl) UpdateStatus(state State) {
 	d.lock.Unlock()
 	// broadcast current state
 	for _, ch := range chs {
-		ch <- d.currentState
+		ch <- currentState
+	}
+}
+
+func (d *DataUpdaterImpl) UpdateActiveSource(source string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.activeSource != source {
+		log.LogInfo("FB GO SDK: data source is switched to %v", source)
+		d.activeSource = source
+	}
+}
+
+func (d *DataUpdaterImpl) getActiveSource() string {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.activeSource
+}
+
+func (d *DataUpdaterImpl) isStale() bool {
+	if storage, ok := d.storage.(StaleDataStorage); ok {
+		return storage.IsStale()
 	}
+	return false
 }
 
 func (d *DataUpdaterImpl) getCurrentState() State {
+	d.lock.Lock()
+	defer d.lock.Unlock()
 	return d.currentState
 }
 
@@ -146,10 +421,20 @@ func (d *DataUpdaterImpl) waitFor(state StateType, timeout time.Duration) bool {
 }
 
 func (d *DataUpdaterImpl) close() {
+	if d.monitor != nil {
+		d.monitor.close()
+	}
+	d.feed.close()
 	d.lock.Lock()
 	defer d.lock.Unlock()
 	for _, listener := range d.listeners {
 		close(listener)
 	}
 	d.listeners = nil
+	d.dataLock.Lock()
+	defer d.dataLock.Unlock()
+	for _, subscriber := range d.subscribers {
+		close(subscriber)
+	}
+	d.subscribers = nil
 }
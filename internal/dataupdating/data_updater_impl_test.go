@@ -6,7 +6,9 @@ import (
 	"github.com/featbit/featbit-go-sdk/internal/datastorage"
 	"github.com/featbit/featbit-go-sdk/internal/types/data"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
 var item1 = data.NewTestItem(false)
@@ -67,3 +69,130 @@ func TestUpsert(t *testing.T) {
 		assert.Equal(t, interfaces.DataStorageUpdateError, dataUpdater.getCurrentState().ErrorTrack.ErrorType)
 	})
 }
+
+func TestUpsertBatch(t *testing.T) {
+	t.Run("upsert batch", func(t *testing.T) {
+		dataStorage := datastorage.NewInMemoryDataStorage()
+		dataUpdater := NewDataUpdaterImpl(dataStorage)
+		item2 := data.NewTestItem(false)
+		items := map[interfaces.Category]map[string]interfaces.Item{data.Datatests: {item1.GetId(): item1, item2.GetId(): item2}}
+		assert.True(t, dataUpdater.UpsertBatch(items, item2.GetTimestamp()))
+		assert.True(t, dataUpdater.StorageInitialized())
+		assert.Equal(t, item2.GetTimestamp(), dataUpdater.GetVersion())
+		all, _ := dataStorage.GetAll(data.Datatests)
+		assert.Equal(t, 2, len(all))
+		assert.False(t, dataUpdater.UpsertBatch(items, item2.GetTimestamp()))
+	})
+	t.Run("upsert batch with error", func(t *testing.T) {
+		mockDataStorage := datastorage.NewMockDataStorage(datastorage.NewInMemoryDataStorage())
+		mockDataStorage.SetErr(fmt.Errorf("fake error"))
+		dataUpdater := NewDataUpdaterImpl(mockDataStorage)
+		items := map[interfaces.Category]map[string]interfaces.Item{data.Datatests: {item1.GetId(): item1}}
+		assert.False(t, dataUpdater.UpsertBatch(items, item1.GetTimestamp()))
+		assert.Equal(t, interfaces.DataStorageUpdateError, dataUpdater.getCurrentState().ErrorTrack.ErrorType)
+	})
+}
+
+func TestResync(t *testing.T) {
+	t.Run("reordered patches", func(t *testing.T) {
+		dataStorage := datastorage.NewInMemoryDataStorage()
+		dataUpdater := NewDataUpdaterImpl(dataStorage)
+		older, newer, missed := data.NewTestItem(false), data.NewTestItem(false), data.NewTestItem(false)
+		require.True(t, dataUpdater.Init(map[interfaces.Category]map[string]interfaces.Item{data.Datatests: {item1.GetId(): item1}}, item1.GetTimestamp()))
+		assert.True(t, dataUpdater.Upsert(data.Datatests, newer.GetId(), newer, newer.GetTimestamp()))
+		assert.False(t, dataUpdater.ResyncRequested())
+
+		// the delayed patch is applied, but some patches could be missed
+		assert.True(t, dataUpdater.Upsert(data.Datatests, older.GetId(), older, older.GetTimestamp()))
+		assert.True(t, dataUpdater.ResyncRequested())
+		assert.Equal(t, newer.GetTimestamp(), dataUpdater.GetVersion())
+
+		// the full data of the same version fill the gaps
+		all := map[interfaces.Category]map[string]interfaces.Item{data.Datatests: {
+			item1.GetId(): item1, older.GetId(): older, missed.GetId(): missed, newer.GetId(): newer,
+		}}
+		require.True(t, dataUpdater.Init(all, newer.GetTimestamp()))
+		assert.False(t, dataUpdater.ResyncRequested())
+		item, _ := dataStorage.Get(data.Datatests, missed.GetId())
+		assert.Equal(t, missed, item)
+		items, _ := dataStorage.GetAll(data.Datatests)
+		assert.Equal(t, 4, len(items))
+	})
+	t.Run("items absent from the full data are removed", func(t *testing.T) {
+		dataStorage := datastorage.NewInMemoryDataStorage()
+		dataUpdater := NewDataUpdaterImpl(dataStorage)
+		deleted, older, newer := data.NewTestItem(false), data.NewTestItem(false), data.NewTestItem(false)
+		require.True(t, dataUpdater.Init(map[interfaces.Category]map[string]interfaces.Item{data.Datatests: {
+			item1.GetId(): item1, deleted.GetId(): deleted,
+		}}, deleted.GetTimestamp()))
+		assert.True(t, dataUpdater.Upsert(data.Datatests, newer.GetId(), newer, newer.GetTimestamp()))
+		assert.True(t, dataUpdater.Upsert(data.Datatests, older.GetId(), older, older.GetTimestamp()))
+		require.True(t, dataUpdater.ResyncRequested())
+
+		// the item was deleted in the gap, it's not in the full data
+		all := map[interfaces.Category]map[string]interfaces.Item{data.Datatests: {
+			item1.GetId(): item1, older.GetId(): older, newer.GetId(): newer,
+		}}
+		require.True(t, dataUpdater.Init(all, newer.GetTimestamp()))
+		assert.False(t, dataUpdater.ResyncRequested())
+		item, _ := dataStorage.Get(data.Datatests, deleted.GetId())
+		assert.Nil(t, item)
+		items, _ := dataStorage.GetAll(data.Datatests)
+		assert.Equal(t, 3, len(items))
+		assert.Equal(t, 3, len(all[data.Datatests]), "the full data are not modified")
+	})
+	t.Run("storage behind the latest version", func(t *testing.T) {
+		dataStorage := &fakePersistentStorage{InMemoryDataStorage: datastorage.NewInMemoryDataStorage()}
+		dataUpdater := NewDataUpdaterImpl(dataStorage)
+		defer dataUpdater.close()
+		item2 := data.NewTestItem(false)
+		require.True(t, dataUpdater.Init(map[interfaces.Category]map[string]interfaces.Item{data.Datatests: {item1.GetId(): item1}}, item1.GetTimestamp()))
+		// the storage is flushed
+		dataStorage.InMemoryDataStorage = datastorage.NewInMemoryDataStorage()
+		assert.True(t, dataUpdater.Upsert(data.Datatests, item2.GetId(), item2, item2.GetTimestamp()))
+		assert.True(t, dataUpdater.ResyncRequested())
+	})
+}
+
+func TestSubscribeDataUpdate(t *testing.T) {
+	dataUpdater := NewDataUpdaterImpl(datastorage.NewInMemoryDataStorage())
+	ch, cancel := dataUpdater.SubscribeDataUpdate()
+	items := map[string]interfaces.Item{item1.GetId(): item1}
+	all := map[interfaces.Category]map[string]interfaces.Item{data.Datatests: items}
+	assert.True(t, dataUpdater.Init(all, int64(1)))
+	item2 := data.NewTestItem(false)
+	assert.True(t, dataUpdater.Upsert(data.Datatests, item2.GetId(), item2, int64(2)))
+	// signals are coalesced
+	_, ok := <-ch
+	assert.True(t, ok)
+	assert.Equal(t, 0, len(ch))
+	// an outdated upsert doesn't change the data
+	assert.False(t, dataUpdater.Upsert(data.Datatests, item2.GetId(), item2, int64(1)))
+	assert.Equal(t, 0, len(ch))
+	cancel()
+	_, ok = <-ch
+	assert.False(t, ok)
+	cancel()
+}
+
+func TestStorageMonitor(t *testing.T) {
+	t.Run("persistent storage", func(t *testing.T) {
+		dataUpdater := NewDataUpdaterImpl(&fakePersistentStorage{InMemoryDataStorage: datastorage.NewInMemoryDataStorage()})
+		defer dataUpdater.close()
+		assert.NotNil(t, dataUpdater.monitor)
+		assert.NotNil(t, dataUpdater.latest)
+	})
+	t.Run("cached persistent storage", func(t *testing.T) {
+		storage := datastorage.NewCachedDataStorage(&fakePersistentStorage{InMemoryDataStorage: datastorage.NewInMemoryDataStorage()}, time.Minute)
+		dataUpdater := NewDataUpdaterImpl(storage)
+		defer dataUpdater.close()
+		assert.NotNil(t, dataUpdater.monitor)
+		assert.NotNil(t, dataUpdater.latest)
+	})
+	t.Run("cached in-memory storage", func(t *testing.T) {
+		storage := datastorage.NewCachedDataStorage(datastorage.NewInMemoryDataStorage(), time.Minute)
+		dataUpdater := NewDataUpdaterImpl(storage)
+		assert.Nil(t, dataUpdater.monitor)
+		assert.Nil(t, dataUpdater.latest)
+	})
+}
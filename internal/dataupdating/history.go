@@ -0,0 +1,166 @@
+package dataupdating
+
+import (
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/types/data"
+	"github.com/featbit/featbit-go-sdk/internal/util/log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const DefaultHistorySize = 10
+
+// History keeps the last versions of each flag and segment applied by the DataUpdater,
+// a feature flag could be pinned to one of them as an emergency rollback.
+//
+// A pin takes effect only in the SDK, it's released once a newer version of the flag is received.
+type History struct {
+	size     int
+	lock     sync.RWMutex
+	versions map[Category]map[string][]ItemVersion
+	// the pinned flags, map[string]Item replaced on each change, so that the evaluations read them without lock
+	pins    atomic.Value
+	pinLock sync.Mutex
+}
+
+func NewHistory(size int) *History {
+	if size <= 0 {
+		size = DefaultHistorySize
+	}
+	h := &History{size: size, versions: make(map[Category]map[string][]ItemVersion)}
+	h.pins.Store(map[string]Item{})
+	return h
+}
+
+// record keeps the new items of the changes, the pin of a changed flag is released
+func (h *History) record(events []ChangeEvent) {
+	if len(events) == 0 {
+		return
+	}
+	now := time.Now()
+	h.lock.Lock()
+	for _, event := range events {
+		if event.NewItem == nil {
+			continue
+		}
+		items, ok := h.versions[event.Category]
+		if !ok {
+			items = make(map[string][]ItemVersion)
+			h.versions[event.Category] = items
+		}
+		versions := append(items[event.Key], ItemVersion{Version: event.NewItem.GetTimestamp(), ReceivedAt: now, Item: event.NewItem})
+		if len(versions) > h.size {
+			versions = append([]ItemVersion(nil), versions[len(versions)-h.size:]...)
+		}
+		items[event.Key] = versions
+	}
+	h.lock.Unlock()
+	for _, event := range events {
+		if event.Category == data.Features && h.Unpin(event.Key) {
+			log.LogInfo("FB GO SDK: flag %s is unpinned by a newer version", event.Key)
+		}
+	}
+}
+
+// Get returns the versions of an item kept in the history, the newest first
+func (h *History) Get(category Category, key string) []ItemVersion {
+	pinned := h.getPinned(key)
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	versions := h.versions[category][key]
+	res := make([]ItemVersion, 0, len(versions))
+	for i := len(versions) - 1; i >= 0; i-- {
+		v := versions[i]
+		v.Pinned = category == data.Features && pinned != nil && pinned.GetTimestamp() == v.Version
+		res = append(res, v)
+	}
+	return res
+}
+
+// Pin pins a feature flag to a version kept in the history, false if the version is not found
+func (h *History) Pin(key string, version int64) bool {
+	h.lock.RLock()
+	var item Item
+	for _, v := range h.versions[data.Features][key] {
+		if v.Version == version {
+			item = v.Item
+		}
+	}
+	h.lock.RUnlock()
+	if item == nil {
+		return false
+	}
+	h.updatePins(func(pins map[string]Item) {
+		pins[key] = item
+	})
+	return true
+}
+
+// Unpin removes the pin of a feature flag, false if the flag is not pinned
+func (h *History) Unpin(key string) bool {
+	if h.getPinned(key) == nil {
+		return false
+	}
+	h.updatePins(func(pins map[string]Item) {
+		delete(pins, key)
+	})
+	return true
+}
+
+func (h *History) updatePins(update func(pins map[string]Item)) {
+	h.pinLock.Lock()
+	defer h.pinLock.Unlock()
+	old := h.pins.Load().(map[string]Item)
+	pins := make(map[string]Item, len(old)+1)
+	for k, v := range old {
+		pins[k] = v
+	}
+	update(pins)
+	h.pins.Store(pins)
+}
+
+func (h *History) getPinned(key string) Item {
+	return h.pins.Load().(map[string]Item)[key]
+}
+
+// View returns a view of the data in which the pinned flags replace the latest ones
+func (h *History) View(view DataStorageView) DataStorageView {
+	return &pinnedView{DataStorageView: view, history: h}
+}
+
+type pinnedView struct {
+	DataStorageView
+	history *History
+}
+
+func (v *pinnedView) Get(category Category, key string) (Item, error) {
+	if category == data.Features {
+		if item := v.history.getPinned(key); item != nil {
+			return item, nil
+		}
+	}
+	return v.DataStorageView.Get(category, key)
+}
+
+func (v *pinnedView) GetAll(category Category) (map[string]Item, error) {
+	items, err := v.DataStorageView.GetAll(category)
+	pins := v.history.pins.Load().(map[string]Item)
+	if err != nil || category != data.Features || len(pins) == 0 {
+		return items, err
+	}
+	res := make(map[string]Item, len(items)+len(pins))
+	for key, item := range items {
+		res[key] = item
+	}
+	for key, item := range pins {
+		res[key] = item
+	}
+	return res, nil
+}
+
+// EnableHistory keeps the last versions of each flag and segment applied from now on, it should be called before any update
+func (d *DataUpdaterImpl) EnableHistory(size int) *History {
+	d.history = NewHistory(size)
+	return d.history
+}
@@ -0,0 +1,78 @@
+package dataupdating
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/datastorage"
+	"github.com/featbit/featbit-go-sdk/internal/types/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	t.Run("last versions", func(t *testing.T) {
+		dataUpdater := NewDataUpdaterImpl(datastorage.NewInMemoryDataStorage())
+		history := dataUpdater.EnableHistory(2)
+		first := data.NewTestItem(false)
+		key := first.GetId()
+		require.True(t, dataUpdater.Init(map[interfaces.Category]map[string]interfaces.Item{data.Datatests: {key: first}}, first.GetTimestamp()))
+		second, third := data.NewTestItem(false), data.NewTestItem(false)
+		require.True(t, dataUpdater.Upsert(data.Datatests, key, second, second.GetTimestamp()))
+		require.True(t, dataUpdater.Upsert(data.Datatests, key, third, third.GetTimestamp()))
+		// an ignored upsert is not recorded
+		dataUpdater.Upsert(data.Datatests, key, first, first.GetTimestamp())
+
+		versions := history.Get(data.Datatests, key)
+		require.Equal(t, 2, len(versions))
+		assert.Equal(t, third, versions[0].Item)
+		assert.Equal(t, third.GetTimestamp(), versions[0].Version)
+		assert.Equal(t, second, versions[1].Item)
+		assert.False(t, versions[0].ReceivedAt.IsZero())
+		assert.Empty(t, history.Get(data.Datatests, "unknown"))
+	})
+
+	t.Run("pin", func(t *testing.T) {
+		storage := datastorage.NewInMemoryDataStorage()
+		dataUpdater := NewDataUpdaterImpl(storage)
+		history := dataUpdater.EnableHistory(0)
+		view := history.View(storage)
+		now := time.Now()
+		v1, v2, v3 := newTestFlag(t, now.Add(-time.Minute)), newTestFlag(t, now), newTestFlag(t, now.Add(time.Minute))
+		require.True(t, dataUpdater.Upsert(data.Features, "flag", v1, v1.GetTimestamp()))
+		require.True(t, dataUpdater.Upsert(data.Features, "flag", v2, v2.GetTimestamp()))
+
+		assert.False(t, history.Pin("flag", 1))
+		assert.False(t, history.Pin("unknown", v1.GetTimestamp()))
+		assert.True(t, history.Pin("flag", v1.GetTimestamp()))
+		versions := history.Get(data.Features, "flag")
+		assert.False(t, versions[0].Pinned)
+		assert.True(t, versions[1].Pinned)
+		item, _ := view.Get(data.Features, "flag")
+		assert.Same(t, v1, item)
+		all, _ := view.GetAll(data.Features)
+		assert.Same(t, v1, all["flag"])
+		item, _ = storage.Get(data.Features, "flag")
+		assert.Same(t, v2, item, "the storage is not changed")
+		assert.True(t, history.Unpin("flag"))
+		assert.False(t, history.Unpin("flag"))
+		item, _ = view.Get(data.Features, "flag")
+		assert.Same(t, v2, item)
+
+		// a newer version releases the pin
+		require.True(t, history.Pin("flag", v1.GetTimestamp()))
+		require.True(t, dataUpdater.Upsert(data.Features, "flag", v3, v3.GetTimestamp()))
+		item, _ = view.Get(data.Features, "flag")
+		assert.Same(t, v3, item)
+		assert.Equal(t, 3, len(history.Get(data.Features, "flag")))
+	})
+}
+
+func newTestFlag(t *testing.T, updatedAt time.Time) *data.FeatureFlag {
+	var flag data.FeatureFlag
+	js := fmt.Sprintf(`{"key":"flag","isEnabled":true,"updatedAt":"%s"}`, updatedAt.Format(time.RFC3339Nano))
+	require.NoError(t, json.Unmarshal([]byte(js), &flag))
+	return &flag
+}
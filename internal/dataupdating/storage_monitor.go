@@ -0,0 +1,160 @@
+package dataupdating
+
+import (
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/util/log"
+	"sync"
+	"time"
+)
+
+const defaultStorageProbeInterval = time.Second
+
+// probedStorage is implemented by the PersistentDataStorage that sets the interval of its availability probes
+type probedStorage interface {
+	// ProbeInterval returns the interval of the probes, the default interval is used if it's not positive
+	ProbeInterval() time.Duration
+}
+
+// storageMonitor tracks the availability of a PersistentDataStorage: the storage is probed periodically,
+// and marked as unavailable by the errors of the write operations. Once the storage is back, refresh is called
+// to write the latest data into it again.
+type storageMonitor struct {
+	storage   PersistentDataStorage
+	interval  time.Duration
+	refresh   func() error
+	lock      sync.Mutex
+	status    DataStorageStatus
+	failures  uint64
+	listeners []chan DataStorageStatus
+	closeOnce sync.Once
+	closeCh   chan struct{}
+}
+
+func newStorageMonitor(storage PersistentDataStorage, interval time.Duration, refresh func() error) *storageMonitor {
+	m := &storageMonitor{
+		storage:  storage,
+		interval: interval,
+		refresh:  refresh,
+		status:   DataStorageStatus{Available: true, Since: time.Now()},
+		closeCh:  make(chan struct{}),
+	}
+	go m.probeRoutine()
+	return m
+}
+
+func (m *storageMonitor) probeRoutine() {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = m.probe()
+		case <-m.closeCh:
+			return
+		}
+	}
+}
+
+// probe checks the availability of the storage, and writes the latest data into the storage if it's back
+func (m *storageMonitor) probe() error {
+	if err := m.storage.CheckAvailability(); err != nil {
+		m.setUnavailable(err)
+		return err
+	}
+	m.lock.Lock()
+	status := m.status
+	failures := m.failures
+	m.lock.Unlock()
+	if status.Available && !status.NeedsRefresh {
+		return nil
+	}
+	if status.NeedsRefresh {
+		if err := m.refresh(); err != nil {
+			log.LogError("FB GO SDK: failed to refresh the data storage: %v", err)
+			m.setUnavailable(err)
+			return err
+		}
+		log.LogInfo("FB GO SDK: the latest data are written into the data storage again")
+	}
+	m.setAvailable(failures)
+	return nil
+}
+
+func (m *storageMonitor) setUnavailable(err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.failures++
+	m.status.LastError = err.Error()
+	if !m.status.Available {
+		return
+	}
+	log.LogWarn("FB GO SDK: data storage is unavailable: %v", err)
+	m.status.Available = false
+	m.status.NeedsRefresh = true
+	m.status.Since = time.Now()
+	m.broadcast()
+}
+
+// setAvailable marks the storage as available unless an error has occurred since the given number of failures
+func (m *storageMonitor) setAvailable(failures uint64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if failures != m.failures {
+		return
+	}
+	log.LogInfo("FB GO SDK: data storage is available")
+	m.status.Available = true
+	m.status.NeedsRefresh = false
+	m.status.Since = time.Now()
+	m.broadcast()
+}
+
+// broadcast sends the current status to the listeners, the caller should hold the lock
+func (m *storageMonitor) broadcast() {
+	for _, ch := range m.listeners {
+		select {
+		case ch <- m.status:
+		default:
+		}
+	}
+}
+
+func (m *storageMonitor) getStatus() DataStorageStatus {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.status
+}
+
+func (m *storageMonitor) addListener() (<-chan DataStorageStatus, func()) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	listener := make(chan DataStorageStatus, defaultListenerNums)
+	m.listeners = append(m.listeners, listener)
+	var once sync.Once
+	remove := func() {
+		once.Do(func() {
+			m.lock.Lock()
+			defer m.lock.Unlock()
+			for i, ch := range m.listeners {
+				if ch == listener {
+					m.listeners = append(m.listeners[:i], m.listeners[i+1:]...)
+					close(ch)
+					break
+				}
+			}
+		})
+	}
+	return listener, remove
+}
+
+func (m *storageMonitor) close() {
+	m.closeOnce.Do(func() {
+		close(m.closeCh)
+		m.lock.Lock()
+		defer m.lock.Unlock()
+		for _, ch := range m.listeners {
+			close(ch)
+		}
+		m.listeners = nil
+	})
+}
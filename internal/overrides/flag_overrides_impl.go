@@ -0,0 +1,174 @@
+package overrides
+
+import (
+	"encoding/json"
+	"fmt"
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/util"
+	"github.com/featbit/featbit-go-sdk/internal/util/log"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+type override struct {
+	value     string
+	predicate UserPredicate
+}
+
+// FlagOverridesImpl is the default implementation of interfaces.FlagOverrides.
+//
+// The overrides come from 3 sources, in the order of priority:
+// the programmatic overrides, the overrides file and the static values.
+// The overrides file is reloaded if its modification time or size changes.
+type FlagOverridesImpl struct {
+	values       map[string]string
+	fileValues   map[string]string
+	programmatic map[string]override
+	filePath     string
+	fileModTime  time.Time
+	fileSize     int64
+	lock         sync.RWMutex
+	closeOnce    sync.Once
+	closeCh      chan struct{}
+}
+
+// NewFlagOverridesImpl creates an instance of FlagOverridesImpl, the overrides file is loaded immediately if given and then
+// reloaded every reloadInterval; a zero or negative reloadInterval disables the reloading.
+func NewFlagOverridesImpl(values map[string]string, filePath string, reloadInterval time.Duration) (*FlagOverridesImpl, error) {
+	f := &FlagOverridesImpl{
+		values:       values,
+		programmatic: make(map[string]override),
+		filePath:     filePath,
+		closeCh:      make(chan struct{}),
+	}
+	if filePath != "" {
+		if _, err := f.reload(); err != nil {
+			return nil, err
+		}
+		if reloadInterval > 0 {
+			go f.watchRoutine(reloadInterval)
+		}
+	}
+	return f, nil
+}
+
+// ParseOverrides parses a json or yaml document that maps the flag keys to the values
+func ParseOverrides(bytes []byte, isYaml bool) (map[string]string, error) {
+	var raw map[string]interface{}
+	var err error
+	if isYaml {
+		err = yaml.Unmarshal(bytes, &raw)
+	} else {
+		err = json.Unmarshal(bytes, &raw)
+	}
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[string]string, len(raw))
+	for key, value := range raw {
+		if value == nil {
+			return nil, fmt.Errorf("null value of flag %s", key)
+		}
+		v, err := util.ValueToString(value)
+		if err != nil {
+			return nil, err
+		}
+		ret[key] = v
+	}
+	return ret, nil
+}
+
+func isYamlFile(filePath string) bool {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// reload loads the overrides file if it's changed since the last loading, returns true if the file is reloaded
+func (f *FlagOverridesImpl) reload() (bool, error) {
+	info, err := os.Stat(f.filePath)
+	if err != nil {
+		return false, err
+	}
+	f.lock.RLock()
+	changed := f.fileValues == nil || !info.ModTime().Equal(f.fileModTime) || info.Size() != f.fileSize
+	f.lock.RUnlock()
+	if !changed {
+		return false, nil
+	}
+	bytes, err := util.ReadFile(f.filePath)
+	if err != nil {
+		return false, err
+	}
+	values, err := ParseOverrides(bytes, isYamlFile(f.filePath))
+	if err != nil {
+		return false, err
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.fileValues = values
+	f.fileModTime = info.ModTime()
+	f.fileSize = info.Size()
+	return true, nil
+}
+
+func (f *FlagOverridesImpl) watchRoutine(reloadInterval time.Duration) {
+	log.LogDebug("flag overrides watcher is starting")
+	ticker := time.NewTicker(reloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			reloaded, err := f.reload()
+			if err != nil {
+				log.LogError("FB GO SDK: error in reloading flag overrides file %s, keep the last overrides: %v", f.filePath, err)
+			} else if reloaded {
+				log.LogInfo("FB GO SDK: flag overrides file %s is reloaded", f.filePath)
+			}
+		case <-f.closeCh:
+			log.LogDebug("flag overrides watcher is over")
+			return
+		}
+	}
+}
+
+// Get returns the override of the flag for the user, the predicate is called out of the lock,
+// so that it could set or remove the overrides
+func (f *FlagOverridesImpl) Get(flagKey string, user FBUser) (string, bool) {
+	f.lock.RLock()
+	o, programmatic := f.programmatic[flagKey]
+	v, ok := f.fileValues[flagKey]
+	if !ok {
+		v, ok = f.values[flagKey]
+	}
+	f.lock.RUnlock()
+	if programmatic && (o.predicate == nil || o.predicate(user)) {
+		return o.value, true
+	}
+	return v, ok
+}
+
+func (f *FlagOverridesImpl) Set(flagKey string, value string, predicate UserPredicate) {
+	if flagKey == "" {
+		return
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.programmatic[flagKey] = override{value: value, predicate: predicate}
+}
+
+func (f *FlagOverridesImpl) Remove(flagKey string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	delete(f.programmatic, flagKey)
+}
+
+func (f *FlagOverridesImpl) Close() error {
+	f.closeOnce.Do(func() {
+		close(f.closeCh)
+	})
+	return nil
+}
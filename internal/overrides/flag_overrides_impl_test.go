@@ -0,0 +1,112 @@
+package overrides
+
+import (
+	"github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var user1, _ = interfaces.NewUserBuilder("user-1").Custom("role", "qa").Build()
+var user2, _ = interfaces.NewUserBuilder("user-2").Build()
+
+func writeFile(t *testing.T, path string, content string) {
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+}
+
+func TestParseOverrides(t *testing.T) {
+	t.Run("json", func(t *testing.T) {
+		values, err := ParseOverrides([]byte(`{"ff-bool": true, "ff-number": 1.5, "ff-string": "on", "ff-json": {"code": 200}}`), false)
+		require.NoError(t, err)
+		assert.Equal(t, "true", values["ff-bool"])
+		assert.Equal(t, "1.5", values["ff-number"])
+		assert.Equal(t, "on", values["ff-string"])
+		assert.Equal(t, `{"code":200}`, values["ff-json"])
+	})
+	t.Run("yaml", func(t *testing.T) {
+		values, err := ParseOverrides([]byte("ff-bool: true\nff-number: 33\nff-string: on\nff-json:\n  code: 200\n"), true)
+		require.NoError(t, err)
+		assert.Equal(t, "true", values["ff-bool"])
+		assert.Equal(t, "33", values["ff-number"])
+		assert.Equal(t, "on", values["ff-string"])
+		assert.Equal(t, `{"code":200}`, values["ff-json"])
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := ParseOverrides([]byte(`{"ff-bool": tru`), false)
+		assert.Error(t, err)
+		_, err = ParseOverrides([]byte(`{"ff-bool": null}`), false)
+		assert.Error(t, err)
+	})
+}
+
+func TestGetOverrides(t *testing.T) {
+	t.Run("static values", func(t *testing.T) {
+		overrides, err := NewFlagOverridesImpl(map[string]string{"ff-test": "on"}, "", 0)
+		require.NoError(t, err)
+		defer overrides.Close()
+		v, ok := overrides.Get("ff-test", user1)
+		assert.True(t, ok)
+		assert.Equal(t, "on", v)
+		_, ok = overrides.Get("ff-not-overridden", user1)
+		assert.False(t, ok)
+	})
+	t.Run("programmatic overrides", func(t *testing.T) {
+		overrides, _ := NewFlagOverridesImpl(map[string]string{"ff-test": "on"}, "", 0)
+		defer overrides.Close()
+		overrides.Set("ff-test", "off", func(user interfaces.FBUser) bool {
+			return user.Get("role") == "qa"
+		})
+		v, _ := overrides.Get("ff-test", user1)
+		assert.Equal(t, "off", v)
+		v, _ = overrides.Get("ff-test", user2)
+		assert.Equal(t, "on", v)
+		overrides.Remove("ff-test")
+		v, _ = overrides.Get("ff-test", user1)
+		assert.Equal(t, "on", v)
+	})
+	t.Run("predicate changing the overrides", func(t *testing.T) {
+		overrides, _ := NewFlagOverridesImpl(nil, "", 0)
+		defer overrides.Close()
+		// a single use override
+		overrides.Set("ff-test", "off", func(user interfaces.FBUser) bool {
+			overrides.Remove("ff-test")
+			return true
+		})
+		v, ok := overrides.Get("ff-test", user1)
+		assert.True(t, ok)
+		assert.Equal(t, "off", v)
+		_, ok = overrides.Get("ff-test", user1)
+		assert.False(t, ok)
+	})
+	t.Run("file and reload", func(t *testing.T) {
+		dir, _ := ioutil.TempDir("", "overrides")
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "overrides.json")
+		writeFile(t, path, `{"ff-test": "file"}`)
+		overrides, err := NewFlagOverridesImpl(map[string]string{"ff-test": "on", "ff-static": "on"}, path, 10*time.Millisecond)
+		require.NoError(t, err)
+		defer overrides.Close()
+		v, _ := overrides.Get("ff-test", user1)
+		assert.Equal(t, "file", v)
+		v, _ = overrides.Get("ff-static", user1)
+		assert.Equal(t, "on", v)
+		// invalid content, keep the last overrides
+		writeFile(t, path, `{"ff-test": `)
+		time.Sleep(50 * time.Millisecond)
+		v, _ = overrides.Get("ff-test", user1)
+		assert.Equal(t, "file", v)
+		writeFile(t, path, `{"ff-test": "reloaded"}`)
+		assert.Eventually(t, func() bool {
+			v, _ = overrides.Get("ff-test", user1)
+			return v == "reloaded"
+		}, time.Second, 10*time.Millisecond)
+	})
+	t.Run("file not found", func(t *testing.T) {
+		_, err := NewFlagOverridesImpl(nil, "not-existed.yaml", 0)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,58 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"time"
+)
+
+// storedItem is the serialized form of an Item in the persistent data storages,
+// the timestamp is kept outside the item to be read without knowing the type of item.
+type storedItem struct {
+	Timestamp int64           `json:"timestamp"`
+	Id        string          `json:"id"`
+	Archived  bool            `json:"archived,omitempty"`
+	Item      json.RawMessage `json:"item,omitempty"`
+}
+
+// EncodeItem serializes an Item to be saved in a persistent data storage, an archived item is saved as a placeholder
+func EncodeItem(item Item) ([]byte, error) {
+	stored := storedItem{Timestamp: item.GetTimestamp(), Id: item.GetId(), Archived: item.IsArchived()}
+	if !item.IsArchived() {
+		bytes, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		stored.Item = bytes
+	}
+	return json.Marshal(stored)
+}
+
+// DecodeItem deserializes an Item of the given category, which is encoded by EncodeItem
+func DecodeItem(category Category, bytes []byte) (Item, error) {
+	var stored storedItem
+	if err := json.Unmarshal(bytes, &stored); err != nil {
+		return nil, err
+	}
+	if stored.Archived {
+		return NewArchivedItem(stored.Id, stored.Timestamp), nil
+	}
+	var item Item
+	switch category {
+	case Features:
+		item = &FeatureFlag{}
+	case Segments:
+		item = &Segment{}
+	default:
+		return nil, fmt.Errorf("unsupported category: %s", category.GetName())
+	}
+	if err := json.Unmarshal(stored.Item, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func timestampToTime(timestamp int64) time.Time {
+	return time.Unix(0, timestamp*int64(time.Millisecond)).UTC()
+}
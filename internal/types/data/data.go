@@ -71,3 +71,41 @@ func (d *Data) ToStorageType() map[Category]map[string]Item {
 type TimestampData struct {
 	Timestamp int64 `json:"timestamp"`
 }
+
+// FromStorageType converts the items of a storage to the full data, the archived items are kept as the archived flags and segments
+func FromStorageType(allData map[Category]map[string]Item) *Data {
+	d := &Data{EventType: FullOp, FeatureFlags: []FeatureFlag{}, Segments: []Segment{}}
+	for _, item := range allData[Features] {
+		if flag, ok := item.(*FeatureFlag); ok {
+			d.FeatureFlags = append(d.FeatureFlags, *flag)
+		} else if item.IsArchived() {
+			d.FeatureFlags = append(d.FeatureFlags, FeatureFlag{Key: item.GetId(), Deleted: true, timestamp: item.GetTimestamp()})
+		}
+	}
+	for _, item := range allData[Segments] {
+		if segment, ok := item.(*Segment); ok {
+			d.Segments = append(d.Segments, *segment)
+		} else if item.IsArchived() {
+			d.Segments = append(d.Segments, Segment{Id: item.GetId(), Deleted: true, timestamp: item.GetTimestamp()})
+		}
+	}
+	sort.SliceStable(d.FeatureFlags, func(i, j int) bool {
+		return d.FeatureFlags[i].GetTimestamp() < d.FeatureFlags[j].GetTimestamp() ||
+			(d.FeatureFlags[i].GetTimestamp() == d.FeatureFlags[j].GetTimestamp() && d.FeatureFlags[i].Key < d.FeatureFlags[j].Key)
+	})
+	sort.SliceStable(d.Segments, func(i, j int) bool {
+		return d.Segments[i].GetTimestamp() < d.Segments[j].GetTimestamp() ||
+			(d.Segments[i].GetTimestamp() == d.Segments[j].GetTimestamp() && d.Segments[i].Id < d.Segments[j].Id)
+	})
+	for _, flag := range d.FeatureFlags {
+		if flag.GetTimestamp() > d.timestamp {
+			d.timestamp = flag.GetTimestamp()
+		}
+	}
+	for _, segment := range d.Segments {
+		if segment.GetTimestamp() > d.timestamp {
+			d.timestamp = segment.GetTimestamp()
+		}
+	}
+	return d
+}
@@ -6,6 +6,12 @@ import (
 	"time"
 )
 
+// the conditions of the segments, same as the clauses of the evaluator
+const (
+	isInSegmentProperty  = "User is in segment"
+	notInSegmentProperty = "User is not in segment"
+)
+
 type FeatureFlag struct {
 	Id                    string       `json:"id"`
 	Deleted               bool         `json:"isArchived"`
@@ -19,6 +25,7 @@ type FeatureFlag struct {
 	TargetUsers           []TargetUser `json:"targetUsers"`
 	Rules                 []TargetRule `json:"rules"`
 	Fallthrough           Fallthrough  `json:"fallthrough"`
+	Tags                  []string     `json:"tags"`
 	timestamp             int64
 	variationMap          map[string]Variation
 }
@@ -70,3 +77,31 @@ func (f *FeatureFlag) GetFlagValue(variationId string) string {
 	}
 	return ""
 }
+
+func (f *FeatureFlag) MarshalJSON() ([]byte, error) {
+	type tmpFeatureFlag FeatureFlag
+	return json.Marshal(struct {
+		*tmpFeatureFlag
+		UpdatedAt time.Time `json:"updatedAt"`
+	}{
+		tmpFeatureFlag: (*tmpFeatureFlag)(f),
+		UpdatedAt:      timestampToTime(f.timestamp),
+	})
+}
+
+// ReferencedSegments returns the ids of the segments used by the rules of the flag
+func (f *FeatureFlag) ReferencedSegments() []string {
+	var res []string
+	for _, rule := range f.Rules {
+		for _, condition := range rule.Conditions {
+			if condition.Op != "" || (condition.Property != isInSegmentProperty && condition.Property != notInSegmentProperty) {
+				continue
+			}
+			var segments []string
+			if err := json.Unmarshal([]byte(condition.Value), &segments); err == nil {
+				res = append(res, segments...)
+			}
+		}
+	}
+	return res
+}
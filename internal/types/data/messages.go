@@ -43,3 +43,11 @@ type All struct {
 func (a *All) IsProcessData() bool {
 	return a.IsSyncMessage() && (a.Data.EventType == FullOp || a.Data.EventType == PatchOp)
 }
+
+// NewAll returns the data-sync message of the given data
+func NewAll(data *Data) *All {
+	return &All{
+		Message: Message{MessageType: syncMessageType},
+		Data:    *data,
+	}
+}
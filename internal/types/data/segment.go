@@ -16,9 +16,10 @@ type Segment struct {
 	Id          string       `json:"id"`
 	Deleted     bool         `json:"isArchived"`
 	Rules       []TargetRule `json:"rules"`
+	BigSegment  bool         `json:"isBigSegment"`
 	timestamp   int64
-	includedSet map[string]struct{}
-	excludedSet map[string]struct{}
+	includedSet userSet
+	excludedSet userSet
 }
 
 func (s *Segment) GetId() string {
@@ -51,26 +52,35 @@ func (s *Segment) UnmarshalJSON(bytes []byte) error {
 		return err
 	}
 	s.timestamp = tmp.UpdatedAt.UnixNano() / int64(time.Millisecond)
-	s.includedSet = make(map[string]struct{}, len(tmp.Included))
-	for _, uid := range tmp.Included {
-		s.includedSet[uid] = struct{}{}
-	}
-	s.excludedSet = make(map[string]struct{}, len(tmp.Excluded))
-	for _, uid := range tmp.Excluded {
-		s.excludedSet[uid] = struct{}{}
-	}
+	s.includedSet = newUserSet(tmp.Included)
+	s.excludedSet = newUserSet(tmp.Excluded)
 	return nil
 }
 
+func (s *Segment) MarshalJSON() ([]byte, error) {
+	type tmpSegment Segment
+	return json.Marshal(struct {
+		*tmpSegment
+		UpdatedAt time.Time `json:"updatedAt"`
+		Included  []string  `json:"included"`
+		Excluded  []string  `json:"excluded"`
+	}{
+		tmpSegment: (*tmpSegment)(s),
+		UpdatedAt:  timestampToTime(s.timestamp),
+		Included:   userSetKeys(s.includedSet),
+		Excluded:   userSetKeys(s.excludedSet),
+	})
+}
+
 func (s *Segment) ToArchivedItem() interfaces.Item {
 	return NewArchivedItem(s.Id, s.timestamp)
 }
 
 func (s *Segment) MatchUser(user string) int {
-	if _, ok := s.excludedSet[user]; ok {
+	if s.excludedSet != nil && s.excludedSet.contains(user) {
 		return SegmentExcludeUser
 	}
-	if _, ok := s.includedSet[user]; ok {
+	if s.includedSet != nil && s.includedSet.contains(user) {
 		return SegmentIncludeUser
 	}
 	return SegmentFallthrough
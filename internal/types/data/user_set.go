@@ -0,0 +1,119 @@
+package data
+
+import (
+	"sort"
+)
+
+// compactUserSetThreshold is the size above which the user keys of a segment are held in a compactUserSet
+const compactUserSetThreshold = 1000
+
+// userSet is a set of the user keys included or excluded by a segment
+type userSet interface {
+	contains(key string) bool
+	// sortedKeys returns the keys in order
+	sortedKeys() []string
+	size() int
+}
+
+// newUserSet returns a map for the small sets, fast to look up, and a compactUserSet for the large ones
+func newUserSet(keys []string) userSet {
+	if len(keys) <= compactUserSetThreshold {
+		set := make(mapUserSet, len(keys))
+		for _, key := range keys {
+			set[key] = struct{}{}
+		}
+		return set
+	}
+	return newCompactUserSet(keys)
+}
+
+// userSetKeys returns the sorted keys of a set, an empty slice if the set is nil
+func userSetKeys(set userSet) []string {
+	if set == nil {
+		return []string{}
+	}
+	return set.sortedKeys()
+}
+
+type mapUserSet map[string]struct{}
+
+func (m mapUserSet) contains(key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
+func (m mapUserSet) sortedKeys() []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func (m mapUserSet) size() int {
+	return len(m)
+}
+
+// compactUserSet holds the sorted keys in a single string, delimited by their end offsets, a key is looked up by binary search.
+// Compared to a map, it saves the string header and the hash bucket of each key, about 50 bytes by key.
+type compactUserSet struct {
+	data string
+	ends []uint32
+}
+
+func newCompactUserSet(keys []string) *compactUserSet {
+	sorted := make([]string, len(keys))
+	copy(sorted, keys)
+	sort.Strings(sorted)
+	total := 0
+	for _, key := range sorted {
+		total += len(key)
+	}
+	buf := make([]byte, 0, total)
+	ends := make([]uint32, 0, len(sorted))
+	for i, key := range sorted {
+		if i > 0 && key == sorted[i-1] {
+			continue
+		}
+		buf = append(buf, key...)
+		ends = append(ends, uint32(len(buf)))
+	}
+	return &compactUserSet{data: string(buf), ends: ends}
+}
+
+func (c *compactUserSet) at(i int) string {
+	var start uint32
+	if i > 0 {
+		start = c.ends[i-1]
+	}
+	return c.data[start:c.ends[i]]
+}
+
+func (c *compactUserSet) contains(key string) bool {
+	lo, hi := 0, len(c.ends)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		switch k := c.at(mid); {
+		case k == key:
+			return true
+		case k < key:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+	return false
+}
+
+func (c *compactUserSet) sortedKeys() []string {
+	res := make([]string, len(c.ends))
+	for i := range c.ends {
+		res[i] = c.at(i)
+	}
+	return res
+}
+
+func (c *compactUserSet) size() int {
+	return len(c.ends)
+}
@@ -0,0 +1,122 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"runtime"
+	"testing"
+)
+
+func testUserKeys(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("user-%08d", i*7919%n)
+	}
+	return keys
+}
+
+func TestUserSet(t *testing.T) {
+	for _, n := range []int{0, 10, compactUserSetThreshold + 1} {
+		keys := testUserKeys(n)
+		set := newUserSet(append(keys, keys...))
+		if n > compactUserSetThreshold {
+			assert.IsType(t, &compactUserSet{}, set)
+		} else {
+			assert.IsType(t, mapUserSet{}, set)
+		}
+		assert.Equal(t, n, set.size(), "the duplicated keys are removed")
+		for _, key := range keys {
+			assert.True(t, set.contains(key))
+		}
+		assert.False(t, set.contains(""))
+		assert.False(t, set.contains("user-"))
+		assert.False(t, set.contains("user-99999999"))
+		sorted := set.sortedKeys()
+		assert.Equal(t, n, len(sorted))
+		for i := 1; i < len(sorted); i++ {
+			assert.True(t, sorted[i-1] < sorted[i])
+		}
+	}
+}
+
+func TestSegmentMatchUser(t *testing.T) {
+	for _, n := range []int{10, compactUserSetThreshold * 2} {
+		included, excluded := testUserKeys(n), []string{"user-00000001", "excluded"}
+		js, _ := json.Marshal(map[string]interface{}{"id": "segment", "included": included, "excluded": excluded})
+		var segment Segment
+		require.NoError(t, json.Unmarshal(js, &segment))
+		assert.Equal(t, SegmentIncludeUser, segment.MatchUser("user-00000000"))
+		assert.Equal(t, SegmentExcludeUser, segment.MatchUser("user-00000001"))
+		assert.Equal(t, SegmentExcludeUser, segment.MatchUser("excluded"))
+		assert.Equal(t, SegmentFallthrough, segment.MatchUser("unknown"))
+
+		// round trip
+		js, _ = json.Marshal(&segment)
+		var copied Segment
+		require.NoError(t, json.Unmarshal(js, &copied))
+		assert.Equal(t, segment.includedSet.sortedKeys(), copied.includedSet.sortedKeys())
+		assert.Equal(t, segment.excludedSet.sortedKeys(), copied.excludedSet.sortedKeys())
+	}
+	var archived Segment
+	assert.Equal(t, SegmentFallthrough, archived.MatchUser("user"))
+	js, err := json.Marshal(&archived)
+	require.NoError(t, err)
+	assert.Contains(t, string(js), `"included":[]`)
+}
+
+const benchmarkUserNums = 200000
+
+func heapInUse() uint64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapInuse
+}
+
+func benchmarkUserSets() map[string]func([]string) userSet {
+	return map[string]func([]string) userSet{
+		"Map": func(keys []string) userSet {
+			set := make(mapUserSet, len(keys))
+			for _, key := range keys {
+				set[key] = struct{}{}
+			}
+			return set
+		},
+		"Compact": func(keys []string) userSet {
+			return newCompactUserSet(keys)
+		},
+	}
+}
+
+// BenchmarkUserSetMemory reports the heap used by a set of 200k user keys
+func BenchmarkUserSetMemory(b *testing.B) {
+	for name, create := range benchmarkUserSets() {
+		b.Run(name, func(b *testing.B) {
+			var set userSet
+			var bytes int64
+			for i := 0; i < b.N; i++ {
+				set = nil
+				before := heapInUse()
+				// the keys are created one by one as the json decoder does, the set retains them or not
+				set = create(testUserKeys(benchmarkUserNums))
+				bytes += int64(heapInUse()) - int64(before)
+			}
+			b.ReportMetric(float64(bytes)/float64(b.N)/float64(set.size()), "B/key")
+		})
+	}
+}
+
+func BenchmarkUserSetContains(b *testing.B) {
+	keys := testUserKeys(benchmarkUserNums)
+	for name, create := range benchmarkUserSets() {
+		b.Run(name, func(b *testing.B) {
+			set := create(keys)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				set.contains(keys[i%len(keys)])
+			}
+		})
+	}
+}
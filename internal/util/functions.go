@@ -1,6 +1,7 @@
 package util
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/featbit/featbit-go-sdk/internal/util/log"
 	"io/ioutil"
@@ -119,3 +120,30 @@ func BuildToken(envSecret string) string {
 	result := fmt.Sprintf("%s%s%s%s%s", part1, part2, part3, part4, part5)
 	return result
 }
+
+// ValueToString converts a flag value to its string representation in FeatBit:
+// string is returned as is, bool and numbers are formatted and the other types are serialized in json
+func ValueToString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case json.Number:
+		return v.String(), nil
+	default:
+		bytes, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		return string(bytes), nil
+	}
+}
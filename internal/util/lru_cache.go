@@ -0,0 +1,86 @@
+package util
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type lruEntry struct {
+	key       string
+	value     interface{}
+	expiredAt time.Time
+}
+
+// LRUCache is a cache bounded by size, the least recently used entry is evicted first.
+// The entries expire after a TTL if it's positive. It's safe for concurrent use.
+type LRUCache struct {
+	size    int
+	ttl     time.Duration
+	lock    sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+// NewLRUCache creates a LRUCache holding at most size entries
+func NewLRUCache(size int, ttl time.Duration) *LRUCache {
+	if size <= 0 {
+		size = 1
+	}
+	return &LRUCache{size: size, ttl: ttl, entries: make(map[string]*list.Element, size), order: list.New()}
+}
+
+// Get returns the value of a key, false if the key is not found or expired
+func (c *LRUCache) Get(key string) (interface{}, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*lruEntry)
+	if c.ttl > 0 && time.Now().After(entry.expiredAt) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+// Add adds or replaces the value of a key
+func (c *LRUCache) Add(key string, value interface{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	var expiredAt time.Time
+	if c.ttl > 0 {
+		expiredAt = time.Now().Add(c.ttl)
+	}
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		entry.value, entry.expiredAt = value, expiredAt
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value, expiredAt: expiredAt})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// Len returns the number of entries, including the expired ones not yet evicted
+func (c *LRUCache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.order.Len()
+}
+
+// Purge removes all the entries
+func (c *LRUCache) Purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.entries = make(map[string]*list.Element, c.size)
+	c.order.Init()
+}
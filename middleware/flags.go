@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"github.com/featbit/featbit-go-sdk"
+	"github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/util/log"
+	"net/http"
+	"sync"
+)
+
+var userNotInRequest = fmt.Errorf("no user attached to the request")
+
+type requestFlagsKey struct{}
+
+// requestFlags holds the user of a request and its lazily computed flag snapshot
+type requestFlags struct {
+	user       interfaces.FBUser
+	userErr    error
+	evaluation interfaces.FBEvaluation
+	once       sync.Once
+	state      interfaces.AllFlagState
+	stateErr   error
+}
+
+func (rf *requestFlags) allFlagState() (interfaces.AllFlagState, error) {
+	if rf.userErr != nil {
+		return nil, rf.userErr
+	}
+	rf.once.Do(func() {
+		rf.state, rf.stateErr = rf.evaluation.AllLatestFlagsVariations(rf.user)
+	})
+	return rf.state, rf.stateErr
+}
+
+// Flags returns a net/http middleware that extracts the interfaces.FBUser of each request with the given UserExtractor,
+// and attaches it to the request context with a snapshot of all the flags for this user.
+// The snapshot is computed at the first call of FlagsFromContext, so the requests that don't use it have no cost.
+//
+// If the QAOverrides middleware wraps this one, the overrides of the request are honoured by the snapshot.
+//
+//	handler := middleware.Flags(client, extractor)(mux)
+//	// in the handlers
+//	state, err := middleware.FlagsFromContext(r.Context())
+//	on, _, _ := state.GetBoolVariation("flag key", false)
+func Flags(client *featbit.FBClient, extractor UserExtractor) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rf := &requestFlags{evaluation: client.WithContext(r.Context())}
+			rf.user, rf.userErr = extractor(r)
+			if rf.userErr != nil {
+				log.LogDebug("FB GO SDK: no user extracted from request %v: %v", r.URL.Path, rf.userErr)
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestFlagsKey{}, rf)))
+		})
+	}
+}
+
+// UserFromContext returns the user attached by the Flags middleware, false if none
+func UserFromContext(ctx context.Context) (interfaces.FBUser, bool) {
+	if rf, ok := ctx.Value(requestFlagsKey{}).(*requestFlags); ok && rf.userErr == nil {
+		return rf.user, true
+	}
+	return interfaces.FBUser{}, false
+}
+
+// FlagsFromContext returns the snapshot of all the flags for the user attached by the Flags middleware.
+// The snapshot is computed once per request and sends the insight events of the flags read from it.
+//
+// An error is returned if no user is attached to the request, or the evaluation fails.
+func FlagsFromContext(ctx context.Context) (interfaces.AllFlagState, error) {
+	rf, ok := ctx.Value(requestFlagsKey{}).(*requestFlags)
+	if !ok {
+		return nil, userNotInRequest
+	}
+	return rf.allFlagState()
+}
+
+// RequireFlag returns a net/http middleware that serves the next handler only if a bool flag is on for the user
+// attached by the Flags middleware, it must be wrapped by the Flags middleware.
+//
+// If the flag is off or can't be evaluated, the request is served by the fallback handler, http.NotFoundHandler if nil.
+//
+//	mux.Handle("/beta", middleware.RequireFlag("beta-page", nil)(betaHandler))
+func RequireFlag(flagKey string, fallback http.Handler) func(http.Handler) http.Handler {
+	if fallback == nil {
+		fallback = http.NotFoundHandler()
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if IsFlagOn(r.Context(), flagKey) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			fallback.ServeHTTP(w, r)
+		})
+	}
+}
+
+// IsFlagOn returns true if a bool flag is on for the user attached by the Flags middleware,
+// false if the flag is off or can't be evaluated
+func IsFlagOn(ctx context.Context, flagKey string) bool {
+	state, err := FlagsFromContext(ctx)
+	if err != nil {
+		log.LogDebug("FB GO SDK: flag %v is considered as off: %v", flagKey, err)
+		return false
+	}
+	on, _, _ := state.GetBoolVariation(flagKey, false)
+	return on
+}
@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"context"
+	"github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type claimsKey struct{}
+
+func TestUserExtractor(t *testing.T) {
+	extractor := NewUserExtractorBuilder(FromHeader("X-User-Id"), FromContext(claimsKey{})).
+		Name(FromCookie("user-name")).
+		Custom("ip", FromRemoteIP(true)).
+		Custom("ua", FromUserAgent()).
+		Custom("country", FromHeader("X-Country")).
+		Build()
+
+	t.Run("from header and cookie", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("X-User-Id", "user-1")
+		r.Header.Set("X-Forwarded-For", "10.0.0.1, 10.0.0.2")
+		r.Header.Set("User-Agent", "test-agent")
+		r.AddCookie(&http.Cookie{Name: "user-name", Value: "name-1"})
+		user, err := extractor(r)
+		require.NoError(t, err)
+		assert.Equal(t, "user-1", user.GetKey())
+		assert.Equal(t, "name-1", user.GetUserName())
+		assert.Equal(t, "10.0.0.1", user.Get("ip"))
+		assert.Equal(t, "test-agent", user.Get("ua"))
+		assert.Equal(t, "", user.Get("country"))
+	})
+	t.Run("from context", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/", nil)
+		r = r.WithContext(context.WithValue(r.Context(), claimsKey{}, "user-2"))
+		user, err := extractor(r)
+		require.NoError(t, err)
+		assert.Equal(t, "user-2", user.GetKey())
+		assert.Equal(t, "user-2", user.GetUserName())
+		assert.Equal(t, "192.0.2.1", user.Get("ip"))
+	})
+	t.Run("no key", func(t *testing.T) {
+		_, err := extractor(httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, userKeyNotFound, err)
+	})
+}
+
+func TestFlags(t *testing.T) {
+	client := newOfflineClient(t)
+	defer client.Close()
+	require.NoError(t, client.SetOverride("ff-test-bool", true, func(user interfaces.FBUser) bool {
+		return user.Get("country") == "fr"
+	}))
+	extractor := NewUserExtractorBuilder(FromHeader("X-User-Id")).Custom("country", FromHeader("X-Country")).Build()
+	flags := Flags(client, extractor)
+	serve := func(h http.Handler, r *http.Request) (int, string) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		body, _ := ioutil.ReadAll(w.Result().Body)
+		return w.Code, string(body)
+	}
+	newRequest := func(userKey, country string) *http.Request {
+		r := httptest.NewRequest("GET", "/", nil)
+		if userKey != "" {
+			r.Header.Set("X-User-Id", userKey)
+		}
+		r.Header.Set("X-Country", country)
+		return r
+	}
+
+	t.Run("user and snapshot", func(t *testing.T) {
+		handler := flags(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := UserFromContext(r.Context())
+			require.True(t, ok)
+			state, err := FlagsFromContext(r.Context())
+			require.NoError(t, err)
+			state1, _ := FlagsFromContext(r.Context())
+			assert.Equal(t, state, state1)
+			v, _, _ := state.GetStringVariation("ff-test-string", "error")
+			_, _ = w.Write([]byte(user.GetKey() + "|" + v))
+		}))
+		_, body := serve(handler, newRequest("user-1", "us"))
+		assert.Equal(t, "user-1|others", body)
+	})
+	t.Run("no user", func(t *testing.T) {
+		handler := flags(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, ok := UserFromContext(r.Context())
+			assert.False(t, ok)
+			_, err := FlagsFromContext(r.Context())
+			assert.Equal(t, userKeyNotFound, err)
+		}))
+		serve(handler, newRequest("", "us"))
+		_, err := FlagsFromContext(context.Background())
+		assert.Equal(t, userNotInRequest, err)
+	})
+	t.Run("require flag", func(t *testing.T) {
+		ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("beta"))
+		})
+		handler := flags(RequireFlag("ff-test-bool", nil)(ok))
+		code, body := serve(handler, newRequest("user-1", "fr"))
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "beta", body)
+		code, _ = serve(handler, newRequest("user-1", "us"))
+		assert.Equal(t, http.StatusNotFound, code)
+		code, _ = serve(handler, newRequest("", "fr"))
+		assert.Equal(t, http.StatusNotFound, code)
+
+		fallback := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "/", http.StatusFound)
+		})
+		code, _ = serve(flags(RequireFlag("ff-test-off", fallback)(ok)), newRequest("user-1", "fr"))
+		assert.Equal(t, http.StatusFound, code)
+	})
+	t.Run("with QA overrides", func(t *testing.T) {
+		token, _ := MintOverrideToken(signingKey, map[string]string{"ff-test-off": "true"}, time.Minute)
+		ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+		handler := QAOverrides(QAOverrideConfig{Key: signingKey})(flags(RequireFlag("ff-test-off", nil)(ok)))
+		r := newRequest("user-1", "us")
+		r.Header.Set(DefaultOverrideHeader, token)
+		code, _ := serve(handler, r)
+		assert.Equal(t, http.StatusOK, code)
+	})
+}
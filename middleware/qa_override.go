@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/featbit/featbit-go-sdk"
+	"github.com/featbit/featbit-go-sdk/internal/util/log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultOverrideHeader the default HTTP header carrying the QA override token
+	DefaultOverrideHeader = "X-FeatBit-Override"
+	// DefaultOverrideCookie the default cookie carrying the QA override token
+	DefaultOverrideCookie = "featbit-override"
+)
+
+var (
+	tokenMalformed = fmt.Errorf("malformed override token")
+	tokenInvalid   = fmt.Errorf("invalid override token signature")
+	tokenExpired   = fmt.Errorf("override token expired")
+	emptyKey       = fmt.Errorf("empty signing key")
+)
+
+type overridePayload struct {
+	Flags     map[string]string `json:"flags"`
+	ExpiresAt int64             `json:"exp,omitempty"`
+}
+
+func sign(key []byte, payload string) string {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// MintOverrideToken creates a token signed with the given key, which maps the flag keys to the variation values or ids.
+// The token expires after ttl, a zero or negative ttl means that the token never expires.
+//
+// The token is normally sent by QA engineers in the DefaultOverrideHeader header or the DefaultOverrideCookie cookie.
+func MintOverrideToken(key []byte, overrides map[string]string, ttl time.Duration) (string, error) {
+	if len(key) == 0 {
+		return "", emptyKey
+	}
+	payload := overridePayload{Flags: overrides}
+	if ttl > 0 {
+		payload.ExpiresAt = time.Now().Add(ttl).Unix()
+	}
+	bytes, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(bytes)
+	return strings.Join([]string{encoded, sign(key, encoded)}, "."), nil
+}
+
+// ParseOverrideToken validates the signature and the expiration of a token created by MintOverrideToken,
+// returns the overrides carried by the token
+func ParseOverrideToken(key []byte, token string) (map[string]string, error) {
+	if len(key) == 0 {
+		return nil, emptyKey
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, tokenMalformed
+	}
+	if !hmac.Equal([]byte(sign(key, parts[0])), []byte(parts[1])) {
+		return nil, tokenInvalid
+	}
+	bytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, tokenMalformed
+	}
+	var payload overridePayload
+	if err = json.Unmarshal(bytes, &payload); err != nil {
+		return nil, tokenMalformed
+	}
+	if payload.ExpiresAt > 0 && time.Now().Unix() > payload.ExpiresAt {
+		return nil, tokenExpired
+	}
+	return payload.Flags, nil
+}
+
+// QAOverrideConfig the configuration of the QA override middleware
+type QAOverrideConfig struct {
+	// Key the HMAC key to verify the signature of tokens, required
+	Key []byte
+	// Header the HTTP header carrying the token, DefaultOverrideHeader if empty
+	Header string
+	// Cookie the cookie carrying the token, DefaultOverrideCookie if empty; the header has priority over the cookie
+	Cookie string
+}
+
+// QAOverrides returns a net/http middleware that reads a signed override token from the request header or cookie,
+// validates it and attaches the overrides to the request context, see featbit.WithRequestOverrides.
+//
+// The evaluations made with FBClient.WithContext(r.Context()) in the next handlers honour the overrides.
+// A request with an invalid or expired token is served without overrides.
+//
+//	handler := middleware.QAOverrides(middleware.QAOverrideConfig{Key: key})(mux)
+func QAOverrides(config QAOverrideConfig) func(http.Handler) http.Handler {
+	header := config.Header
+	if header == "" {
+		header = DefaultOverrideHeader
+	}
+	cookie := config.Cookie
+	if cookie == "" {
+		cookie = DefaultOverrideCookie
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := r.Header.Get(header)
+			if token == "" {
+				if c, err := r.Cookie(cookie); err == nil {
+					token = c.Value
+				}
+			}
+			if token != "" {
+				overrides, err := ParseOverrideToken(config.Key, token)
+				if err != nil {
+					log.LogWarn("FB GO SDK: ignore the QA override token, %v", err)
+				} else {
+					r = r.WithContext(featbit.WithRequestOverrides(r.Context(), overrides))
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"encoding/base64"
+	"github.com/featbit/featbit-go-sdk"
+	"github.com/featbit/featbit-go-sdk/fixtures"
+	"github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+var fakeEnvSecret = base64.URLEncoding.EncodeToString([]byte("http://fake"))
+var testUser1, _ = interfaces.NewUserBuilder("test-user-1").Custom("country", "us").Build()
+var signingKey = []byte("qa-signing-key")
+
+func newOfflineClient(t *testing.T) *featbit.FBClient {
+	config := featbit.FBConfig{Offline: true, StartWait: 1 * time.Millisecond}
+	client, _ := featbit.MakeCustomFBClient(fakeEnvSecret, "ws://fake-url", "http://fake-url", config)
+	// fixtures are loaded from the root directory
+	wd, _ := os.Getwd()
+	require.NoError(t, os.Chdir(".."))
+	jsonBytes, err := fixtures.LoadFBClientTestData()
+	_ = os.Chdir(wd)
+	require.NoError(t, err)
+	ok, _ := client.InitializeFromExternalJson(string(jsonBytes))
+	require.True(t, ok)
+	return client
+}
+
+func TestOverrideToken(t *testing.T) {
+	t.Run("mint and parse", func(t *testing.T) {
+		token, err := MintOverrideToken(signingKey, map[string]string{"ff-test-string": "email"}, time.Minute)
+		require.NoError(t, err)
+		overrides, err := ParseOverrideToken(signingKey, token)
+		require.NoError(t, err)
+		assert.Equal(t, "email", overrides["ff-test-string"])
+	})
+	t.Run("invalid signature", func(t *testing.T) {
+		token, _ := MintOverrideToken([]byte("other key"), map[string]string{"ff-test-string": "email"}, time.Minute)
+		_, err := ParseOverrideToken(signingKey, token)
+		assert.Equal(t, tokenInvalid, err)
+	})
+	t.Run("expired", func(t *testing.T) {
+		token, _ := MintOverrideToken(signingKey, map[string]string{"ff-test-string": "email"}, -time.Minute)
+		_, err := ParseOverrideToken(signingKey, token)
+		require.NoError(t, err)
+		payload := base64.RawURLEncoding.EncodeToString([]byte(`{"flags":{"ff-test-string":"email"},"exp":1}`))
+		_, err = ParseOverrideToken(signingKey, payload+"."+sign(signingKey, payload))
+		assert.Equal(t, tokenExpired, err)
+	})
+	t.Run("malformed", func(t *testing.T) {
+		_, err := ParseOverrideToken(signingKey, "malformed")
+		assert.Equal(t, tokenMalformed, err)
+		_, err = MintOverrideToken(nil, nil, 0)
+		assert.Equal(t, emptyKey, err)
+	})
+}
+
+func TestQAOverrides(t *testing.T) {
+	client := newOfflineClient(t)
+	defer client.Close()
+	handler := QAOverrides(QAOverrideConfig{Key: signingKey})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v, detail, _ := client.WithContext(r.Context()).Variation("ff-test-string", testUser1, "error")
+		_, _ = w.Write([]byte(v + "|" + detail.Reason))
+	}))
+	serve := func(r *http.Request) string {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		body, _ := ioutil.ReadAll(w.Result().Body)
+		return string(body)
+	}
+	token, _ := MintOverrideToken(signingKey, map[string]string{"ff-test-string": "email"}, time.Minute)
+
+	t.Run("no token", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/", nil)
+		assert.Equal(t, "others|"+featbit.ReasonFallthrough, serve(r))
+	})
+	t.Run("token in header", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set(DefaultOverrideHeader, token)
+		assert.Equal(t, "email|"+featbit.ReasonOverride, serve(r))
+	})
+	t.Run("token in cookie", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.AddCookie(&http.Cookie{Name: DefaultOverrideCookie, Value: token})
+		assert.Equal(t, "email|"+featbit.ReasonOverride, serve(r))
+	})
+	t.Run("invalid token", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set(DefaultOverrideHeader, token+"x")
+		assert.Equal(t, "others|"+featbit.ReasonFallthrough, serve(r))
+	})
+	t.Run("overrides don't leak to the client", func(t *testing.T) {
+		v, _, _ := client.Variation("ff-test-string", testUser1, "error")
+		assert.Equal(t, "others", v)
+	})
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/featbit/featbit-go-sdk/interfaces"
+	"net"
+	"net/http"
+	"strings"
+)
+
+var userKeyNotFound = fmt.Errorf("user key not found in request")
+
+// UserExtractor builds the interfaces.FBUser of a request
+type UserExtractor func(r *http.Request) (interfaces.FBUser, error)
+
+// AttributeSource reads an attribute from a request, returns an empty string if the attribute is not found
+type AttributeSource func(r *http.Request) string
+
+// FromHeader reads an attribute from the request header
+func FromHeader(name string) AttributeSource {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// FromCookie reads an attribute from the request cookie
+func FromCookie(name string) AttributeSource {
+	return func(r *http.Request) string {
+		if c, err := r.Cookie(name); err == nil {
+			return c.Value
+		}
+		return ""
+	}
+}
+
+// FromContext reads an attribute from the request context, the value should be a string or a fmt.Stringer.
+//
+// It's useful to read the auth claims set by an authentication middleware.
+func FromContext(key interface{}) AttributeSource {
+	return func(r *http.Request) string {
+		switch v := r.Context().Value(key).(type) {
+		case string:
+			return v
+		case fmt.Stringer:
+			return v.String()
+		default:
+			return ""
+		}
+	}
+}
+
+// FromRemoteIP reads the IP address of the client, the first address of the X-Forwarded-For header if trustProxy is true,
+// otherwise the remote address of the connection
+func FromRemoteIP(trustProxy bool) AttributeSource {
+	return func(r *http.Request) string {
+		if trustProxy {
+			if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+				return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+			}
+		}
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			return host
+		}
+		return r.RemoteAddr
+	}
+}
+
+// FromUserAgent reads the user agent of the request
+func FromUserAgent() AttributeSource {
+	return func(r *http.Request) string {
+		return r.UserAgent()
+	}
+}
+
+// UserExtractorBuilder builds an UserExtractor from the attribute sources
+//
+//	extractor := middleware.NewUserExtractorBuilder(middleware.FromHeader("X-User-Id")).
+//	    Name(middleware.FromCookie("user-name")).
+//	    Custom("ip", middleware.FromRemoteIP(false)).
+//	    Custom("ua", middleware.FromUserAgent()).
+//	    Build()
+type UserExtractorBuilder struct {
+	key    []AttributeSource
+	name   AttributeSource
+	custom map[string]AttributeSource
+}
+
+// NewUserExtractorBuilder creates an UserExtractorBuilder, the user key is read from the first source that returns a non-empty value
+func NewUserExtractorBuilder(key AttributeSource, fallbacks ...AttributeSource) *UserExtractorBuilder {
+	return &UserExtractorBuilder{key: append([]AttributeSource{key}, fallbacks...), custom: map[string]AttributeSource{}}
+}
+
+// Name sets the source of the user name, the user key is used if not set or empty
+func (u *UserExtractorBuilder) Name(source AttributeSource) *UserExtractorBuilder {
+	u.name = source
+	return u
+}
+
+// Custom sets the source of a custom attribute, the attribute is ignored if empty
+func (u *UserExtractorBuilder) Custom(attribute string, source AttributeSource) *UserExtractorBuilder {
+	u.custom[attribute] = source
+	return u
+}
+
+// Build creates the UserExtractor, which returns an error if the user key is not found
+func (u *UserExtractorBuilder) Build() UserExtractor {
+	key := u.key
+	name := u.name
+	custom := make(map[string]AttributeSource, len(u.custom))
+	for k, v := range u.custom {
+		custom[k] = v
+	}
+	return func(r *http.Request) (interfaces.FBUser, error) {
+		var userKey string
+		for _, source := range key {
+			if source != nil {
+				if userKey = source(r); userKey != "" {
+					break
+				}
+			}
+		}
+		if userKey == "" {
+			return interfaces.FBUser{}, userKeyNotFound
+		}
+		builder := interfaces.NewUserBuilder(userKey)
+		if name != nil {
+			if userName := name(r); userName != "" {
+				builder.UserName(userName)
+			}
+		}
+		for attribute, source := range custom {
+			if value := source(r); value != "" {
+				builder.Custom(attribute, value)
+			}
+		}
+		return builder.Build()
+	}
+}
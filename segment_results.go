@@ -0,0 +1,80 @@
+package featbit
+
+import (
+	. "github.com/featbit/featbit-go-sdk/interfaces"
+	"github.com/featbit/featbit-go-sdk/internal/types/data"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// segmentResult is the result of a segment for a user, it's valid until the segment is updated
+type segmentResult struct {
+	timestamp int64
+	matched   bool
+}
+
+// userSegmentResults keeps the results of the segments for a user, so that the segments referenced by many conditions
+// and many flags are matched once. A result is discarded once the timestamp of the segment changes.
+//
+// The results are kept during an evaluation, or across the evaluations if the evaluator has a segment cache.
+type userSegmentResults struct {
+	lock    sync.Mutex
+	results map[string]segmentResult
+}
+
+// segmentResults gives the results of the segments for the evaluation of a flag,
+// and records whether the evaluation depends on the memberships of the big segments
+type segmentResults struct {
+	*userSegmentResults
+	bigSegmentsUsed        bool
+	bigSegmentsUnavailable bool
+	bigSegmentsStale       bool
+}
+
+// forEvaluation returns the results of the segments for the evaluation of another flag, the results are shared
+// but not the use of the big segments
+func (s *segmentResults) forEvaluation() *segmentResults {
+	return &segmentResults{userSegmentResults: s.userSegmentResults}
+}
+
+func (s *userSegmentResults) get(segment *data.Segment) (bool, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	res, ok := s.results[segment.Id]
+	if !ok || res.timestamp != segment.GetTimestamp() {
+		return false, false
+	}
+	return res.matched, true
+}
+
+func (s *userSegmentResults) set(segment *data.Segment, matched bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.results == nil {
+		s.results = make(map[string]segmentResult)
+	}
+	s.results[segment.Id] = segmentResult{timestamp: segment.GetTimestamp(), matched: matched}
+}
+
+// userFingerprint identifies a user with all the attributes the segment rules could use,
+// two users with the same key but different attributes don't share the results of the segments
+func userFingerprint(user *FBUser) string {
+	attrs := user.CustomAttributes()
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	sb.WriteString(user.GetKey())
+	sb.WriteByte(0)
+	sb.WriteString(user.GetUserName())
+	for _, name := range names {
+		sb.WriteByte(0)
+		sb.WriteString(name)
+		sb.WriteByte('=')
+		sb.WriteString(attrs[name])
+	}
+	return sb.String()
+}